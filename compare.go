@@ -0,0 +1,222 @@
+package tdconv
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DiffKind is the kind of the difference.
+type DiffKind string
+
+// Kinds of the difference.
+const (
+	DiffAdded   DiffKind = "added"
+	DiffRemoved DiffKind = "removed"
+	DiffChanged DiffKind = "changed"
+)
+
+// SchemaDiff is the difference between two table sets.
+type SchemaDiff struct {
+	Tables []TableDiff `json:"tables"`
+}
+
+// TableDiff is the difference of a table.
+// The added table has all columns and keys as added, and the removed table has nothing else.
+type TableDiff struct {
+	Name    string            `json:"name"`
+	Kind    DiffKind          `json:"kind"`
+	Changes []AttributeChange `json:"changes,omitempty"`
+	Columns []ColumnDiff      `json:"columns,omitempty"`
+	Keys    []KeyDiff         `json:"keys,omitempty"`
+}
+
+// ColumnDiff is the difference of a column.
+// The added column has all attributes which are not zero value as the changes from the zero value.
+type ColumnDiff struct {
+	Name    string            `json:"name"`
+	Kind    DiffKind          `json:"kind"`
+	Changes []AttributeChange `json:"changes,omitempty"`
+}
+
+// AttributeChange is the change of an attribute like `type` or `not_null`.
+type AttributeChange struct {
+	Attribute string `json:"attribute"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+}
+
+// KeyDiff is the difference of a unique key or an index key, which is identified by the name.
+type KeyDiff struct {
+	Type string   `json:"type"` // "unique" or "index"
+	Name string   `json:"name"`
+	Kind DiffKind `json:"kind"`
+	From []string `json:"from,omitempty"`
+	To   []string `json:"to,omitempty"`
+}
+
+// Empty reports whether there is no difference.
+func (d *SchemaDiff) Empty() bool {
+	return d == nil || len(d.Tables) == 0
+}
+
+// Count returns the number of the added, removed and changed tables.
+func (d *SchemaDiff) Count() (added, removed, changed int) {
+	if d == nil {
+		return 0, 0, 0
+	}
+	for _, t := range d.Tables {
+		switch t.Kind {
+		case DiffAdded:
+			added++
+		case DiffRemoved:
+			removed++
+		case DiffChanged:
+			changed++
+		}
+	}
+	return added, removed, changed
+}
+
+// CompareTableSets returns the difference from a to b.
+// The tables, columns and keys are identified by the name, and the order of them is not compared.
+// The common column flag is not compared, because it depends on the sheet layout.
+// The differences are in the order of a, and the added ones follow in the order of b.
+func CompareTableSets(a, b *TableSet) *SchemaDiff {
+
+	if a == nil {
+		a = &TableSet{}
+	}
+	if b == nil {
+		b = &TableSet{}
+	}
+
+	d := &SchemaDiff{Tables: []TableDiff{}}
+
+	bm := map[string]*Table{}
+	for _, t := range b.Tables {
+		if t != nil {
+			bm[t.Name] = t
+		}
+	}
+	am := map[string]struct{}{}
+	for _, ta := range a.Tables {
+		if ta == nil {
+			continue
+		}
+		am[ta.Name] = struct{}{}
+		tb, ok := bm[ta.Name]
+		if !ok {
+			d.Tables = append(d.Tables, TableDiff{Name: ta.Name, Kind: DiffRemoved})
+			continue
+		}
+		if td := compareTables(ta, tb); td != nil {
+			d.Tables = append(d.Tables, *td)
+		}
+	}
+	for _, tb := range b.Tables {
+		if tb == nil {
+			continue
+		}
+		if _, ok := am[tb.Name]; ok {
+			continue
+		}
+		td := TableDiff{Name: tb.Name, Kind: DiffAdded}
+		if diff := compareTables(&Table{}, tb); diff != nil {
+			td.Changes, td.Columns, td.Keys = diff.Changes, diff.Columns, diff.Keys
+		}
+		d.Tables = append(d.Tables, td)
+	}
+
+	return d
+}
+
+// compareTables returns the difference of the table, or nil if no difference.
+func compareTables(a, b *Table) *TableDiff {
+
+	td := &TableDiff{Name: a.Name, Kind: DiffChanged}
+
+	if from, to := strings.Join(a.PKeyColumns, ", "), strings.Join(b.PKeyColumns, ", "); from != to {
+		td.Changes = append(td.Changes, AttributeChange{Attribute: "primary_key", From: from, To: to})
+	}
+
+	bm := map[string]Column{}
+	for _, c := range b.Columns {
+		bm[c.Name] = c
+	}
+	am := map[string]struct{}{}
+	for _, ca := range a.Columns {
+		am[ca.Name] = struct{}{}
+		cb, ok := bm[ca.Name]
+		if !ok {
+			td.Columns = append(td.Columns, ColumnDiff{Name: ca.Name, Kind: DiffRemoved})
+			continue
+		}
+		if cs := compareColumns(ca, cb); len(cs) > 0 {
+			td.Columns = append(td.Columns, ColumnDiff{Name: ca.Name, Kind: DiffChanged, Changes: cs})
+		}
+	}
+	for _, cb := range b.Columns {
+		if _, ok := am[cb.Name]; ok {
+			continue
+		}
+		td.Columns = append(td.Columns, ColumnDiff{Name: cb.Name, Kind: DiffAdded, Changes: compareColumns(Column{}, cb)})
+	}
+
+	td.Keys = append(compareKeys("unique", a.UniqueKeys, b.UniqueKeys), compareKeys("index", a.IndexKeys, b.IndexKeys)...)
+
+	if len(td.Changes) == 0 && len(td.Columns) == 0 && len(td.Keys) == 0 {
+		return nil
+	}
+	return td
+}
+
+// compareColumns returns the changes of the attributes of the column.
+func compareColumns(a, b Column) []AttributeChange {
+
+	var cs []AttributeChange
+	add := func(attr, from, to string) {
+		if from != to {
+			cs = append(cs, AttributeChange{Attribute: attr, From: from, To: to})
+		}
+	}
+
+	add("type", strings.TrimSpace(a.Type), strings.TrimSpace(b.Type))
+	add("primary_key", strconv.FormatBool(a.PKey), strconv.FormatBool(b.PKey))
+	add("not_null", strconv.FormatBool(a.NotNull), strconv.FormatBool(b.NotNull))
+	add("unique", strconv.FormatBool(a.Unique), strconv.FormatBool(b.Unique))
+	add("index", strconv.FormatBool(a.Index), strconv.FormatBool(b.Index))
+	add("option", a.Option, b.Option)
+	add("comment", a.Comment, b.Comment)
+
+	return cs
+}
+
+// compareKeys returns the differences of the keys identified by the name.
+func compareKeys(typ string, a, b []Key) []KeyDiff {
+
+	var ds []KeyDiff
+
+	bm := map[string]Key{}
+	for _, k := range b {
+		bm[k.Name] = k
+	}
+	am := map[string]struct{}{}
+	for _, ka := range a {
+		am[ka.Name] = struct{}{}
+		kb, ok := bm[ka.Name]
+		if !ok {
+			ds = append(ds, KeyDiff{Type: typ, Name: ka.Name, Kind: DiffRemoved, From: ka.Columns})
+			continue
+		}
+		if strings.Join(ka.Columns, ",") != strings.Join(kb.Columns, ",") {
+			ds = append(ds, KeyDiff{Type: typ, Name: ka.Name, Kind: DiffChanged, From: ka.Columns, To: kb.Columns})
+		}
+	}
+	for _, kb := range b {
+		if _, ok := am[kb.Name]; !ok {
+			ds = append(ds, KeyDiff{Type: typ, Name: kb.Name, Kind: DiffAdded, To: kb.Columns})
+		}
+	}
+
+	return ds
+}
@@ -0,0 +1,124 @@
+package tdconv_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+func TestCompareTableSets(t *testing.T) {
+
+	users := func(modify func(t *tdconv.Table)) *tdconv.Table {
+		t := &tdconv.Table{
+			Name: "users",
+			Columns: []tdconv.Column{
+				{Name: "id", Type: "INT", PKey: true, NotNull: true},
+				{Name: "name", Type: "VARCHAR(32)", Index: true, IsCommon: true},
+			},
+			PKeyColumns: []string{"id"},
+			IndexKeys:   []tdconv.Key{{Name: "name_key", Columns: []string{"name"}}},
+		}
+		if modify != nil {
+			modify(t)
+		}
+		return t
+	}
+
+	cases := []struct {
+		caseName string
+		a, b     *tdconv.TableSet
+		expected []tdconv.TableDiff
+	}{
+		{
+			caseName: "same",
+			a:        &tdconv.TableSet{Name: "a", Tables: []*tdconv.Table{users(nil)}},
+			b: &tdconv.TableSet{Name: "b", Tables: []*tdconv.Table{users(func(t *tdconv.Table) {
+				t.Columns[1].IsCommon = false
+			})}},
+			expected: []tdconv.TableDiff{},
+		},
+		{
+			caseName: "added and removed tables",
+			a:        &tdconv.TableSet{Tables: []*tdconv.Table{users(nil), {Name: "logs"}}},
+			b: &tdconv.TableSet{Tables: []*tdconv.Table{
+				{Name: "posts", Columns: []tdconv.Column{{Name: "id", Type: "INT", PKey: true}}, PKeyColumns: []string{"id"}},
+				users(nil),
+			}},
+			expected: []tdconv.TableDiff{
+				{Name: "logs", Kind: tdconv.DiffRemoved},
+				{
+					Name:    "posts",
+					Kind:    tdconv.DiffAdded,
+					Changes: []tdconv.AttributeChange{{Attribute: "primary_key", From: "", To: "id"}},
+					Columns: []tdconv.ColumnDiff{{
+						Name: "id",
+						Kind: tdconv.DiffAdded,
+						Changes: []tdconv.AttributeChange{
+							{Attribute: "type", From: "", To: "INT"},
+							{Attribute: "primary_key", From: "false", To: "true"},
+						},
+					}},
+				},
+			},
+		},
+		{
+			caseName: "changed columns and keys",
+			a:        &tdconv.TableSet{Tables: []*tdconv.Table{users(nil)}},
+			b: &tdconv.TableSet{Tables: []*tdconv.Table{users(func(t *tdconv.Table) {
+				t.Columns = []tdconv.Column{
+					{Name: "id", Type: "BIGINT", PKey: true, NotNull: true, Comment: "id"},
+					{Name: "email", Type: "TEXT"},
+				}
+				t.PKeyColumns = []string{"id", "email"}
+				t.UniqueKeys = []tdconv.Key{{Name: "email_uk", Columns: []string{"email"}}}
+				t.IndexKeys = []tdconv.Key{{Name: "name_key", Columns: []string{"email"}}}
+			})}},
+			expected: []tdconv.TableDiff{
+				{
+					Name:    "users",
+					Kind:    tdconv.DiffChanged,
+					Changes: []tdconv.AttributeChange{{Attribute: "primary_key", From: "id", To: "id, email"}},
+					Columns: []tdconv.ColumnDiff{
+						{
+							Name: "id",
+							Kind: tdconv.DiffChanged,
+							Changes: []tdconv.AttributeChange{
+								{Attribute: "type", From: "INT", To: "BIGINT"},
+								{Attribute: "comment", From: "", To: "id"},
+							},
+						},
+						{Name: "name", Kind: tdconv.DiffRemoved},
+						{
+							Name:    "email",
+							Kind:    tdconv.DiffAdded,
+							Changes: []tdconv.AttributeChange{{Attribute: "type", From: "", To: "TEXT"}},
+						},
+					},
+					Keys: []tdconv.KeyDiff{
+						{Type: "unique", Name: "email_uk", Kind: tdconv.DiffAdded, To: []string{"email"}},
+						{Type: "index", Name: "name_key", Kind: tdconv.DiffChanged, From: []string{"name"}, To: []string{"email"}},
+					},
+				},
+			},
+		},
+		{
+			caseName: "nil",
+			a:        nil,
+			b:        &tdconv.TableSet{Tables: []*tdconv.Table{{Name: "logs"}}},
+			expected: []tdconv.TableDiff{{Name: "logs", Kind: tdconv.DiffAdded}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			d := tdconv.CompareTableSets(c.a, c.b)
+			if !reflect.DeepEqual(d.Tables, c.expected) {
+				t.Errorf("value doesn't match (expected=%+v, actual=%+v)", c.expected, d.Tables)
+			}
+			if d.Empty() != (len(c.expected) == 0) {
+				t.Errorf("empty doesn't match (expected=%t, actual=%t)", len(c.expected) == 0, d.Empty())
+			}
+		})
+	}
+}
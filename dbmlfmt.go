@@ -0,0 +1,171 @@
+package tdconv
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DBMLFormatter is a formatter to output the table definision as DBML (https://dbml.dbdiagram.io).
+// The references are inferred in the same way as ERDFormatter.
+type DBMLFormatter struct {
+	formatter
+}
+
+// NewDBMLFormatter creates a new DBMLFormatter.
+// You can change some parameters of the DBMLFormatter with DBMLFormatOption.
+func NewDBMLFormatter(options ...DBMLFormatOption) (*DBMLFormatter, error) {
+
+	f := DBMLFormatter{}
+	f.setHeader(func(w io.Writer, _ *TableSet) {
+		fmt.Fprint(w,
+			"// This file generated by tdconv. DO NOT EDIT.\n"+
+				"// See more details at https://github.com/takuoki/tdconv.\n\n")
+	})
+	for _, opt := range options {
+		err := opt(&f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+// DBMLFormatOption changes some parameters of the DBMLFormatter.
+type DBMLFormatOption func(*DBMLFormatter) error
+
+// DBMLHeader changes the header.
+func DBMLHeader(fc func(w io.Writer, ts *TableSet)) DBMLFormatOption {
+	return func(f *DBMLFormatter) error {
+		f.setHeader(fc)
+		return nil
+	}
+}
+
+// DBMLTableHeader changes the header of each table.
+func DBMLTableHeader(fc func(w io.Writer, t *Table)) DBMLFormatOption {
+	return func(f *DBMLFormatter) error {
+		f.setTableHeader(fc)
+		return nil
+	}
+}
+
+// DBMLTableFooter changes the footer of each table.
+func DBMLTableFooter(fc func(w io.Writer, t *Table)) DBMLFormatOption {
+	return func(f *DBMLFormatter) error {
+		f.setTableFooter(fc)
+		return nil
+	}
+}
+
+// DBMLFooter changes the footer.
+func DBMLFooter(fc func(w io.Writer, ts *TableSet)) DBMLFormatOption {
+	return func(f *DBMLFormatter) error {
+		f.setFooter(fc)
+		return nil
+	}
+}
+
+// DBMLTypeMap overrides the type conversion to DBML column type.
+// The key is the base SQL type name, like `VARCHAR`, and it's case-insensitive.
+func DBMLTypeMap(m map[string]string) DBMLFormatOption {
+	return func(f *DBMLFormatter) error {
+		f.setTypeMap(m)
+		return nil
+	}
+}
+
+// Extension returns the extension of DBML file.
+func (f *DBMLFormatter) Extension() string {
+	return "dbml"
+}
+
+// Fprint outputs the table definision as DBML `Table` block.
+// The references are resolved in the table set output by Output or FprintTableSet,
+// including the tables in the other files with multi flag.
+func (f *DBMLFormatter) Fprint(w io.Writer, t *Table) {
+
+	if f == nil || t == nil {
+		return
+	}
+
+	refs := map[string]erdRelation{}
+	for _, r := range erdRelations(tableSetOf(w), t) {
+		refs[r.column.Name] = r
+	}
+	compositePK := len(t.PKeyColumns) > 1
+
+	fmt.Fprintf(w, "Table %s {\n", dbmlName(t.Name))
+	for _, c := range t.Columns {
+		var settings []string
+		if c.PKey && !compositePK {
+			settings = append(settings, "pk")
+		}
+		if strings.Contains(strings.ToUpper(c.Option), "AUTO_INCREMENT") {
+			settings = append(settings, "increment")
+		}
+		if c.NotNull && !(c.PKey && !compositePK) {
+			settings = append(settings, "not null")
+		}
+		if c.Unique {
+			settings = append(settings, "unique")
+		}
+		if r, ok := refs[c.Name]; ok {
+			settings = append(settings, fmt.Sprintf("ref: > %s.%s", dbmlName(r.ref.Name), dbmlName(r.refColumn)))
+		}
+		if c.Comment != "" {
+			settings = append(settings, "note: "+dbmlString(c.Comment))
+		}
+		s := fmt.Sprintf("  %s %s", dbmlName(c.Name), dbmlType(f.convType(c.Type, strings.TrimSpace)))
+		if len(settings) > 0 {
+			s += " [" + strings.Join(settings, ", ") + "]"
+		}
+		fmt.Fprintln(w, s)
+	}
+
+	if compositePK || len(t.UniqueKeys) > 0 || len(t.IndexKeys) > 0 {
+		fmt.Fprintln(w, "\n  Indexes {")
+		if compositePK {
+			fmt.Fprintf(w, "    %s [pk]\n", dbmlIndexColumns(t.PKeyColumns))
+		}
+		for _, k := range t.UniqueKeys {
+			fmt.Fprintf(w, "    %s [unique, name: %s]\n", dbmlIndexColumns(k.Columns), dbmlString(k.Name))
+		}
+		for _, k := range t.IndexKeys {
+			fmt.Fprintf(w, "    %s [name: %s]\n", dbmlIndexColumns(k.Columns), dbmlString(k.Name))
+		}
+		fmt.Fprintln(w, "  }")
+	}
+
+	fmt.Fprintln(w, "}")
+}
+
+func dbmlName(s string) string {
+	if erdIDRegexp.MatchString(s) {
+		return "\"" + strings.Replace(s, "\"", "\\\"", -1) + "\""
+	}
+	return s
+}
+
+func dbmlType(t string) string {
+	t = strings.TrimSpace(t)
+	if strings.ContainsAny(t, " \"") {
+		return "\"" + strings.Replace(t, "\"", "\\\"", -1) + "\""
+	}
+	return t
+}
+
+func dbmlString(s string) string {
+	return "'" + strings.Replace(strings.Replace(s, "\\", "\\\\", -1), "'", "\\'", -1) + "'"
+}
+
+func dbmlIndexColumns(cs []string) string {
+	if len(cs) == 1 {
+		return dbmlName(cs[0])
+	}
+	ns := make([]string, 0, len(cs))
+	for _, c := range cs {
+		ns = append(ns, dbmlName(c))
+	}
+	return "(" + strings.Join(ns, ", ") + ")"
+}
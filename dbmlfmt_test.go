@@ -0,0 +1,185 @@
+package tdconv_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+var mustDBMLFormatter = func(options ...tdconv.DBMLFormatOption) *tdconv.DBMLFormatter {
+	f, err := tdconv.NewDBMLFormatter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func TestNewDBMLFormatter(t *testing.T) {
+
+	errOptionFunc := func(*tdconv.DBMLFormatter) error {
+		return errors.New("error")
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.DBMLFormatOption
+		errMsg   string
+	}{
+		{
+			caseName: "success: default",
+		},
+		{
+			caseName: "success: set all options",
+			opts: []tdconv.DBMLFormatOption{
+				tdconv.DBMLHeader(nil),
+				tdconv.DBMLTableHeader(nil),
+				tdconv.DBMLTableFooter(nil),
+				tdconv.DBMLFooter(nil),
+				tdconv.DBMLTypeMap(map[string]string{"json": "string"}),
+			},
+		},
+		{
+			caseName: "failure: option error",
+			opts:     []tdconv.DBMLFormatOption{errOptionFunc},
+			errMsg:   "error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.NewDBMLFormatter(c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if endIndex := strings.Index(err.Error(), ":"); endIndex < 0 {
+					if err.Error() != c.errMsg {
+						t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+						return
+					}
+				} else if err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestDBMLFormatter_Extension(t *testing.T) {
+	var f *tdconv.DBMLFormatter
+	if f.Extension() != "dbml" {
+		t.Errorf("value doesn't match (expected=dbml, actual=%s)", f.Extension())
+	}
+}
+
+func TestDBMLFormatter_multi(t *testing.T) {
+
+	fs := tdconv.NewMemoryFileSystem()
+	if err := tdconv.Output(mustDBMLFormatter(), erdTestTableSet, true, "out", tdconv.OutputFileSystem(fs)); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	b, ok := fs.Files()["out/posts.dbml"]
+	if !ok {
+		t.Fatal("file must be output (out/posts.dbml)")
+	}
+	for _, s := range []string{"user_id \"INT UNSIGNED\" [not null, ref: > users.id]", "editor \"INT UNSIGNED\" [ref: > users.id]"} {
+		if !strings.Contains(string(b), s) {
+			t.Errorf("reference to the table in the other file must be output (expected=%s, actual=%s)", s, string(b))
+		}
+	}
+}
+
+func TestDBMLFormatter_Fprint(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.DBMLFormatter
+		t        *tdconv.Table
+		expected string
+	}{
+		{
+			caseName: "nil formatter",
+			f:        nil,
+			t:        docTestTable,
+			expected: "",
+		},
+		{
+			caseName: "table is nil",
+			f:        mustDBMLFormatter(),
+			t:        nil,
+			expected: "",
+		},
+		{
+			caseName: "standard output",
+			f:        mustDBMLFormatter(),
+			t:        docTestTable,
+			expected: "Table sample_table {\n" +
+				"  id \"INT UNSIGNED\" [pk, increment, note: 'this is id!']\n" +
+				"  foo VARCHAR(32) [not null, unique, note: 'a|b']\n" +
+				"  bar VARCHAR(32) [note: '<bar>']\n" +
+				"  created_at \"TIMESTAMP NULL\"\n" +
+				"\n  Indexes {\n" +
+				"    (foo, bar) [unique, name: 'foo_bar_key']\n" +
+				"    bar [name: 'bar_key']\n" +
+				"  }\n" +
+				"}\n",
+		},
+		{
+			caseName: "references",
+			f:        mustDBMLFormatter(),
+			t:        erdTestTableSet.Tables[1],
+			expected: "Table posts {\n" +
+				"  id \"INT UNSIGNED\" [pk]\n" +
+				"  user_id \"INT UNSIGNED\" [not null, ref: > users.id]\n" +
+				"  editor \"INT UNSIGNED\" [ref: > users.id]\n" +
+				"  category_id \"INT UNSIGNED\"\n" +
+				"}\n",
+		},
+		{
+			caseName: "composite primary key",
+			f:        mustDBMLFormatter(),
+			t: &tdconv.Table{
+				Name: "user_roles",
+				Columns: []tdconv.Column{
+					{Name: "user_id", Type: "INT", PKey: true, NotNull: true},
+					{Name: "type", Type: "VARCHAR(32)", PKey: true, NotNull: true},
+					{Name: "geom", Type: "GEOMETRY"},
+				},
+				PKeyColumns: []string{"user_id", "type"},
+			},
+			expected: "Table user_roles {\n" +
+				"  user_id INT [not null, ref: > users.id]\n" +
+				"  type VARCHAR(32) [not null]\n" +
+				"  geom GEOMETRY\n" +
+				"\n  Indexes {\n" +
+				"    (user_id, type) [pk]\n" +
+				"  }\n" +
+				"}\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			if err := tdconv.FprintTable(b, c.f, erdTestTableSet, c.t); err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+		})
+	}
+}
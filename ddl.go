@@ -0,0 +1,425 @@
+package tdconv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// ReadDDL reads the table definitions from `CREATE TABLE` statements like the output of SQLFormatter.
+// The other statements, foreign keys and check constraints are ignored.
+// The column type is the words before the first constraint (e.g. `INT UNSIGNED`),
+// and the unknown constraints like `AUTO_INCREMENT` or `DEFAULT 0` become the option of the column.
+// A single column index makes the index flag of the column true, same as the parsed sheet.
+func ReadDDL(r io.Reader) (*TableSet, error) {
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read DDL: %v", err)
+	}
+
+	ts := &TableSet{}
+	for _, stmt := range splitSQL(stripSQLComments(string(b)), ';') {
+		t, err := parseCreateTable(stmt)
+		if err != nil {
+			return nil, err
+		}
+		if t != nil {
+			ts.Tables = append(ts.Tables, t)
+		}
+	}
+
+	if len(ts.Tables) == 0 {
+		return nil, errors.New("No CREATE TABLE statement in DDL")
+	}
+
+	return ts, nil
+}
+
+// LoadDDL loads the table definitions from the DDL file.
+// The name of the table set is the file name without the extension.
+func LoadDDL(name string) (*TableSet, error) {
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open DDL file: %v", err)
+	}
+	defer file.Close()
+
+	ts, err := ReadDDL(file)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load DDL file (%s): %v", name, err)
+	}
+	base := filepath.Base(name)
+	ts.Name = strings.TrimSuffix(base, filepath.Ext(base))
+
+	return ts, nil
+}
+
+// parseCreateTable parses `CREATE TABLE` statement. If the statement is not it, it returns nil.
+func parseCreateTable(stmt string) (*Table, error) {
+
+	open := indexSQL(stmt, '(')
+	if open < 0 {
+		return nil, nil
+	}
+	head := sqlTokens(stmt[:open])
+	if len(head) < 3 || !strings.EqualFold(head[0], "CREATE") || !hasSQLKeyword(head[1:len(head)-1], "TABLE") {
+		return nil, nil
+	}
+
+	ids := strings.Split(head[len(head)-1], ".")
+	t := &Table{Name: unquoteSQLIdent(ids[len(ids)-1])}
+
+	end := closingParen(stmt, open)
+	if end < 0 {
+		return nil, fmt.Errorf("Parenthesis is not closed (table=%s)", t.Name)
+	}
+
+	pkeys := []string{}
+	for _, e := range splitSQL(stmt[open+1:end], ',') {
+		tokens := sqlTokens(e)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		var constraint string
+		if strings.EqualFold(tokens[0], "CONSTRAINT") && len(tokens) > 2 {
+			constraint = unquoteSQLIdent(tokens[1])
+			i := strings.Index(e, tokens[0]) + len(tokens[0])
+			e = e[i+strings.Index(e[i:], tokens[1])+len(tokens[1]):]
+			tokens = tokens[2:]
+		}
+
+		switch strings.ToUpper(strings.SplitN(tokens[0], "(", 2)[0]) {
+		case "PRIMARY":
+			_, cols := sqlKey(e, 2)
+			pkeys = append(pkeys, cols...)
+		case "UNIQUE":
+			name, cols := sqlKey(e, 1)
+			if name == "" {
+				name = constraint
+			}
+			t.UniqueKeys = append(t.UniqueKeys, Key{Name: name, Columns: cols})
+		case "INDEX", "KEY":
+			name, cols := sqlKey(e, 1)
+			t.IndexKeys = append(t.IndexKeys, Key{Name: name, Columns: cols})
+		case "FOREIGN", "CHECK", "FULLTEXT", "SPATIAL", "EXCLUDE":
+		default:
+			c := parseSQLColumn(tokens)
+			if c.PKey {
+				pkeys = append(pkeys, c.Name)
+			}
+			t.Columns = append(t.Columns, c)
+		}
+	}
+
+	if len(t.Columns) == 0 {
+		return nil, fmt.Errorf("The length of table columns must not be zero (table=%s)", t.Name)
+	}
+
+	for _, p := range pkeys {
+		if !containsString(t.PKeyColumns, p) {
+			t.PKeyColumns = append(t.PKeyColumns, p)
+		}
+	}
+	for i := range t.Columns {
+		c := &t.Columns[i]
+		c.PKey = containsString(t.PKeyColumns, c.Name)
+		for _, k := range t.IndexKeys {
+			if len(k.Columns) == 1 && k.Columns[0] == c.Name {
+				c.Index = true
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// sqlColumnKeywords are the keywords which end the column type.
+var sqlColumnKeywords = map[string]struct{}{
+	"NOT": {}, "NULL": {}, "DEFAULT": {}, "AUTO_INCREMENT": {}, "AUTOINCREMENT": {}, "PRIMARY": {},
+	"UNIQUE": {}, "COMMENT": {}, "REFERENCES": {}, "CHECK": {}, "GENERATED": {}, "COLLATE": {},
+	"CHARSET": {}, "ON": {}, "CONSTRAINT": {}, "KEY": {}, "AS": {}, "IDENTITY": {},
+}
+
+func parseSQLColumn(tokens []string) Column {
+
+	c := Column{Name: unquoteSQLIdent(tokens[0])}
+
+	i := 1
+	var typ []string
+	for ; i < len(tokens); i++ {
+		if _, ok := sqlColumnKeywords[strings.ToUpper(tokens[i])]; ok && len(typ) > 0 {
+			break
+		}
+		typ = append(typ, tokens[i])
+	}
+	c.Type = strings.Join(typ, " ")
+
+	var opts []string
+	for ; i < len(tokens); i++ {
+		next := ""
+		if i+1 < len(tokens) {
+			next = strings.ToUpper(tokens[i+1])
+		}
+		switch strings.ToUpper(tokens[i]) {
+		case "NOT":
+			if next == "NULL" {
+				c.NotNull = true
+				i++
+				continue
+			}
+		case "NULL":
+			continue
+		case "PRIMARY":
+			if next == "KEY" {
+				c.PKey = true
+				i++
+				continue
+			}
+		case "UNIQUE":
+			c.Unique = true
+			if next == "KEY" {
+				i++
+			}
+			continue
+		case "COMMENT":
+			if i+1 < len(tokens) && strings.HasPrefix(tokens[i+1], "'") {
+				c.Comment = unquoteSQLString(tokens[i+1])
+				i++
+				continue
+			}
+		}
+		opts = append(opts, tokens[i])
+	}
+	c.Option = strings.Join(opts, " ")
+
+	return c
+}
+
+// sqlKey returns the name and the columns of the key definition like `UNIQUE KEY name (a, b)`.
+// skip is the number of the keywords at the beginning, except for the optional `KEY` or `INDEX`.
+func sqlKey(e string, skip int) (string, []string) {
+
+	open := indexSQL(e, '(')
+	if open < 0 {
+		return "", nil
+	}
+	tokens := sqlTokens(e[:open])
+	if skip < len(tokens) {
+		if s := strings.ToUpper(tokens[skip]); skip == 1 && (s == "KEY" || s == "INDEX") {
+			skip++
+		}
+	}
+
+	var name string
+	if skip < len(tokens) {
+		name = unquoteSQLIdent(tokens[skip])
+	}
+
+	end := closingParen(e, open)
+	if end < 0 {
+		end = len(e)
+	}
+	var cols []string
+	for _, c := range splitSQL(e[open+1:end], ',') {
+		tokens := sqlTokens(c)
+		if len(tokens) == 0 {
+			continue
+		}
+		// the prefix length like `name(10)` is removed
+		if i := indexSQL(tokens[0], '('); i > 0 {
+			tokens[0] = tokens[0][:i]
+		}
+		cols = append(cols, unquoteSQLIdent(tokens[0]))
+	}
+
+	return name, cols
+}
+
+// stripSQLComments removes `-- ...`, `# ...` and `/* ... */` comments outside the quotes.
+func stripSQLComments(s string) string {
+
+	var sb strings.Builder
+	rs := []rune(s)
+	var quote rune
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '#' || (r == '-' && i+1 < len(rs) && rs[i+1] == '-'):
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+			if i < len(rs) {
+				sb.WriteRune('\n')
+			}
+			continue
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			for i += 2; i < len(rs) && !(rs[i] == '*' && i+1 < len(rs) && rs[i+1] == '/'); i++ {
+			}
+			i++
+			sb.WriteRune(' ')
+			continue
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// splitSQL splits the string by the separator outside the quotes and parentheses.
+func splitSQL(s string, sep rune) []string {
+
+	var ss []string
+	var quote rune
+	depth, start := 0, 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == sep && depth == 0:
+			ss = append(ss, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" {
+		ss = append(ss, rest)
+	}
+
+	return ss
+}
+
+// sqlTokens splits the string by the spaces outside the quotes and parentheses.
+func sqlTokens(s string) []string {
+
+	var tokens []string
+	var quote rune
+	depth, start := 0, -1
+	for i, r := range s {
+		if start < 0 {
+			if unicode.IsSpace(r) {
+				continue
+			}
+			start = i
+		}
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case unicode.IsSpace(r) && depth == 0:
+			tokens = append(tokens, s[start:i])
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, s[start:])
+	}
+
+	return tokens
+}
+
+// indexSQL returns the index of the first rune outside the quotes, or -1.
+func indexSQL(s string, c rune) int {
+
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == c:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// closingParen returns the index of the parenthesis closing the one at open, or -1.
+func closingParen(s string, open int) int {
+
+	var quote rune
+	depth := 0
+	for i, r := range s[open:] {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth == 0 {
+				return open + i
+			}
+		}
+	}
+
+	return -1
+}
+
+func unquoteSQLIdent(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '`' || s[0] == '"' || s[0] == '[') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func unquoteSQLString(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		s = s[1 : len(s)-1]
+	}
+	return strings.NewReplacer("''", "'", `\'`, "'").Replace(s)
+}
+
+func hasSQLKeyword(tokens []string, keyword string) bool {
+	for _, t := range tokens {
+		if strings.EqualFold(t, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
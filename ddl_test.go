@@ -0,0 +1,140 @@
+package tdconv_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+func TestReadDDL(t *testing.T) {
+
+	t.Run("round trip: SQLFormatter", func(t *testing.T) {
+		f, err := tdconv.NewSQLFormatter()
+		if err != nil {
+			t.Fatalf("error must not occur: %v", err)
+		}
+		b := &bytes.Buffer{}
+		if err := tdconv.FprintTableSet(b, f, snapshotTableSet); err != nil {
+			t.Fatalf("error must not occur: %v", err)
+		}
+		ts, err := tdconv.ReadDDL(b)
+		if err != nil {
+			t.Fatalf("error must not occur: %v", err)
+		}
+		if d := tdconv.CompareTableSets(snapshotTableSet, ts); !d.Empty() {
+			t.Errorf("table set must be the same (diff=%+v)", d)
+		}
+	})
+
+	cases := []struct {
+		caseName string
+		ddl      string
+		expected []*tdconv.Table
+		errMsg   string
+	}{
+		{
+			caseName: "success: hand-written DDL",
+			ddl: "-- users table\n" +
+				"DROP TABLE IF EXISTS users;\n" +
+				"CREATE TABLE IF NOT EXISTS db.`users` (\n" +
+				"  id bigint unsigned PRIMARY KEY AUTO_INCREMENT, # id\n" +
+				"  `name` VARCHAR(32) NOT NULL DEFAULT 'a, b' COMMENT 'it''s name',\n" +
+				"  /* multi-line\n comment; */\n" +
+				"  email VARCHAR(255) NULL UNIQUE KEY,\n" +
+				"  group_id INT REFERENCES `groups`(id),\n" +
+				"  CONSTRAINT name_email_uk UNIQUE (name(10), email),\n" +
+				"  KEY group_key (group_id),\n" +
+				"  FOREIGN KEY (group_id) REFERENCES `groups`(id)\n" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n" +
+				"INSERT INTO users (id) VALUES (1);\n",
+			expected: []*tdconv.Table{
+				{
+					Name: "users",
+					Columns: []tdconv.Column{
+						{Name: "id", Type: "bigint unsigned", PKey: true, Option: "AUTO_INCREMENT"},
+						{Name: "name", Type: "VARCHAR(32)", NotNull: true, Option: "DEFAULT 'a, b'", Comment: "it's name"},
+						{Name: "email", Type: "VARCHAR(255)", Unique: true},
+						{Name: "group_id", Type: "INT", Index: true, Option: "REFERENCES `groups`(id)"},
+					},
+					PKeyColumns: []string{"id"},
+					UniqueKeys:  []tdconv.Key{{Name: "name_email_uk", Columns: []string{"name", "email"}}},
+					IndexKeys:   []tdconv.Key{{Name: "group_key", Columns: []string{"group_id"}}},
+				},
+			},
+		},
+		{
+			caseName: "success: composite primary key",
+			ddl:      "CREATE TABLE t (a INT, b INT, PRIMARY KEY(a, b), INDEX (a, b));",
+			expected: []*tdconv.Table{
+				{
+					Name: "t",
+					Columns: []tdconv.Column{
+						{Name: "a", Type: "INT", PKey: true},
+						{Name: "b", Type: "INT", PKey: true},
+					},
+					PKeyColumns: []string{"a", "b"},
+					IndexKeys:   []tdconv.Key{{Columns: []string{"a", "b"}}},
+				},
+			},
+		},
+		{
+			caseName: "failure: no table",
+			ddl:      "DROP TABLE users;",
+			errMsg:   "No CREATE TABLE statement in DDL",
+		},
+		{
+			caseName: "failure: no columns",
+			ddl:      "CREATE TABLE t (PRIMARY KEY (a));",
+			errMsg:   "The length of table columns must not be zero (table=t)",
+		},
+		{
+			caseName: "failure: not closed",
+			ddl:      "CREATE TABLE t (a INT",
+			errMsg:   "Parenthesis is not closed (table=t)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			ts, err := tdconv.ReadDDL(strings.NewReader(c.ddl))
+
+			if c.errMsg != "" {
+				if err == nil {
+					t.Fatalf("error must occur")
+				}
+				if err.Error() != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			if !reflect.DeepEqual(ts.Tables, c.expected) {
+				t.Errorf("value doesn't match (expected=%+v, actual=%+v)", c.expected, ts.Tables)
+			}
+		})
+	}
+}
+
+func TestLoadDDL(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "schema.sql")
+	if err := ioutil.WriteFile(file, []byte("CREATE TABLE t (a INT);\n"), 0644); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+
+	ts, err := tdconv.LoadDDL(file)
+	if err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	if ts.Name != "schema" {
+		t.Errorf("name doesn't match (expected=schema, actual=%s)", ts.Name)
+	}
+}
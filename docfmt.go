@@ -0,0 +1,354 @@
+package tdconv
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// MarkdownFormatter is a formatter to output the table definision as Markdown document (data dictionary).
+type MarkdownFormatter struct {
+	formatter
+}
+
+// NewMarkdownFormatter creates a new MarkdownFormatter.
+// You can change some parameters of the MarkdownFormatter with MarkdownFormatOption.
+func NewMarkdownFormatter(options ...MarkdownFormatOption) (*MarkdownFormatter, error) {
+
+	f := MarkdownFormatter{}
+	f.setHeader(func(w io.Writer, ts *TableSet) {
+		markdownHeader(w, ts, true)
+	})
+	// the table of contents is not output with multi flag, because the tables are in the other files
+	f.setMultiHeader(func(w io.Writer, ts *TableSet, _ int) {
+		markdownHeader(w, ts, false)
+	})
+	for _, opt := range options {
+		err := opt(&f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+func markdownHeader(w io.Writer, ts *TableSet, toc bool) {
+	fmt.Fprint(w,
+		"<!-- This file generated by tdconv. DO NOT EDIT. -->\n"+
+			"<!-- See more details at https://github.com/takuoki/tdconv. -->\n\n")
+	fmt.Fprintf(w, "# %s\n\n", markdownEscape(ts.Name))
+	if !toc {
+		return
+	}
+	anchors := docAnchors(ts.Name, ts.Tables)
+	for _, t := range ts.Tables {
+		fmt.Fprintf(w, "* [%s](#%s)\n", markdownEscape(t.Name), anchors[t])
+	}
+	fmt.Fprintln(w)
+}
+
+// MarkdownFormatOption changes some parameters of the MarkdownFormatter.
+type MarkdownFormatOption func(*MarkdownFormatter) error
+
+// MarkdownHeader changes the header.
+func MarkdownHeader(fc func(w io.Writer, ts *TableSet)) MarkdownFormatOption {
+	return func(f *MarkdownFormatter) error {
+		f.setHeader(fc)
+		return nil
+	}
+}
+
+// MarkdownTableHeader changes the header of each table.
+func MarkdownTableHeader(fc func(w io.Writer, t *Table)) MarkdownFormatOption {
+	return func(f *MarkdownFormatter) error {
+		f.setTableHeader(fc)
+		return nil
+	}
+}
+
+// MarkdownTableFooter changes the footer of each table.
+func MarkdownTableFooter(fc func(w io.Writer, t *Table)) MarkdownFormatOption {
+	return func(f *MarkdownFormatter) error {
+		f.setTableFooter(fc)
+		return nil
+	}
+}
+
+// MarkdownFooter changes the footer.
+func MarkdownFooter(fc func(w io.Writer, ts *TableSet)) MarkdownFormatOption {
+	return func(f *MarkdownFormatter) error {
+		f.setFooter(fc)
+		return nil
+	}
+}
+
+// Extension returns the extension of Markdown file.
+func (f *MarkdownFormatter) Extension() string {
+	return "md"
+}
+
+// Fprint outputs the table definision as Markdown document.
+func (f *MarkdownFormatter) Fprint(w io.Writer, t *Table) {
+
+	if f == nil || t == nil {
+		return
+	}
+
+	fmt.Fprintf(w, "## <a name='%s'></a>%s\n\n", docAnchor(w, t), markdownEscape(t.Name))
+
+	fmt.Fprintln(w, "| No | Name | Type | PK | NN | UQ | IDX | Option | Comment |")
+	fmt.Fprintln(w, "|---:|------|------|:--:|:--:|:--:|:---:|--------|---------|")
+	var common bool
+	for i, c := range t.Columns {
+		name := markdownEscape(c.Name)
+		if c.IsCommon {
+			name += " \\*"
+			common = true
+		}
+		fmt.Fprintf(w, "| %d | %s | %s | %s | %s | %s | %s | %s | %s |\n",
+			i+1, name, markdownEscape(c.Type),
+			docMark(c.PKey), docMark(c.NotNull), docMark(c.Unique), docMark(c.Index),
+			markdownEscape(c.Option), markdownEscape(c.Comment))
+	}
+	if common {
+		fmt.Fprint(w, "\n\\* common column\n")
+	}
+
+	if ks := docKeys(t); len(ks) > 0 {
+		fmt.Fprint(w, "\n### Keys\n\n")
+		for _, k := range ks {
+			fmt.Fprintf(w, "* %s: `%s`\n", k.kind, strings.Join(k.columns, "`, `"))
+		}
+	}
+}
+
+// HTMLFormatter is a formatter to output the table definision as standalone HTML document (data dictionary).
+type HTMLFormatter struct {
+	formatter
+}
+
+// NewHTMLFormatter creates a new HTMLFormatter.
+// You can change some parameters of the HTMLFormatter with HTMLFormatOption.
+func NewHTMLFormatter(options ...HTMLFormatOption) (*HTMLFormatter, error) {
+
+	f := HTMLFormatter{}
+	f.setHeader(func(w io.Writer, ts *TableSet) {
+		htmlHeader(w, ts, true)
+	})
+	// the table of contents is not output with multi flag, because the tables are in the other files
+	f.setMultiHeader(func(w io.Writer, ts *TableSet, _ int) {
+		htmlHeader(w, ts, false)
+	})
+	f.setFooter(func(w io.Writer, _ *TableSet) {
+		fmt.Fprint(w, "</body>\n</html>\n")
+	})
+	for _, opt := range options {
+		err := opt(&f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+func htmlHeader(w io.Writer, ts *TableSet, toc bool) {
+	name := html.EscapeString(ts.Name)
+	fmt.Fprintf(w, "<!DOCTYPE html>\n"+
+		"<!-- This file generated by tdconv. DO NOT EDIT. -->\n"+
+		"<!-- See more details at https://github.com/takuoki/tdconv. -->\n"+
+		"<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n"+
+		"<style>\n"+
+		"body { font-family: sans-serif; }\n"+
+		"table { border-collapse: collapse; }\n"+
+		"th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }\n"+
+		"td.mark { text-align: center; }\n"+
+		"tr.common { color: #666; }\n"+
+		"</style>\n"+
+		"</head>\n<body>\n<h1>%s</h1>\n", name, name)
+	if !toc {
+		fmt.Fprintln(w)
+		return
+	}
+	fmt.Fprint(w, "<ul>\n")
+	anchors := docAnchors(ts.Name, ts.Tables)
+	for _, t := range ts.Tables {
+		fmt.Fprintf(w, "<li><a href=\"#%s\">%s</a></li>\n", anchors[t], html.EscapeString(t.Name))
+	}
+	fmt.Fprint(w, "</ul>\n\n")
+}
+
+// HTMLFormatOption changes some parameters of the HTMLFormatter.
+type HTMLFormatOption func(*HTMLFormatter) error
+
+// HTMLHeader changes the header.
+func HTMLHeader(fc func(w io.Writer, ts *TableSet)) HTMLFormatOption {
+	return func(f *HTMLFormatter) error {
+		f.setHeader(fc)
+		return nil
+	}
+}
+
+// HTMLTableHeader changes the header of each table.
+func HTMLTableHeader(fc func(w io.Writer, t *Table)) HTMLFormatOption {
+	return func(f *HTMLFormatter) error {
+		f.setTableHeader(fc)
+		return nil
+	}
+}
+
+// HTMLTableFooter changes the footer of each table.
+func HTMLTableFooter(fc func(w io.Writer, t *Table)) HTMLFormatOption {
+	return func(f *HTMLFormatter) error {
+		f.setTableFooter(fc)
+		return nil
+	}
+}
+
+// HTMLFooter changes the footer.
+func HTMLFooter(fc func(w io.Writer, ts *TableSet)) HTMLFormatOption {
+	return func(f *HTMLFormatter) error {
+		f.setFooter(fc)
+		return nil
+	}
+}
+
+// Extension returns the extension of HTML file.
+func (f *HTMLFormatter) Extension() string {
+	return "html"
+}
+
+// Fprint outputs the table definision as HTML document.
+func (f *HTMLFormatter) Fprint(w io.Writer, t *Table) {
+
+	if f == nil || t == nil {
+		return
+	}
+
+	fmt.Fprintf(w, "<h2 id=\"%s\">%s</h2>\n", docAnchor(w, t), html.EscapeString(t.Name))
+	fmt.Fprint(w, "<table>\n<tr><th>No</th><th>Name</th><th>Type</th><th>PK</th><th>NN</th><th>UQ</th><th>IDX</th><th>Option</th><th>Comment</th></tr>\n")
+	var common bool
+	for i, c := range t.Columns {
+		name := html.EscapeString(c.Name)
+		class := ""
+		if c.IsCommon {
+			name += " *"
+			class = " class=\"common\""
+			common = true
+		}
+		fmt.Fprintf(w, "<tr%s><td>%d</td><td>%s</td><td>%s</td>"+
+			"<td class=\"mark\">%s</td><td class=\"mark\">%s</td><td class=\"mark\">%s</td><td class=\"mark\">%s</td>"+
+			"<td>%s</td><td>%s</td></tr>\n",
+			class, i+1, name, html.EscapeString(c.Type),
+			docMark(c.PKey), docMark(c.NotNull), docMark(c.Unique), docMark(c.Index),
+			html.EscapeString(c.Option), html.EscapeString(c.Comment))
+	}
+	fmt.Fprint(w, "</table>\n")
+	if common {
+		fmt.Fprint(w, "<p>* common column</p>\n")
+	}
+
+	if ks := docKeys(t); len(ks) > 0 {
+		fmt.Fprint(w, "<h3>Keys</h3>\n<ul>\n")
+		for _, k := range ks {
+			fmt.Fprintf(w, "<li>%s: <code>%s</code></li>\n", html.EscapeString(k.kind), html.EscapeString(strings.Join(k.columns, ", ")))
+		}
+		fmt.Fprint(w, "</ul>\n")
+	}
+}
+
+type docKey struct {
+	kind    string
+	columns []string
+}
+
+func docKeys(t *Table) []docKey {
+	var ks []docKey
+	if len(t.PKeyColumns) > 0 {
+		ks = append(ks, docKey{kind: "PRIMARY KEY", columns: t.PKeyColumns})
+	}
+	for _, c := range t.Columns {
+		if c.Unique {
+			ks = append(ks, docKey{kind: "UNIQUE", columns: []string{c.Name}})
+		}
+	}
+	for _, k := range t.UniqueKeys {
+		ks = append(ks, docKey{kind: "UNIQUE KEY " + k.Name, columns: k.Columns})
+	}
+	for _, k := range t.IndexKeys {
+		ks = append(ks, docKey{kind: "INDEX " + k.Name, columns: k.Columns})
+	}
+	return ks
+}
+
+// docAnchor returns the anchor of the table in the file with the writer.
+// If the writer is not passed by fprint, the table is regarded as the only one in the file.
+func docAnchor(w io.Writer, t *Table) string {
+	var title string
+	if ts := tableSetOf(w); ts != nil {
+		title = ts.Name
+	}
+	if a, ok := docAnchors(title, fileTablesOf(w))[t]; ok {
+		return a
+	}
+	return docAnchors(title, []*Table{t})[t]
+}
+
+// docAnchors returns the anchors of the tables in the same way as GitHub generates the heading IDs,
+// so that the links to the Markdown document rendered in GitHub work.
+// The headings of the title and the keys are also counted for the duplicated slugs.
+func docAnchors(title string, tables []*Table) map[*Table]string {
+	s := docSlugger{}
+	s.slug(title)
+	anchors := make(map[*Table]string, len(tables))
+	for _, t := range tables {
+		anchors[t] = s.slug(t.Name)
+		if len(docKeys(t)) > 0 {
+			s.slug("Keys")
+		}
+	}
+	return anchors
+}
+
+// docSlugger generates the unique slugs of the headings like GitHub.
+// The occurrences are counted with the original slugs, and `-N` is added to the duplicated ones.
+type docSlugger map[string]int
+
+func (s docSlugger) slug(heading string) string {
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || unicode.In(r, unicode.L, unicode.M, unicode.N, unicode.Pc):
+			b.WriteRune(r)
+		}
+	}
+
+	original := b.String()
+	slug := original
+	for {
+		if _, ok := s[slug]; !ok {
+			break
+		}
+		s[original]++
+		slug = fmt.Sprintf("%s-%d", original, s[original])
+	}
+	s[slug] = 0
+	return slug
+}
+
+func docMark(b bool) string {
+	if b {
+		return "✓"
+	}
+	return ""
+}
+
+var markdownReplacer = strings.NewReplacer("|", "\\|", "\n", "<br>")
+
+func markdownEscape(s string) string {
+	return markdownReplacer.Replace(s)
+}
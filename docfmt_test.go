@@ -0,0 +1,392 @@
+package tdconv_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+var mustMarkdownFormatter = func(options ...tdconv.MarkdownFormatOption) *tdconv.MarkdownFormatter {
+	f, err := tdconv.NewMarkdownFormatter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+var mustHTMLFormatter = func(options ...tdconv.HTMLFormatOption) *tdconv.HTMLFormatter {
+	f, err := tdconv.NewHTMLFormatter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+var docTestTable = &tdconv.Table{
+	Name: "sample_table",
+	Columns: []tdconv.Column{
+		{Name: "id", Type: "INT UNSIGNED", PKey: true, NotNull: true, Unique: false, Index: false, Option: "AUTO_INCREMENT", Comment: "this is id!", IsCommon: false},
+		{Name: "foo", Type: "VARCHAR(32)", PKey: false, NotNull: true, Unique: true, Index: false, Option: "", Comment: "a|b", IsCommon: false},
+		{Name: "bar", Type: "VARCHAR(32)", PKey: false, NotNull: false, Unique: false, Index: true, Option: "", Comment: "<bar>", IsCommon: false},
+		{Name: "created_at", Type: "TIMESTAMP NULL", PKey: false, NotNull: false, Unique: false, Index: false, Option: "DEFAULT CURRENT_TIMESTAMP", Comment: "", IsCommon: true},
+	},
+	PKeyColumns: []string{"id"},
+	UniqueKeys:  []tdconv.Key{{Name: "foo_bar_key", Columns: []string{"foo", "bar"}}},
+	IndexKeys:   []tdconv.Key{{Name: "bar_key", Columns: []string{"bar"}}},
+}
+
+func TestNewMarkdownFormatter(t *testing.T) {
+
+	errOptionFunc := func(*tdconv.MarkdownFormatter) error {
+		return errors.New("error")
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.MarkdownFormatOption
+		errMsg   string
+	}{
+		{
+			caseName: "success: default",
+		},
+		{
+			caseName: "success: set all options",
+			opts: []tdconv.MarkdownFormatOption{
+				tdconv.MarkdownHeader(nil),
+				tdconv.MarkdownTableHeader(nil),
+				tdconv.MarkdownTableFooter(nil),
+				tdconv.MarkdownFooter(nil),
+			},
+		},
+		{
+			caseName: "failure: option error",
+			opts:     []tdconv.MarkdownFormatOption{errOptionFunc},
+			errMsg:   "error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.NewMarkdownFormatter(c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if endIndex := strings.Index(err.Error(), ":"); endIndex < 0 {
+					if err.Error() != c.errMsg {
+						t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+						return
+					}
+				} else if err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestMarkdownFormatter_Extension(t *testing.T) {
+	var f *tdconv.MarkdownFormatter
+	if f.Extension() != "md" {
+		t.Errorf("value doesn't match (expected=md, actual=%s)", f.Extension())
+	}
+}
+
+func TestMarkdownFormatter_Header(t *testing.T) {
+
+	ts := &tdconv.TableSet{
+		Name:   "Sample Table Set",
+		Tables: []*tdconv.Table{{Name: "sample_table_1"}, {Name: "Sample Table 2"}, {Name: "sample table 2"}, {Name: "ユーザー"}, {Name: "商品"}},
+	}
+	expected := "<!-- This file generated by tdconv. DO NOT EDIT. -->\n" +
+		"<!-- See more details at https://github.com/takuoki/tdconv. -->\n\n" +
+		"# Sample Table Set\n\n" +
+		"* [sample_table_1](#sample_table_1)\n" +
+		"* [Sample Table 2](#sample-table-2)\n" +
+		"* [sample table 2](#sample-table-2-1)\n" +
+		"* [ユーザー](#ユーザー)\n" +
+		"* [商品](#商品)\n\n"
+
+	b := &bytes.Buffer{}
+	mustMarkdownFormatter().Header(b, ts)
+
+	if b.String() != expected {
+		t.Errorf("value doesn't match (expected=%s, actual=%s)", expected, b.String())
+	}
+}
+
+func TestMarkdownFormatter_Fprint(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.MarkdownFormatter
+		t        *tdconv.Table
+		expected string
+	}{
+		{
+			caseName: "nil formatter",
+			f:        nil,
+			t:        docTestTable,
+			expected: "",
+		},
+		{
+			caseName: "table is nil",
+			f:        mustMarkdownFormatter(),
+			t:        nil,
+			expected: "",
+		},
+		{
+			caseName: "standard output",
+			f:        mustMarkdownFormatter(),
+			t:        docTestTable,
+			expected: "## <a name='sample_table'></a>sample_table\n\n" +
+				"| No | Name | Type | PK | NN | UQ | IDX | Option | Comment |\n" +
+				"|---:|------|------|:--:|:--:|:--:|:---:|--------|---------|\n" +
+				"| 1 | id | INT UNSIGNED | ✓ | ✓ |  |  | AUTO_INCREMENT | this is id! |\n" +
+				"| 2 | foo | VARCHAR(32) |  | ✓ | ✓ |  |  | a\\|b |\n" +
+				"| 3 | bar | VARCHAR(32) |  |  |  | ✓ |  | <bar> |\n" +
+				"| 4 | created_at \\* | TIMESTAMP NULL |  |  |  |  | DEFAULT CURRENT_TIMESTAMP |  |\n" +
+				"\n\\* common column\n" +
+				"\n### Keys\n\n" +
+				"* PRIMARY KEY: `id`\n" +
+				"* UNIQUE: `foo`\n" +
+				"* UNIQUE KEY foo_bar_key: `foo`, `bar`\n" +
+				"* INDEX bar_key: `bar`\n",
+		},
+		{
+			caseName: "no keys and no common columns",
+			f:        mustMarkdownFormatter(),
+			t: &tdconv.Table{
+				Name:    "sample_table",
+				Columns: []tdconv.Column{{Name: "foo", Type: "TEXT"}},
+			},
+			expected: "## <a name='sample_table'></a>sample_table\n\n" +
+				"| No | Name | Type | PK | NN | UQ | IDX | Option | Comment |\n" +
+				"|---:|------|------|:--:|:--:|:--:|:---:|--------|---------|\n" +
+				"| 1 | foo | TEXT |  |  |  |  |  |  |\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			c.f.Fprint(b, c.t)
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestNewHTMLFormatter(t *testing.T) {
+
+	errOptionFunc := func(*tdconv.HTMLFormatter) error {
+		return errors.New("error")
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.HTMLFormatOption
+		errMsg   string
+	}{
+		{
+			caseName: "success: default",
+		},
+		{
+			caseName: "success: set all options",
+			opts: []tdconv.HTMLFormatOption{
+				tdconv.HTMLHeader(nil),
+				tdconv.HTMLTableHeader(nil),
+				tdconv.HTMLTableFooter(nil),
+				tdconv.HTMLFooter(nil),
+			},
+		},
+		{
+			caseName: "failure: option error",
+			opts:     []tdconv.HTMLFormatOption{errOptionFunc},
+			errMsg:   "error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.NewHTMLFormatter(c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if endIndex := strings.Index(err.Error(), ":"); endIndex < 0 {
+					if err.Error() != c.errMsg {
+						t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+						return
+					}
+				} else if err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestHTMLFormatter_Extension(t *testing.T) {
+	var f *tdconv.HTMLFormatter
+	if f.Extension() != "html" {
+		t.Errorf("value doesn't match (expected=html, actual=%s)", f.Extension())
+	}
+}
+
+func TestDocFormatter_anchors(t *testing.T) {
+
+	// the slugs of the title and the keys heading of docTestTable are also counted
+	ts := &tdconv.TableSet{
+		Name:   "Sample Table Set",
+		Tables: []*tdconv.Table{docTestTable, {Name: "Keys"}, {Name: "Sample Table Set"}, {Name: "a|b (c)"}},
+	}
+
+	cases := []struct {
+		caseName string
+		f        tdconv.Formatter
+		toc      string
+		heading  string
+	}{
+		{caseName: "markdown", f: mustMarkdownFormatter(), toc: "](#%s)\n", heading: "## <a name='%s'></a>"},
+		{caseName: "html", f: mustHTMLFormatter(), toc: "<a href=\"#%s\">", heading: "<h2 id=\"%s\">"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			if err := tdconv.FprintTableSet(b, c.f, ts); err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			for _, a := range []string{"sample_table", "keys-1", "sample-table-set-1", "ab-c"} {
+				if !strings.Contains(b.String(), fmt.Sprintf(c.toc, a)) {
+					t.Errorf("link is not output (anchor=%s)", a)
+				}
+				if !strings.Contains(b.String(), fmt.Sprintf(c.heading, a)) {
+					t.Errorf("heading is not output (anchor=%s)", a)
+				}
+			}
+		})
+	}
+}
+
+func TestDocFormatter_multi(t *testing.T) {
+
+	ts := &tdconv.TableSet{
+		Name:   "sample_table_set",
+		Tables: []*tdconv.Table{docTestTable, {Name: "sample_table_2", Columns: []tdconv.Column{{Name: "foo", Type: "TEXT"}}}},
+	}
+
+	cases := []struct {
+		caseName string
+		f        tdconv.Formatter
+		toc      string
+	}{
+		{caseName: "markdown", f: mustMarkdownFormatter(), toc: "* [sample_table"},
+		{caseName: "html", f: mustHTMLFormatter(), toc: "<li><a href="},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			fs := tdconv.NewMemoryFileSystem()
+			if err := tdconv.Output(c.f, ts, true, "out", tdconv.OutputFileSystem(fs)); err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			files := fs.Files()
+			if len(files) != 2 {
+				t.Fatalf("number of files doesn't match (expected=2, actual=%d)", len(files))
+			}
+			for name, b := range files {
+				if strings.Contains(string(b), c.toc) {
+					t.Errorf("table of contents must not be output with multi flag (file=%s)", name)
+				}
+			}
+
+			b := &bytes.Buffer{}
+			if err := tdconv.FprintTableSet(b, c.f, ts); err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			if !strings.Contains(b.String(), c.toc) {
+				t.Errorf("table of contents must be output without multi flag")
+			}
+		})
+	}
+}
+
+func TestHTMLFormatter_Fprint(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.HTMLFormatter
+		t        *tdconv.Table
+		expected string
+	}{
+		{
+			caseName: "nil formatter",
+			f:        nil,
+			t:        docTestTable,
+			expected: "",
+		},
+		{
+			caseName: "table is nil",
+			f:        mustHTMLFormatter(),
+			t:        nil,
+			expected: "",
+		},
+		{
+			caseName: "standard output",
+			f:        mustHTMLFormatter(),
+			t:        docTestTable,
+			expected: "<h2 id=\"sample_table\">sample_table</h2>\n" +
+				"<table>\n" +
+				"<tr><th>No</th><th>Name</th><th>Type</th><th>PK</th><th>NN</th><th>UQ</th><th>IDX</th><th>Option</th><th>Comment</th></tr>\n" +
+				"<tr><td>1</td><td>id</td><td>INT UNSIGNED</td><td class=\"mark\">✓</td><td class=\"mark\">✓</td><td class=\"mark\"></td><td class=\"mark\"></td><td>AUTO_INCREMENT</td><td>this is id!</td></tr>\n" +
+				"<tr><td>2</td><td>foo</td><td>VARCHAR(32)</td><td class=\"mark\"></td><td class=\"mark\">✓</td><td class=\"mark\">✓</td><td class=\"mark\"></td><td></td><td>a|b</td></tr>\n" +
+				"<tr><td>3</td><td>bar</td><td>VARCHAR(32)</td><td class=\"mark\"></td><td class=\"mark\"></td><td class=\"mark\"></td><td class=\"mark\">✓</td><td></td><td>&lt;bar&gt;</td></tr>\n" +
+				"<tr class=\"common\"><td>4</td><td>created_at *</td><td>TIMESTAMP NULL</td><td class=\"mark\"></td><td class=\"mark\"></td><td class=\"mark\"></td><td class=\"mark\"></td><td>DEFAULT CURRENT_TIMESTAMP</td><td></td></tr>\n" +
+				"</table>\n" +
+				"<p>* common column</p>\n" +
+				"<h3>Keys</h3>\n<ul>\n" +
+				"<li>PRIMARY KEY: <code>id</code></li>\n" +
+				"<li>UNIQUE: <code>foo</code></li>\n" +
+				"<li>UNIQUE KEY foo_bar_key: <code>foo, bar</code></li>\n" +
+				"<li>INDEX bar_key: <code>bar</code></li>\n" +
+				"</ul>\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			c.f.Fprint(b, c.t)
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package tdconv
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ERDNotation is a notation of the ER diagram.
+type ERDNotation int
+
+// ERD notations.
+const (
+	ERDMermaid ERDNotation = iota
+	ERDPlantUML
+	ERDDOT
+)
+
+// ERDFormatter is a formatter to output the table definision as ER diagram.
+// The relationships are inferred from `REFERENCES table(column)` in the column option,
+// or from the naming convention (`user_id` -> `users.id`).
+type ERDFormatter struct {
+	formatter
+	notation ERDNotation
+}
+
+// NewERDFormatter creates a new ERDFormatter.
+// You can change some parameters of the ERDFormatter with ERDFormatOption.
+func NewERDFormatter(options ...ERDFormatOption) (*ERDFormatter, error) {
+
+	f := ERDFormatter{}
+	f.setHeader(func(w io.Writer, ts *TableSet) {
+		switch f.notation {
+		case ERDPlantUML:
+			fmt.Fprint(w,
+				"' This file generated by tdconv. DO NOT EDIT.\n"+
+					"' See more details at https://github.com/takuoki/tdconv.\n"+
+					"@startuml\nhide circle\nskinparam linetype ortho\n\n")
+		case ERDDOT:
+			fmt.Fprintf(w,
+				"// This file generated by tdconv. DO NOT EDIT.\n"+
+					"// See more details at https://github.com/takuoki/tdconv.\n"+
+					"digraph %q {\n  graph [rankdir=LR];\n  node [shape=plaintext];\n\n", ts.Name)
+		default:
+			fmt.Fprint(w,
+				"%% This file generated by tdconv. DO NOT EDIT.\n"+
+					"%% See more details at https://github.com/takuoki/tdconv.\n"+
+					"erDiagram\n")
+		}
+	})
+	f.setFooter(func(w io.Writer, _ *TableSet) {
+		switch f.notation {
+		case ERDPlantUML:
+			fmt.Fprintln(w, "@enduml")
+		case ERDDOT:
+			fmt.Fprintln(w, "}")
+		}
+	})
+	for _, opt := range options {
+		err := opt(&f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+// ERDFormatOption changes some parameters of the ERDFormatter.
+type ERDFormatOption func(*ERDFormatter) error
+
+// ERDHeader changes the header.
+func ERDHeader(fc func(w io.Writer, ts *TableSet)) ERDFormatOption {
+	return func(f *ERDFormatter) error {
+		f.setHeader(fc)
+		return nil
+	}
+}
+
+// ERDFooter changes the footer.
+func ERDFooter(fc func(w io.Writer, ts *TableSet)) ERDFormatOption {
+	return func(f *ERDFormatter) error {
+		f.setFooter(fc)
+		return nil
+	}
+}
+
+// ERDFormat changes the notation of the ER diagram.
+// Mermaid `erDiagram` is used by default.
+func ERDFormat(n ERDNotation) ERDFormatOption {
+	return func(f *ERDFormatter) error {
+		switch n {
+		case ERDMermaid, ERDPlantUML, ERDDOT:
+		default:
+			return fmt.Errorf("Unknown ERD notation (%d)", n)
+		}
+		f.notation = n
+		return nil
+	}
+}
+
+// Extension returns the extension of the ER diagram file.
+func (f *ERDFormatter) Extension() string {
+	if f == nil {
+		return "mmd"
+	}
+	switch f.notation {
+	case ERDPlantUML:
+		return "puml"
+	case ERDDOT:
+		return "dot"
+	default:
+		return "mmd"
+	}
+}
+
+// Fprint outputs the table definision as an entity of ER diagram, and its relationships.
+// The relationships are resolved in the table set output by Output or FprintTableSet,
+// including the tables in the other files with multi flag.
+func (f *ERDFormatter) Fprint(w io.Writer, t *Table) {
+
+	if f == nil || t == nil {
+		return
+	}
+
+	rels := erdRelations(tableSetOf(w), t)
+	fks := map[string]bool{}
+	for _, r := range rels {
+		fks[r.column.Name] = true
+	}
+
+	switch f.notation {
+	case ERDPlantUML:
+		f.fprintPlantUML(w, t, rels)
+	case ERDDOT:
+		f.fprintDOT(w, t, rels)
+	default:
+		f.fprintMermaid(w, t, rels, fks)
+	}
+}
+
+func (f *ERDFormatter) fprintMermaid(w io.Writer, t *Table, rels []erdRelation, fks map[string]bool) {
+
+	fmt.Fprintf(w, "    %s {\n", erdID(t.Name))
+	for _, c := range t.Columns {
+		var keys []string
+		if c.PKey {
+			keys = append(keys, "PK")
+		}
+		if fks[c.Name] {
+			keys = append(keys, "FK")
+		}
+		if c.Unique {
+			keys = append(keys, "UK")
+		}
+		s := fmt.Sprintf("        %s %s", erdID(parseSQLType(c.Type).name), erdID(c.Name))
+		if len(keys) > 0 {
+			s += " " + strings.Join(keys, ", ")
+		}
+		if c.Comment != "" {
+			s += " \"" + strings.Replace(c.Comment, "\"", "'", -1) + "\""
+		}
+		fmt.Fprintln(w, s)
+	}
+	fmt.Fprintln(w, "    }")
+
+	for _, r := range rels {
+		fmt.Fprintf(w, "    %s %s %s : %q\n", erdID(r.ref.Name), r.cardinality(), erdID(t.Name), r.column.Name)
+	}
+}
+
+func (f *ERDFormatter) fprintPlantUML(w io.Writer, t *Table, rels []erdRelation) {
+
+	fmt.Fprintf(w, "entity %q as %s {\n", t.Name, erdID(t.Name))
+	var pkeys, others []Column
+	for _, c := range t.Columns {
+		if c.PKey {
+			pkeys = append(pkeys, c)
+		} else {
+			others = append(others, c)
+		}
+	}
+	for i, cs := range [][]Column{pkeys, others} {
+		if i == 1 && len(pkeys) > 0 {
+			fmt.Fprintln(w, "  --")
+		}
+		for _, c := range cs {
+			s := "  "
+			if c.NotNull || c.PKey {
+				s += "* "
+			}
+			s += c.Name + " : " + c.Type
+			if c.PKey {
+				s += " <<PK>>"
+			}
+			for _, r := range rels {
+				if r.column.Name == c.Name {
+					s += " <<FK>>"
+					break
+				}
+			}
+			if c.Unique {
+				s += " <<UK>>"
+			}
+			fmt.Fprintln(w, s)
+		}
+	}
+	fmt.Fprintln(w, "}")
+
+	for _, r := range rels {
+		fmt.Fprintf(w, "%s %s %s\n", erdID(r.ref.Name), r.cardinality(), erdID(t.Name))
+	}
+}
+
+func (f *ERDFormatter) fprintDOT(w io.Writer, t *Table, rels []erdRelation) {
+
+	fmt.Fprintf(w, "  %q [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">\n", t.Name)
+	fmt.Fprintf(w, "    <tr><td bgcolor=\"lightgrey\"><b>%s</b></td></tr>\n", html.EscapeString(t.Name))
+	for _, c := range t.Columns {
+		var keys []string
+		if c.PKey {
+			keys = append(keys, "PK")
+		}
+		if c.Unique {
+			keys = append(keys, "UK")
+		}
+		s := c.Name + ": " + c.Type
+		if len(keys) > 0 {
+			s += " (" + strings.Join(keys, ", ") + ")"
+		}
+		fmt.Fprintf(w, "    <tr><td align=\"left\" port=%q>%s</td></tr>\n", c.Name, html.EscapeString(s))
+	}
+	fmt.Fprintln(w, "  </table>>];")
+
+	for _, r := range rels {
+		fmt.Fprintf(w, "  %q:%q -> %q:%q;\n", t.Name, r.column.Name, r.ref.Name, r.refColumn)
+	}
+}
+
+type erdRelation struct {
+	column    Column
+	ref       *Table
+	refColumn string
+}
+
+// cardinality returns the cardinality in crow's foot notation (from the referenced table).
+func (r erdRelation) cardinality() string {
+	left := "||"
+	if !r.column.NotNull && !r.column.PKey {
+		left = "|o"
+	}
+	right := "o{"
+	if r.column.Unique {
+		right = "o|"
+	}
+	return left + "--" + right
+}
+
+var erdReferencesRegexp = regexp.MustCompile("(?i)REFERENCES\\s+`?(\\w+)`?\\s*\\(\\s*`?(\\w+)`?\\s*\\)")
+
+func erdRelations(ts *TableSet, t *Table) []erdRelation {
+
+	if ts == nil {
+		return nil
+	}
+	find := func(name string) *Table {
+		for _, tt := range ts.Tables {
+			if strings.EqualFold(tt.Name, name) {
+				return tt
+			}
+		}
+		return nil
+	}
+	hasColumn := func(t *Table, name string) bool {
+		for _, c := range t.Columns {
+			if c.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	var rels []erdRelation
+	for _, c := range t.Columns {
+
+		// foreign key info
+		if m := erdReferencesRegexp.FindStringSubmatch(c.Option); m != nil {
+			if ref := find(m[1]); ref != nil && hasColumn(ref, m[2]) {
+				rels = append(rels, erdRelation{column: c, ref: ref, refColumn: m[2]})
+			}
+			continue
+		}
+
+		// naming convention
+		if !strings.HasSuffix(c.Name, "_id") {
+			continue
+		}
+		prefix := strings.TrimSuffix(c.Name, "_id")
+		candidates := []string{prefix, prefix + "s", prefix + "es"}
+		if strings.HasSuffix(prefix, "y") {
+			candidates = append(candidates, strings.TrimSuffix(prefix, "y")+"ies")
+		}
+		for _, n := range candidates {
+			if ref := find(n); ref != nil && ref != t && hasColumn(ref, "id") {
+				rels = append(rels, erdRelation{column: c, ref: ref, refColumn: "id"})
+				break
+			}
+		}
+	}
+
+	return rels
+}
+
+var erdIDRegexp = regexp.MustCompile(`\W+`)
+
+func erdID(s string) string {
+	return erdIDRegexp.ReplaceAllString(s, "_")
+}
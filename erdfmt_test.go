@@ -0,0 +1,295 @@
+package tdconv_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+var mustERDFormatter = func(options ...tdconv.ERDFormatOption) *tdconv.ERDFormatter {
+	f, err := tdconv.NewERDFormatter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+var erdTestTableSet = &tdconv.TableSet{
+	Name: "sample",
+	Tables: []*tdconv.Table{
+		{
+			Name: "users",
+			Columns: []tdconv.Column{
+				{Name: "id", Type: "INT UNSIGNED", PKey: true, NotNull: true, Comment: "this is id!"},
+				{Name: "email", Type: "VARCHAR(255)", NotNull: true, Unique: true},
+			},
+			PKeyColumns: []string{"id"},
+		},
+		{
+			Name: "posts",
+			Columns: []tdconv.Column{
+				{Name: "id", Type: "INT UNSIGNED", PKey: true, NotNull: true},
+				{Name: "user_id", Type: "INT UNSIGNED", NotNull: true},
+				{Name: "editor", Type: "INT UNSIGNED", Option: "REFERENCES users(id)"},
+				{Name: "category_id", Type: "INT UNSIGNED"},
+			},
+			PKeyColumns: []string{"id"},
+		},
+	},
+}
+
+func TestNewERDFormatter(t *testing.T) {
+
+	errOptionFunc := func(*tdconv.ERDFormatter) error {
+		return errors.New("error")
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.ERDFormatOption
+		errMsg   string
+	}{
+		{
+			caseName: "success: default",
+		},
+		{
+			caseName: "success: set all options",
+			opts: []tdconv.ERDFormatOption{
+				tdconv.ERDHeader(nil),
+				tdconv.ERDFooter(nil),
+				tdconv.ERDFormat(tdconv.ERDDOT),
+			},
+		},
+		{
+			caseName: "failure: unknown notation",
+			opts:     []tdconv.ERDFormatOption{tdconv.ERDFormat(tdconv.ERDNotation(99))},
+			errMsg:   "Unknown ERD notation (99)",
+		},
+		{
+			caseName: "failure: option error",
+			opts:     []tdconv.ERDFormatOption{errOptionFunc},
+			errMsg:   "error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.NewERDFormatter(c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if endIndex := strings.Index(err.Error(), ":"); endIndex < 0 {
+					if err.Error() != c.errMsg {
+						t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+						return
+					}
+				} else if err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestERDFormatter_Extension(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.ERDFormatter
+		expected string
+	}{
+		{caseName: "nil formatter", f: nil, expected: "mmd"},
+		{caseName: "mermaid", f: mustERDFormatter(), expected: "mmd"},
+		{caseName: "plantuml", f: mustERDFormatter(tdconv.ERDFormat(tdconv.ERDPlantUML)), expected: "puml"},
+		{caseName: "dot", f: mustERDFormatter(tdconv.ERDFormat(tdconv.ERDDOT)), expected: "dot"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			if c.f.Extension() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, c.f.Extension())
+			}
+		})
+	}
+}
+
+func TestERDFormatter_Fprint(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.ERDFormatter
+		t        *tdconv.Table
+		expected string
+	}{
+		{
+			caseName: "nil formatter",
+			f:        nil,
+			t:        erdTestTableSet.Tables[1],
+			expected: "",
+		},
+		{
+			caseName: "table is nil",
+			f:        mustERDFormatter(),
+			t:        nil,
+			expected: "",
+		},
+		{
+			caseName: "mermaid",
+			f:        mustERDFormatter(),
+			t:        erdTestTableSet.Tables[1],
+			expected: "    posts {\n" +
+				"        INT id PK\n" +
+				"        INT user_id FK\n" +
+				"        INT editor FK\n" +
+				"        INT category_id\n" +
+				"    }\n" +
+				"    users ||--o{ posts : \"user_id\"\n" +
+				"    users |o--o{ posts : \"editor\"\n",
+		},
+		{
+			caseName: "mermaid: unique and comment",
+			f:        mustERDFormatter(),
+			t:        erdTestTableSet.Tables[0],
+			expected: "    users {\n" +
+				"        INT id PK \"this is id!\"\n" +
+				"        VARCHAR email UK\n" +
+				"    }\n",
+		},
+		{
+			caseName: "plantuml",
+			f:        mustERDFormatter(tdconv.ERDFormat(tdconv.ERDPlantUML)),
+			t:        erdTestTableSet.Tables[1],
+			expected: "entity \"posts\" as posts {\n" +
+				"  * id : INT UNSIGNED <<PK>>\n" +
+				"  --\n" +
+				"  * user_id : INT UNSIGNED <<FK>>\n" +
+				"  editor : INT UNSIGNED <<FK>>\n" +
+				"  category_id : INT UNSIGNED\n" +
+				"}\n" +
+				"users ||--o{ posts\n" +
+				"users |o--o{ posts\n",
+		},
+		{
+			caseName: "dot",
+			f:        mustERDFormatter(tdconv.ERDFormat(tdconv.ERDDOT)),
+			t:        erdTestTableSet.Tables[1],
+			expected: "  \"posts\" [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">\n" +
+				"    <tr><td bgcolor=\"lightgrey\"><b>posts</b></td></tr>\n" +
+				"    <tr><td align=\"left\" port=\"id\">id: INT UNSIGNED (PK)</td></tr>\n" +
+				"    <tr><td align=\"left\" port=\"user_id\">user_id: INT UNSIGNED</td></tr>\n" +
+				"    <tr><td align=\"left\" port=\"editor\">editor: INT UNSIGNED</td></tr>\n" +
+				"    <tr><td align=\"left\" port=\"category_id\">category_id: INT UNSIGNED</td></tr>\n" +
+				"  </table>>];\n" +
+				"  \"posts\":\"user_id\" -> \"users\":\"id\";\n" +
+				"  \"posts\":\"editor\" -> \"users\":\"id\";\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			if err := tdconv.FprintTable(b, c.f, erdTestTableSet, c.t); err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestERDFormatter_multi(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.ERDFormatter
+		file     string
+		expected string
+	}{
+		{
+			caseName: "mermaid",
+			f:        mustERDFormatter(),
+			file:     "out/posts.mmd",
+			expected: "    users ||--o{ posts : \"user_id\"\n",
+		},
+		{
+			caseName: "plantuml",
+			f:        mustERDFormatter(tdconv.ERDFormat(tdconv.ERDPlantUML)),
+			file:     "out/posts.puml",
+			expected: "users ||--o{ posts\n",
+		},
+		{
+			caseName: "dot",
+			f:        mustERDFormatter(tdconv.ERDFormat(tdconv.ERDDOT)),
+			file:     "out/posts.dot",
+			expected: "  \"posts\":\"user_id\" -> \"users\":\"id\";\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			fs := tdconv.NewMemoryFileSystem()
+			if err := tdconv.Output(c.f, erdTestTableSet, true, "out", tdconv.OutputFileSystem(fs)); err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			b, ok := fs.Files()[c.file]
+			if !ok {
+				t.Fatalf("file must be output (%s)", c.file)
+			}
+			if !strings.Contains(string(b), c.expected) {
+				t.Errorf("relation to the table in the other file must be output (expected=%s, actual=%s)", c.expected, string(b))
+			}
+		})
+	}
+}
+
+func TestERDFormatter_Header(t *testing.T) {
+
+	ts := &tdconv.TableSet{Name: "sample"}
+	cases := []struct {
+		caseName string
+		f        *tdconv.ERDFormatter
+		expected string
+	}{
+		{
+			caseName: "mermaid",
+			f:        mustERDFormatter(),
+			expected: "%% This file generated by tdconv. DO NOT EDIT.\n" +
+				"%% See more details at https://github.com/takuoki/tdconv.\n" +
+				"erDiagram\n",
+		},
+		{
+			caseName: "dot",
+			f:        mustERDFormatter(tdconv.ERDFormat(tdconv.ERDDOT)),
+			expected: "// This file generated by tdconv. DO NOT EDIT.\n" +
+				"// See more details at https://github.com/takuoki/tdconv.\n" +
+				"digraph \"sample\" {\n  graph [rankdir=LR];\n  node [shape=plaintext];\n\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			c.f.Header(b, ts)
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+		})
+	}
+}
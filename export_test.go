@@ -1,8 +1,6 @@
 package tdconv
 
-import (
-	"io"
-)
+import "io"
 
 func (p *Parser) TableNameRow() int {
 	if p == nil {
@@ -25,10 +23,14 @@ func (p *Parser) StartRow() int {
 	return p.startRow
 }
 
-func SetCreateFile(f func(name string) (io.WriteCloser, error)) (resetFunc func()) {
-	tmpFunc := createFile
-	createFile = f
-	return func() {
-		createFile = tmpFunc
-	}
+func ParseSQLType(t string) (name string, args []string) {
+	st := parseSQLType(t)
+	return st.name, st.args
+}
+
+// FprintTable outputs the table in the table set like Output does, and returns the error reported by the formatter.
+func FprintTable(w io.Writer, f Formatter, ts *TableSet, t *Table) error {
+	fw := &fprintWriter{w: w, ts: ts, tables: []*Table{t}}
+	f.Fprint(fw, t)
+	return fw.fmtErr
 }
@@ -0,0 +1,170 @@
+package tdconv
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// FileSystem is an interface of the file system which Output writes the files to.
+type FileSystem interface {
+	Create(name string) (io.WriteCloser, error)
+	Remove(name string) error
+}
+
+// ReadFileSystem is a FileSystem which can read the files.
+// If the file system implements it, Output skips writing the files whose contents are not changed.
+// ReadFile must return an error satisfying os.IsNotExist if the file doesn't exist.
+type ReadFileSystem interface {
+	FileSystem
+	ReadFile(name string) ([]byte, error)
+}
+
+// Aborter is an optional interface for the file created by FileSystem.
+// If the file implements it, Output calls Abort instead of Close and Remove on failure,
+// so that the existing file is kept as is.
+type Aborter interface {
+	Abort() error
+}
+
+// OSFileSystem is a FileSystem which writes the files to the disk.
+// The files are written atomically, that is, written to a temporary file and renamed on Close.
+type OSFileSystem struct{}
+
+// Create creates the file.
+// The parent directories are created if they don't exist.
+func (OSFileSystem) Create(name string) (io.WriteCloser, error) {
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(name)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{File: tmp, name: name}, nil
+}
+
+// ReadFile reads the file.
+func (OSFileSystem) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+// Remove removes the file.
+func (OSFileSystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+type atomicFile struct {
+	*os.File
+	name string
+}
+
+// Close renames the temporary file to the file name.
+// The permission of the existing file is kept.
+func (f *atomicFile) Close() error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(f.name); err == nil {
+		mode = info.Mode().Perm()
+	}
+	err := f.File.Chmod(mode)
+	if cerr := f.File.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.File.Name(), f.name)
+	}
+	if err != nil {
+		os.Remove(f.File.Name())
+	}
+	return err
+}
+
+// Abort removes the temporary file.
+func (f *atomicFile) Abort() error {
+	f.File.Close()
+	return os.Remove(f.File.Name())
+}
+
+// MemoryFileSystem is a FileSystem which keeps the files in memory.
+// It is safe for concurrent use.
+type MemoryFileSystem struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemoryFileSystem creates a new MemoryFileSystem.
+func NewMemoryFileSystem() *MemoryFileSystem {
+	return &MemoryFileSystem{files: map[string][]byte{}}
+}
+
+// Create creates the file.
+// The contents are stored when the file is closed.
+func (fs *MemoryFileSystem) Create(name string) (io.WriteCloser, error) {
+	return &memoryFile{fs: fs, name: name}, nil
+}
+
+// ReadFile reads the file.
+func (fs *MemoryFileSystem) ReadFile(name string) ([]byte, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	b, ok := fs.files[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return append([]byte(nil), b...), nil
+}
+
+// Remove removes the file.
+func (fs *MemoryFileSystem) Remove(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.files[name]; !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	delete(fs.files, name)
+	return nil
+}
+
+// Files returns a copy of the files (file name to contents).
+func (fs *MemoryFileSystem) Files() map[string][]byte {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	m := make(map[string][]byte, len(fs.files))
+	for k, v := range fs.files {
+		m[k] = append([]byte(nil), v...)
+	}
+	return m
+}
+
+// Names returns the sorted file names.
+func (fs *MemoryFileSystem) Names() []string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	ns := make([]string, 0, len(fs.files))
+	for k := range fs.files {
+		ns = append(ns, k)
+	}
+	sort.Strings(ns)
+	return ns
+}
+
+type memoryFile struct {
+	bytes.Buffer
+	fs   *MemoryFileSystem
+	name string
+}
+
+func (f *memoryFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.fs.files == nil {
+		f.fs.files = map[string][]byte{}
+	}
+	f.fs.files[f.name] = append([]byte(nil), f.Bytes()...)
+	return nil
+}
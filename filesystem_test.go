@@ -0,0 +1,215 @@
+package tdconv_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+func TestMemoryFileSystem(t *testing.T) {
+
+	fs := tdconv.NewMemoryFileSystem()
+	ts := &tdconv.TableSet{
+		Name: "sample_table_set",
+		Tables: []*tdconv.Table{
+			{Name: "sample_table_1"},
+			{Name: "sample_table_2"},
+		},
+	}
+
+	var wg sync.WaitGroup
+	for _, dir := range []string{"dir1", "dir2"} {
+		wg.Add(1)
+		go func(dir string) {
+			defer wg.Done()
+			if err := tdconv.Output(&testFormatter{}, ts, true, dir, tdconv.OutputFileSystem(fs)); err != nil {
+				t.Errorf("error must not occur: %v", err)
+			}
+		}(dir)
+	}
+	wg.Wait()
+
+	expectedNames := []string{"dir1/sample_table_1.test", "dir1/sample_table_2.test", "dir2/sample_table_1.test", "dir2/sample_table_2.test"}
+	if !reflect.DeepEqual(fs.Names(), expectedNames) {
+		t.Errorf("file names don't match (expected=%v, actual=%v)", expectedNames, fs.Names())
+	}
+
+	expected := "header: sample_table_set\n" +
+		"table header: sample_table_1\n" +
+		"table contents: sample_table_1\n" +
+		"table footer: sample_table_1\n" +
+		"footer: sample_table_set\n"
+	if a := string(fs.Files()["dir1/sample_table_1.test"]); a != expected {
+		t.Errorf("file contents don't match (expected=%s, actual=%s)", expected, a)
+	}
+
+	if err := fs.Remove("dir1/sample_table_1.test"); err != nil {
+		t.Errorf("error must not occur: %v", err)
+	}
+	if _, ok := fs.Files()["dir1/sample_table_1.test"]; ok {
+		t.Errorf("file must be removed")
+	}
+	if err := fs.Remove("dir1/sample_table_1.test"); !os.IsNotExist(err) {
+		t.Errorf("error must be not exist error (actual=%v)", err)
+	}
+}
+
+func TestOSFileSystem(t *testing.T) {
+
+	dir := t.TempDir()
+	ts := &tdconv.TableSet{
+		Name:   "sample_table_set",
+		Tables: []*tdconv.Table{{Name: "sample_table_1"}},
+	}
+
+	err := tdconv.Output(&testFormatter{}, ts, true, dir, tdconv.OutputFileNameTemplate("nested/dir/{{ .Name }}"))
+	if err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "nested", "dir", "sample_table_1.test"))
+	if err != nil {
+		t.Fatalf("file must be created in the nested directory: %v", err)
+	}
+	if !strings.HasPrefix(string(b), "header: sample_table_set\n") {
+		t.Errorf("file contents don't match (actual=%s)", string(b))
+	}
+}
+
+func TestOutput_Manifest(t *testing.T) {
+
+	fs := tdconv.NewMemoryFileSystem()
+	var statuses []string
+	report := tdconv.OutputReport(func(name string, s tdconv.FileStatus) {
+		statuses = append(statuses, name+" "+s.String())
+	})
+	output := func(ts *tdconv.TableSet) {
+		t.Helper()
+		statuses = nil
+		err := tdconv.Output(&testFormatter{}, ts, true, "out", tdconv.OutputFileSystem(fs), tdconv.OutputManifest(".manifest"), report)
+		if err != nil {
+			t.Fatalf("error must not occur: %v", err)
+		}
+	}
+
+	output(&tdconv.TableSet{
+		Name:   "sample_table_set",
+		Tables: []*tdconv.Table{{Name: "sample_table_1"}, {Name: "sample_table_2"}},
+	})
+	expected := []string{"out/sample_table_1.test created", "out/sample_table_2.test created", "out/.manifest created"}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("statuses don't match (expected=%v, actual=%v)", expected, statuses)
+	}
+	expectedManifest := "# This file generated by tdconv. DO NOT EDIT.\nsample_table_1.test\nsample_table_2.test\n"
+	if a := string(fs.Files()["out/.manifest"]); a != expectedManifest {
+		t.Errorf("manifest doesn't match (expected=%s, actual=%s)", expectedManifest, a)
+	}
+
+	// sample_table_2 is removed, and sample_table_3 is added
+	output(&tdconv.TableSet{
+		Name:   "sample_table_set",
+		Tables: []*tdconv.Table{{Name: "sample_table_1"}, {Name: "sample_table_3"}},
+	})
+	expected = []string{"out/sample_table_1.test unchanged", "out/sample_table_3.test created", "out/sample_table_2.test removed", "out/.manifest updated"}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("statuses don't match (expected=%v, actual=%v)", expected, statuses)
+	}
+	expectedNames := []string{"out/.manifest", "out/sample_table_1.test", "out/sample_table_3.test"}
+	if !reflect.DeepEqual(fs.Names(), expectedNames) {
+		t.Errorf("file names don't match (expected=%v, actual=%v)", expectedNames, fs.Names())
+	}
+}
+
+func TestOutput_ManifestError(t *testing.T) {
+
+	ts := &tdconv.TableSet{Name: "sample_table_set"}
+
+	fs := tdconv.NewMemoryFileSystem()
+	w, _ := fs.Create("out/.manifest")
+	w.Write([]byte("../outside.test\n"))
+	w.Close()
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.OutputOption
+		errMsg   string
+	}{
+		{
+			caseName: "empty manifest name",
+			opts:     []tdconv.OutputOption{tdconv.OutputManifest("")},
+			errMsg:   "Manifest file name must not be empty",
+		},
+		{
+			caseName: "file system is not readable",
+			opts:     []tdconv.OutputOption{tdconv.OutputFileSystem(&testFileSystem{outputMap: map[string]*bytes.Buffer{}}), tdconv.OutputManifest(".manifest")},
+			errMsg:   "File system must implement ReadFileSystem to use manifest",
+		},
+		{
+			caseName: "file outside the output directory",
+			opts:     []tdconv.OutputOption{tdconv.OutputFileSystem(fs), tdconv.OutputManifest(".manifest")},
+			errMsg:   "Invalid file name in manifest file (out/.manifest): ../outside.test",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			err := tdconv.Output(&testFormatter{}, ts, false, "out", c.opts...)
+			if err == nil || err.Error() != c.errMsg {
+				t.Errorf("error message doesn't match (expected=%s, actual=%v)", c.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestOSFileSystem_Atomic(t *testing.T) {
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "sample.test")
+	if err := ioutil.WriteFile(name, []byte("old"), 0600); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+
+	fs := tdconv.OSFileSystem{}
+
+	// aborted file doesn't change the existing file
+	w, err := fs.Create(name)
+	if err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	w.Write([]byte("partial"))
+	if err := w.(tdconv.Aborter).Abort(); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	if b, _ := fs.ReadFile(name); string(b) != "old" {
+		t.Errorf("file contents must not be changed (actual=%s)", string(b))
+	}
+
+	// closed file replaces the existing file with the same permission
+	w, err = fs.Create(name)
+	if err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	w.Write([]byte("new"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	if b, _ := fs.ReadFile(name); string(b) != "new" {
+		t.Errorf("file contents don't match (expected=new, actual=%s)", string(b))
+	}
+	if info, _ := os.Stat(name); info.Mode().Perm() != 0600 {
+		t.Errorf("permission must be kept (actual=%v)", info.Mode().Perm())
+	}
+
+	// no temporary file remains
+	infos, _ := ioutil.ReadDir(dir)
+	if len(infos) != 1 {
+		t.Errorf("the number of files doesn't match (expected=1, actual=%d)", len(infos))
+	}
+}
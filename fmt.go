@@ -1,22 +1,20 @@
 package tdconv
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
 
 	"github.com/iancoleman/strcase"
 )
 
-var createFile func(name string) (io.WriteCloser, error)
-
-func init() {
-	createFile = func(name string) (io.WriteCloser, error) {
-		return os.Create(name)
-	}
-}
-
 // Formatter is an interface for formatting.
 type Formatter interface {
 	Fprint(w io.Writer, t *Table)
@@ -27,11 +25,28 @@ type Formatter interface {
 	Extension() string
 }
 
+// Separator is an optional interface for Formatter.
+// If the Formatter implements it, Output calls Separator between tables
+// in the same file instead of outputting an empty line.
+type Separator interface {
+	Separator(w io.Writer)
+}
+
+// MultiHeader is an optional interface for Formatter.
+// If the Formatter implements it, Output calls MultiHeader instead of Header on each file with multi flag,
+// where i is the index of the table output to the file.
+// It's useful for the header which must not be repeated in every file, like the table of contents.
+type MultiHeader interface {
+	MultiHeader(w io.Writer, ts *TableSet, i int)
+}
+
 type formatter struct {
 	header      func(w io.Writer, ts *TableSet)
+	multiHeader func(w io.Writer, ts *TableSet, i int)
 	tableHeader func(w io.Writer, t *Table)
 	tableFooter func(w io.Writer, t *Table)
 	footer      func(w io.Writer, ts *TableSet)
+	typeMap     map[string]string
 }
 
 func (f *formatter) Header(w io.Writer, ts *TableSet) {
@@ -40,6 +55,15 @@ func (f *formatter) Header(w io.Writer, ts *TableSet) {
 	}
 }
 
+// MultiHeader outputs the header of the file with multi flag. If the header for it is not set, it's same as Header.
+func (f *formatter) MultiHeader(w io.Writer, ts *TableSet, i int) {
+	if f.multiHeader != nil {
+		f.multiHeader(w, ts, i)
+		return
+	}
+	f.Header(w, ts)
+}
+
 func (f *formatter) TableHeader(w io.Writer, t *Table) {
 	if f.tableHeader != nil {
 		f.tableHeader(w, t)
@@ -58,8 +82,43 @@ func (f *formatter) Footer(w io.Writer, ts *TableSet) {
 	}
 }
 
+func typeError(lang string, t *Table, c Column) error {
+	return fmt.Errorf("Unable to convert the type to %s (table=%s, column=%s, type=%s)", lang, t.Name, c.Name, c.Type)
+}
+
+// setTypeMap sets the type mapping which overrides the default type conversion.
+// The key is the base SQL type name, like `VARCHAR`, and it's case-insensitive.
+func (f *formatter) setTypeMap(m map[string]string) {
+	if f.typeMap == nil {
+		f.typeMap = map[string]string{}
+	}
+	for k, v := range m {
+		f.typeMap[strings.ToUpper(k)] = v
+	}
+}
+
+// convType converts the SQL type using the type mapping if mapped, otherwise using conv.
+func (f *formatter) convType(t string, conv func(string) string) string {
+	if s, ok := f.typeMap[parseSQLType(t).name]; ok {
+		return s
+	}
+	return conv(t)
+}
+
+// isMapped reports whether the SQL type is mapped with the type mapping.
+func (f *formatter) isMapped(t string) bool {
+	_, ok := f.typeMap[parseSQLType(t).name]
+	return ok
+}
+
+// setHeader sets the header. The header for multi flag is also cleared, so that the custom header is used for all files.
 func (f *formatter) setHeader(fc func(w io.Writer, ts *TableSet)) {
 	f.header = fc
+	f.multiHeader = nil
+}
+
+func (f *formatter) setMultiHeader(fc func(w io.Writer, ts *TableSet, i int)) {
+	f.multiHeader = fc
 }
 
 func (f *formatter) setTableHeader(fc func(w io.Writer, t *Table)) {
@@ -75,46 +134,442 @@ func (f *formatter) setFooter(fc func(w io.Writer, ts *TableSet)) {
 }
 
 // Output outputs file(s) using Formatter.
-func Output(f Formatter, ts *TableSet, multi bool, outdir string) error {
+// The files are written to the OS file system by default.
+// You can change it with OutputOption, like OutputFileSystem.
+func Output(f Formatter, ts *TableSet, multi bool, outdir string, options ...OutputOption) error {
 
 	if ts == nil {
 		return errors.New("Table set is nil")
 	}
 
+	o := outputOptions{fs: OSFileSystem{}}
+	for _, opt := range options {
+		if err := opt(&o); err != nil {
+			return err
+		}
+	}
+
+	type file struct {
+		name     string
+		from, to int
+	}
+	var files []file
 	if !multi {
-		err := output(f, ts, 0, len(ts.Tables), fmt.Sprintf("%s/%s.%s", outdir, strcase.ToSnake(ts.Name), f.Extension()))
+		files = append(files, file{from: 0, to: len(ts.Tables)})
+	} else {
+		for i := 0; i < len(ts.Tables); i++ {
+			files = append(files, file{from: i, to: i + 1})
+		}
+	}
+
+	names := map[string]string{}
+	for i := range files {
+		var t *Table
+		src := ts.Name
+		if multi {
+			t = ts.Tables[files[i].from]
+			src = t.Name
+		}
+		name, err := o.fileName(ts, t)
+		if err != nil {
+			return fmt.Errorf("Invalid file name (%s): %v", src, err)
+		}
+		if prev, ok := names[name]; ok {
+			return fmt.Errorf("File name conflicts (%s): '%s' and '%s'", name, prev, src)
+		}
+		names[name] = src
+		files[i].name = name
+	}
+
+	// render all files before writing, so that a formatter error doesn't leave some files updated
+	contents := make([][]byte, len(files))
+	for i, file := range files {
+		b := &bytes.Buffer{}
+		if fmtErr, _ := fprint(b, f, ts, file.from, file.to, multi); fmtErr != nil {
+			return fmt.Errorf("Unable to format file (%s): %v", filepath.Join(outdir, filepath.FromSlash(file.name)+"."+f.Extension()), fmtErr)
+		}
+		contents[i] = b.Bytes()
+	}
+
+	var prevNames []string
+	if o.manifest != "" {
+		var err error
+		prevNames, err = readManifest(o.fs, filepath.Join(outdir, o.manifest))
+		if err != nil {
+			return err
+		}
+	}
+
+	generated := map[string]struct{}{}
+	for i, file := range files {
+		name := file.name + "." + f.Extension()
+		generated[name] = struct{}{}
+		path := filepath.Join(outdir, filepath.FromSlash(name))
+		status, err := writeFile(o.fs, path, contents[i])
 		if err != nil {
 			return err
 		}
+		o.report(path, status)
+	}
+
+	if o.manifest != "" {
+		for _, name := range prevNames {
+			if _, ok := generated[name]; ok {
+				continue
+			}
+			path := filepath.Join(outdir, filepath.FromSlash(name))
+			if err := o.fs.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("Unable to remove stale file (%s): %v", path, err)
+			}
+			o.report(path, FileRemoved)
+		}
+		path := filepath.Join(outdir, o.manifest)
+		status, err := writeFile(o.fs, path, manifestContents(generated))
+		if err != nil {
+			return err
+		}
+		o.report(path, status)
+	}
+
+	return nil
+}
+
+// FileStatus is a status of the file output by Output.
+type FileStatus int
+
+// File statuses.
+const (
+	FileCreated FileStatus = iota + 1
+	FileUpdated
+	FileUnchanged
+	FileRemoved
+)
+
+func (s FileStatus) String() string {
+	switch s {
+	case FileCreated:
+		return "created"
+	case FileUpdated:
+		return "updated"
+	case FileUnchanged:
+		return "unchanged"
+	case FileRemoved:
+		return "removed"
+	}
+	return fmt.Sprintf("FileStatus(%d)", int(s))
+}
+
+// FileNameFunc returns the file name without the extension, relative to the output directory.
+// The table is nil if the table set is output as one file.
+// The file name can contain the subdirectories separated by '/'.
+type FileNameFunc func(ts *TableSet, t *Table) string
+
+// FileNameData is a data passed to the template of OutputFileNameTemplate.
+type FileNameData struct {
+	TableSet *TableSet
+	Table    *Table // nil if the table set is output as one file
+	Name     string // name of the table, or the table set if the table is nil
+}
+
+// OutputOption changes the behavior of Output.
+type OutputOption func(*outputOptions) error
+
+type outputOptions struct {
+	fs             FileSystem
+	fileNameFunc   func(ts *TableSet, t *Table) (string, error)
+	prefix, suffix string
+	manifest       string
+	reportFunc     func(name string, s FileStatus)
+}
+
+func (o *outputOptions) report(name string, s FileStatus) {
+	if o.reportFunc != nil {
+		o.reportFunc(name, s)
+	}
+}
+
+// fileName returns the cleaned file name without the extension,
+// and checks that it is inside the output directory.
+func (o *outputOptions) fileName(ts *TableSet, t *Table) (string, error) {
+	var name string
+	if o.fileNameFunc != nil {
+		var err error
+		name, err = o.fileNameFunc(ts, t)
+		if err != nil {
+			return "", err
+		}
+	} else if t != nil {
+		name = strcase.ToSnake(t.Name)
 	} else {
-		for i := 0; i < len(ts.Tables); i++ {
-			err := output(f, ts, i, i+1, fmt.Sprintf("%s/%s.%s", outdir, strcase.ToSnake(ts.Tables[i].Name), f.Extension()))
-			if err != nil {
-				return err
+		name = strcase.ToSnake(ts.Name)
+	}
+
+	name = path.Clean(filepath.ToSlash(name))
+	if name == "." {
+		return "", errors.New("File name is empty")
+	}
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", errors.New("File name must be inside the output directory")
+	}
+
+	dir, base := path.Split(name)
+	return dir + o.prefix + base + o.suffix, nil
+}
+
+// OutputManifest writes the manifest file, which lists the generated files, into the output directory.
+// The files listed in the previous manifest but not generated anymore
+// (e.g. the tables removed from the spreadsheet) are removed.
+// The file system must implement ReadFileSystem.
+func OutputManifest(name string) OutputOption {
+	return func(o *outputOptions) error {
+		if name == "" {
+			return errors.New("Manifest file name must not be empty")
+		}
+		o.manifest = name
+		return nil
+	}
+}
+
+// OutputReport sets the function called with the status of each file.
+func OutputReport(fc func(name string, s FileStatus)) OutputOption {
+	return func(o *outputOptions) error {
+		o.reportFunc = fc
+		return nil
+	}
+}
+
+// OutputFileSystem changes the file system which the files are written to.
+func OutputFileSystem(fs FileSystem) OutputOption {
+	return func(o *outputOptions) error {
+		if fs == nil {
+			return errors.New("File system must not be nil")
+		}
+		o.fs = fs
+		return nil
+	}
+}
+
+// OutputFileName changes the file name.
+// By default, the file name is the snake case of the table set name or the table name.
+func OutputFileName(fc FileNameFunc) OutputOption {
+	return func(o *outputOptions) error {
+		if fc == nil {
+			return errors.New("File name function must not be nil")
+		}
+		o.fileNameFunc = func(ts *TableSet, t *Table) (string, error) {
+			return fc(ts, t), nil
+		}
+		return nil
+	}
+}
+
+// OutputFileNameTemplate changes the file name with `text/template`.
+// The template is executed with FileNameData, and the functions returned by TemplateFuncMap are available.
+// For example, `{{ snake .TableSet.Name }}/{{ snake .Name }}`.
+func OutputFileNameTemplate(text string) OutputOption {
+	return func(o *outputOptions) error {
+		tmpl, err := template.New("filename").Funcs(TemplateFuncMap()).Parse(text)
+		if err != nil {
+			return fmt.Errorf("Unable to parse file name template: %v", err)
+		}
+		o.fileNameFunc = func(ts *TableSet, t *Table) (string, error) {
+			d := FileNameData{TableSet: ts, Table: t, Name: ts.Name}
+			if t != nil {
+				d.Name = t.Name
 			}
+			var b strings.Builder
+			if err := tmpl.Execute(&b, d); err != nil {
+				return "", fmt.Errorf("Unable to execute file name template: %v", err)
+			}
+			return b.String(), nil
 		}
+		return nil
+	}
+}
+
+// OutputFileNamePrefix adds the prefix to the base name of the file.
+func OutputFileNamePrefix(prefix string) OutputOption {
+	return func(o *outputOptions) error {
+		o.prefix = prefix
+		return nil
+	}
+}
+
+// OutputFileNameSuffix adds the suffix to the base name of the file (before the extension), like `_gen`.
+func OutputFileNameSuffix(suffix string) OutputOption {
+	return func(o *outputOptions) error {
+		o.suffix = suffix
+		return nil
+	}
+}
+
+// FprintTableSet outputs all tables in the table set to the writer as one file using Formatter.
+func FprintTableSet(w io.Writer, f Formatter, ts *TableSet) error {
+
+	if ts == nil {
+		return errors.New("Table set is nil")
+	}
+
+	fmtErr, writeErr := fprint(w, f, ts, 0, len(ts.Tables), false)
+	if fmtErr != nil {
+		return fmt.Errorf("Unable to format: %v", fmtErr)
+	}
+	if writeErr != nil {
+		return fmt.Errorf("Unable to write: %v", writeErr)
 	}
 
 	return nil
 }
 
-func output(f Formatter, ts *TableSet, from, to int, filepath string) error {
-	file, err := createFile(filepath)
+// writeFile writes the file unless the contents are identical to the current file.
+// If the file system implements ReadFileSystem, the current file is compared.
+func writeFile(fs FileSystem, name string, data []byte) (FileStatus, error) {
+
+	status := FileCreated
+	if rfs, ok := fs.(ReadFileSystem); ok {
+		current, err := rfs.ReadFile(name)
+		if err == nil {
+			if bytes.Equal(current, data) {
+				return FileUnchanged, nil
+			}
+			status = FileUpdated
+		} else if !os.IsNotExist(err) {
+			return 0, fmt.Errorf("Unable to read file (%s): %v", name, err)
+		}
+	}
+
+	file, err := fs.Create(name)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := file.Write(data); err != nil {
+		if a, ok := file.(Aborter); ok {
+			a.Abort()
+		} else {
+			file.Close()
+			fs.Remove(name)
+		}
+		return 0, fmt.Errorf("Unable to write file (%s): %v", name, err)
+	}
+	if err := file.Close(); err != nil {
+		if _, ok := file.(Aborter); !ok {
+			fs.Remove(name)
+		}
+		return 0, fmt.Errorf("Unable to close file (%s): %v", name, err)
+	}
+
+	return status, nil
+}
+
+const manifestHeader = "# This file generated by tdconv. DO NOT EDIT.\n"
+
+func readManifest(fs FileSystem, name string) ([]string, error) {
+
+	rfs, ok := fs.(ReadFileSystem)
+	if !ok {
+		return nil, errors.New("File system must implement ReadFileSystem to use manifest")
+	}
+	b, err := rfs.ReadFile(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("Unable to read manifest file (%s): %v", name, err)
+	}
+
+	var names []string
+	for _, l := range strings.Split(string(b), "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		// never remove the files outside the output directory
+		c := path.Clean(l)
+		if path.IsAbs(c) || c == ".." || strings.HasPrefix(c, "../") {
+			return nil, fmt.Errorf("Invalid file name in manifest file (%s): %s", name, l)
+		}
+		names = append(names, c)
 	}
-	defer file.Close()
+	return names, nil
+}
 
-	f.Header(file, ts)
+func manifestContents(names map[string]struct{}) []byte {
+	ns := make([]string, 0, len(names))
+	for n := range names {
+		ns = append(ns, n)
+	}
+	sort.Strings(ns)
+	return []byte(manifestHeader + strings.Join(ns, "\n") + "\n")
+}
+
+func fprint(file io.Writer, f Formatter, ts *TableSet, from, to int, multi bool) (fmtErr, writeErr error) {
+
+	w := &fprintWriter{w: file, ts: ts, tables: ts.Tables[from:to]}
+	if mh, ok := f.(MultiHeader); ok && multi {
+		mh.MultiHeader(w, ts, from)
+	} else {
+		f.Header(w, ts)
+	}
 	for i := from; i < to; i++ {
-		f.TableHeader(file, ts.Tables[i])
-		f.Fprint(file, ts.Tables[i])
-		f.TableFooter(file, ts.Tables[i])
+		f.TableHeader(w, ts.Tables[i])
+		f.Fprint(w, ts.Tables[i])
+		f.TableFooter(w, ts.Tables[i])
 		if i < to-1 {
-			fmt.Fprintln(file)
+			if s, ok := f.(Separator); ok {
+				s.Separator(w)
+			} else {
+				fmt.Fprintln(w)
+			}
 		}
 	}
-	f.Footer(file, ts)
+	f.Footer(w, ts)
+
+	return w.fmtErr, w.err
+}
+
+// fprintWriter is the writer passed to the Formatter by Output and FprintTableSet.
+// It keeps the first error of the writer, and skips the following writes.
+// It also carries the context of the file being output, like the errors reported by the Formatter,
+// so that the Formatter keeps no state between the calls and can be used concurrently.
+type fprintWriter struct {
+	w      io.Writer
+	err    error
+	fmtErr error
+	ts     *TableSet
+	tables []*Table // tables output to the file
+}
+
+func (w *fprintWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(p)
+	w.err = err
+	return n, err
+}
+
+// ReportError reports the error occurred on formatting, like a type which can't be mapped.
+// Call it with the writer passed to the Formatter,
+// then Output and FprintTableSet fail with the first error reported on the file.
+// The error reported with the other writer is ignored.
+func ReportError(w io.Writer, err error) {
+	if fw, ok := w.(*fprintWriter); ok && err != nil && fw.fmtErr == nil {
+		fw.fmtErr = err
+	}
+}
+
+// tableSetOf returns the table set being output with the writer, or nil if the writer is not passed by fprint.
+func tableSetOf(w io.Writer) *TableSet {
+	if fw, ok := w.(*fprintWriter); ok {
+		return fw.ts
+	}
+	return nil
+}
 
+// fileTablesOf returns the tables output to the file with the writer, or nil if the writer is not passed by fprint.
+func fileTablesOf(w io.Writer) []*Table {
+	if fw, ok := w.(*fprintWriter); ok {
+		return fw.tables
+	}
 	return nil
 }
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/takuoki/tdconv"
@@ -37,26 +39,68 @@ func (*testFormatter) Extension() string {
 	return "test"
 }
 
+type testSeparatorFormatter struct {
+	testFormatter
+}
+
+func (*testSeparatorFormatter) Separator(w io.Writer) {
+	fmt.Fprintln(w, "separator")
+}
+
+type testReportFormatter struct {
+	testFormatter
+}
+
+func (*testReportFormatter) Fprint(w io.Writer, t *tdconv.Table) {
+	tdconv.ReportError(w, fmt.Errorf("error: %s", t.Name))
+}
+
 type nopCloser struct {
 	io.Writer
 }
 
 func (nopCloser) Close() error { return nil }
 
-func TestOutput(t *testing.T) {
+type errWriteCloser struct {
+	writeErr, closeErr error
+}
 
-	var outputMap map[string]*bytes.Buffer
-	errFileName := "output_dir/error.test"
-	createFile := func(name string) (io.WriteCloser, error) {
-		if name == errFileName {
-			return nil, errors.New("error")
-		}
-		b := &bytes.Buffer{}
-		outputMap[name] = b
-		return nopCloser{b}, nil
+func (w errWriteCloser) Write(p []byte) (int, error) {
+	if w.writeErr != nil {
+		return 0, w.writeErr
+	}
+	return len(p), nil
+}
+
+func (w errWriteCloser) Close() error { return w.closeErr }
+
+type testFileSystem struct {
+	outputMap map[string]*bytes.Buffer
+	removed   []string
+}
+
+func (fs *testFileSystem) Create(name string) (io.WriteCloser, error) {
+	switch name {
+	case "output_dir/error.test":
+		return nil, errors.New("error")
+	case "output_dir/write_error.test":
+		return errWriteCloser{writeErr: errors.New("error")}, nil
+	case "output_dir/close_error.test":
+		return errWriteCloser{closeErr: errors.New("error")}, nil
 	}
-	resetFunc := tdconv.SetCreateFile(createFile)
-	defer resetFunc()
+	b := &bytes.Buffer{}
+	fs.outputMap[name] = b
+	return nopCloser{b}, nil
+}
+
+func (fs *testFileSystem) Remove(name string) error {
+	fs.removed = append(fs.removed, name)
+	return nil
+}
+
+func TestOutput(t *testing.T) {
+
+	var fs *testFileSystem
 
 	optFunc := func(s string) func(io.Writer, *tdconv.TableSet) {
 		return func(w io.Writer, _ *tdconv.TableSet) {
@@ -74,8 +118,10 @@ func TestOutput(t *testing.T) {
 		f        tdconv.Formatter
 		tableSet *tdconv.TableSet
 		multi    bool
+		opts     []tdconv.OutputOption
 		expected map[string]string
 		errMsg   string
+		removed  []string
 	}{
 		{
 			caseName: "success: non-multi",
@@ -122,6 +168,28 @@ func TestOutput(t *testing.T) {
 					"footer: sample_table_set\n",
 			},
 		},
+		{
+			caseName: "success: separator",
+			f:        &testSeparatorFormatter{},
+			tableSet: &tdconv.TableSet{
+				Name: "sample_table_set",
+				Tables: []*tdconv.Table{
+					{Name: "sample_table_1"},
+					{Name: "sample_table_2"},
+				},
+			},
+			expected: map[string]string{
+				"output_dir/sample_table_set.test": "header: sample_table_set\n" +
+					"table header: sample_table_1\n" +
+					"table contents: sample_table_1\n" +
+					"table footer: sample_table_1\n" +
+					"separator\n" +
+					"table header: sample_table_2\n" +
+					"table contents: sample_table_2\n" +
+					"table footer: sample_table_2\n" +
+					"footer: sample_table_set\n",
+			},
+		},
 		{
 			caseName: "success: SQL formatter",
 			f: mustSQLFormatter(
@@ -189,6 +257,104 @@ func TestOutput(t *testing.T) {
 					"// footer\n",
 			},
 		},
+		{
+			caseName: "success: prefix and suffix",
+			f:        &testFormatter{},
+			tableSet: &tdconv.TableSet{
+				Name:   "sample_table_set",
+				Tables: []*tdconv.Table{{Name: "sample_table_1"}},
+			},
+			multi: true,
+			opts:  []tdconv.OutputOption{tdconv.OutputFileNamePrefix("pre_"), tdconv.OutputFileNameSuffix("_gen")},
+			expected: map[string]string{
+				"output_dir/pre_sample_table_1_gen.test": "header: sample_table_set\n" +
+					"table header: sample_table_1\n" +
+					"table contents: sample_table_1\n" +
+					"table footer: sample_table_1\n" +
+					"footer: sample_table_set\n",
+			},
+		},
+		{
+			caseName: "success: file name function with subdirectory",
+			f:        &testFormatter{},
+			tableSet: &tdconv.TableSet{
+				Name:   "sample_table_set",
+				Tables: []*tdconv.Table{{Name: "sample_table_1"}},
+			},
+			opts: []tdconv.OutputOption{
+				tdconv.OutputFileName(func(ts *tdconv.TableSet, _ *tdconv.Table) string { return "sub/dir/" + ts.Name }),
+				tdconv.OutputFileNameSuffix("_gen"),
+			},
+			expected: map[string]string{
+				"output_dir/sub/dir/sample_table_set_gen.test": "header: sample_table_set\n" +
+					"table header: sample_table_1\n" +
+					"table contents: sample_table_1\n" +
+					"table footer: sample_table_1\n" +
+					"footer: sample_table_set\n",
+			},
+		},
+		{
+			caseName: "success: file name template",
+			f:        &testFormatter{},
+			tableSet: &tdconv.TableSet{
+				Name:   "Sample Table Set",
+				Tables: []*tdconv.Table{{Name: "SampleTable1"}},
+			},
+			multi: true,
+			opts:  []tdconv.OutputOption{tdconv.OutputFileNameTemplate("{{ snake .TableSet.Name }}/{{ kebab .Name }}")},
+			expected: map[string]string{
+				"output_dir/sample_table_set/sample-table-1.test": "header: Sample Table Set\n" +
+					"table header: SampleTable1\n" +
+					"table contents: SampleTable1\n" +
+					"table footer: SampleTable1\n" +
+					"footer: Sample Table Set\n",
+			},
+		},
+		{
+			caseName: "failure: file name conflicts",
+			f:        &testFormatter{},
+			tableSet: &tdconv.TableSet{
+				Name:   "sample_table_set",
+				Tables: []*tdconv.Table{{Name: "SampleTable"}, {Name: "sample_table"}},
+			},
+			multi:  true,
+			errMsg: "File name conflicts (sample_table)",
+		},
+		{
+			caseName: "failure: file name outside the output directory",
+			f:        &testFormatter{},
+			tableSet: &tdconv.TableSet{Name: "sample_table_set"},
+			opts:     []tdconv.OutputOption{tdconv.OutputFileNameTemplate("../{{ .Name }}")},
+			errMsg:   "Invalid file name (sample_table_set)",
+		},
+		{
+			caseName: "failure: empty file name",
+			f:        &testFormatter{},
+			tableSet: &tdconv.TableSet{Name: "sample_table_set"},
+			opts:     []tdconv.OutputOption{tdconv.OutputFileNameTemplate("")},
+			errMsg:   "Invalid file name (sample_table_set)",
+		},
+		{
+			caseName: "failure: file name template error",
+			f:        &testFormatter{},
+			tableSet: &tdconv.TableSet{Name: "sample_table_set"},
+			opts:     []tdconv.OutputOption{tdconv.OutputFileNameTemplate("{{ .Foo }}")},
+			errMsg:   "Invalid file name (sample_table_set)",
+		},
+		{
+			caseName: "failure: file name template parse error",
+			f:        &testFormatter{},
+			tableSet: &tdconv.TableSet{Name: "sample_table_set"},
+			opts:     []tdconv.OutputOption{tdconv.OutputFileNameTemplate("{{ .Name ")},
+			errMsg:   "Unable to parse file name template",
+		},
+		{
+			caseName: "failure: file name function is nil",
+			f:        &testFormatter{},
+			tableSet: &tdconv.TableSet{Name: "sample_table_set"},
+			opts:     []tdconv.OutputOption{tdconv.OutputFileName(nil)},
+			errMsg:   "File name function must not be nil",
+		},
 		{
 			caseName: "failure: table set is nil",
 			f:        &testFormatter{},
@@ -219,12 +385,47 @@ func TestOutput(t *testing.T) {
 			multi:  true,
 			errMsg: "error",
 		},
+		{
+			caseName: "failure: write error",
+			f:        &testFormatter{},
+			tableSet: &tdconv.TableSet{
+				Name:   "write_error",
+				Tables: []*tdconv.Table{{Name: "sample_table_1"}},
+			},
+			errMsg:  "Unable to write file (output_dir/write_error.test)",
+			removed: []string{"output_dir/write_error.test"},
+		},
+		{
+			caseName: "failure: close error",
+			f:        &testFormatter{},
+			tableSet: &tdconv.TableSet{
+				Name:   "close_error",
+				Tables: []*tdconv.Table{{Name: "sample_table_1"}},
+			},
+			errMsg:  "Unable to close file (output_dir/close_error.test)",
+			removed: []string{"output_dir/close_error.test"},
+		},
+		{
+			caseName: "failure: formatter error",
+			f:        mustGoFormatter(),
+			tableSet: &tdconv.TableSet{
+				Name: "sample_table_set",
+				Tables: []*tdconv.Table{
+					{
+						Name:    "sample_table",
+						Columns: []tdconv.Column{{Name: "geom", Type: "GEOMETRY"}},
+					},
+				},
+			},
+			errMsg: "Unable to format file (output_dir/sample_table_set.go)",
+		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.caseName, func(t *testing.T) {
-			outputMap = map[string]*bytes.Buffer{}
-			err := tdconv.Output(c.f, c.tableSet, c.multi, "output_dir")
+			fs = &testFileSystem{outputMap: map[string]*bytes.Buffer{}}
+			outputMap := fs.outputMap
+			err := tdconv.Output(c.f, c.tableSet, c.multi, "output_dir", append(c.opts, tdconv.OutputFileSystem(fs))...)
 
 			if c.errMsg == "" {
 				if err != nil {
@@ -244,6 +445,122 @@ func TestOutput(t *testing.T) {
 						return
 					}
 				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if endIndex := strings.Index(err.Error(), ":"); endIndex < 0 {
+					if err.Error() != c.errMsg {
+						t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+						return
+					}
+				} else if err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+					return
+				}
+				if !reflect.DeepEqual(fs.removed, c.removed) {
+					t.Errorf("removed files don't match (expected=%v, actual=%v)", c.removed, fs.removed)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestOutput_OptionError(t *testing.T) {
+	err := tdconv.Output(&testFormatter{}, &tdconv.TableSet{Name: "sample_table_set"}, false, "output_dir", tdconv.OutputFileSystem(nil))
+	if err == nil || err.Error() != "File system must not be nil" {
+		t.Errorf("error message doesn't match (expected=File system must not be nil, actual=%v)", err)
+	}
+}
+
+func TestFprintTableSet(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		w        io.Writer
+		f        tdconv.Formatter
+		tableSet *tdconv.TableSet
+		expected string
+		errMsg   string
+	}{
+		{
+			caseName: "success",
+			w:        &bytes.Buffer{},
+			f:        &testFormatter{},
+			tableSet: &tdconv.TableSet{
+				Name: "sample_table_set",
+				Tables: []*tdconv.Table{
+					{Name: "sample_table_1"},
+					{Name: "sample_table_2"},
+				},
+			},
+			expected: "header: sample_table_set\n" +
+				"table header: sample_table_1\n" +
+				"table contents: sample_table_1\n" +
+				"table footer: sample_table_1\n\n" +
+				"table header: sample_table_2\n" +
+				"table contents: sample_table_2\n" +
+				"table footer: sample_table_2\n" +
+				"footer: sample_table_set\n",
+		},
+		{
+			caseName: "failure: table set is nil",
+			w:        &bytes.Buffer{},
+			f:        &testFormatter{},
+			tableSet: nil,
+			errMsg:   "Table set is nil",
+		},
+		{
+			caseName: "failure: write error",
+			w:        errWriteCloser{writeErr: errors.New("error")},
+			f:        &testFormatter{},
+			tableSet: &tdconv.TableSet{Name: "sample_table_set"},
+			errMsg:   "Unable to write",
+		},
+		{
+			caseName: "failure: formatter error",
+			w:        &bytes.Buffer{},
+			f:        mustGoFormatter(),
+			tableSet: &tdconv.TableSet{
+				Name: "sample_table_set",
+				Tables: []*tdconv.Table{
+					{
+						Name:    "sample_table",
+						Columns: []tdconv.Column{{Name: "geom", Type: "GEOMETRY"}},
+					},
+				},
+			},
+			errMsg: "Unable to format",
+		},
+		{
+			caseName: "failure: reported error",
+			w:        &bytes.Buffer{},
+			f:        &testReportFormatter{},
+			tableSet: &tdconv.TableSet{
+				Name: "sample_table_set",
+				Tables: []*tdconv.Table{
+					{Name: "sample_table_1"},
+					{Name: "sample_table_2"},
+				},
+			},
+			errMsg: "Unable to format",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			err := tdconv.FprintTableSet(c.w, c.f, c.tableSet)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+				if a := c.w.(*bytes.Buffer).String(); a != c.expected {
+					t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, a)
+				}
 			} else {
 				if err == nil {
 					t.Errorf("error must occur")
@@ -262,3 +579,41 @@ func TestOutput(t *testing.T) {
 		})
 	}
 }
+
+func TestFprintTableSet_reuse(t *testing.T) {
+
+	f := mustGoFormatter()
+	invalid := &tdconv.TableSet{
+		Name:   "invalid",
+		Tables: []*tdconv.Table{{Name: "sample_table", Columns: []tdconv.Column{{Name: "geom", Type: "GEOMETRY"}}}},
+	}
+	valid := &tdconv.TableSet{
+		Name:   "valid",
+		Tables: []*tdconv.Table{{Name: "sample_table", Columns: []tdconv.Column{{Name: "id", Type: "INT"}}}},
+	}
+
+	// the formatter keeps no state between the calls, so it can be used concurrently and after a failure
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ts := valid
+			if i%2 == 0 {
+				ts = invalid
+			}
+			errs[i] = tdconv.FprintTableSet(&bytes.Buffer{}, f, ts)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if i%2 == 0 && err == nil {
+			t.Errorf("error must occur (%d)", i)
+		}
+		if i%2 != 0 && err != nil {
+			t.Errorf("error must not occur (%d): %v", i, err)
+		}
+	}
+}
@@ -1,12 +1,32 @@
 module github.com/takuoki/tdconv
 
+go 1.17
+
 require (
 	github.com/iancoleman/strcase v0.0.0-20180726023541-3605ed457bf7
-	github.com/mattn/go-runewidth v0.0.4 // indirect
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/olekukonko/tablewriter v0.0.1
 	github.com/takuoki/clmconv v1.0.0
 	github.com/takuoki/gocase v1.0.0
 	github.com/takuoki/gostr v0.0.0-20180826070049-ca8c73a0e8e2
 	github.com/takuoki/gsheets v0.1.1
 	github.com/urfave/cli v1.20.0
+	golang.org/x/oauth2 v0.0.0-20190319182350-c85d3e98c914
+	google.golang.org/api v0.3.0
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+require (
+	cloud.google.com/go v0.34.0 // indirect
+	github.com/golang/protobuf v1.2.0 // indirect
+	github.com/hashicorp/golang-lru v0.5.0 // indirect
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/mattn/go-runewidth v0.0.4 // indirect
+	go.opencensus.io v0.19.2 // indirect
+	golang.org/x/net v0.0.0-20190311183353-d8887717615a // indirect
+	golang.org/x/sys v0.0.0-20190215142949-d0b11bdaac8a // indirect
+	golang.org/x/text v0.3.0 // indirect
+	google.golang.org/appengine v1.4.0 // indirect
+	google.golang.org/genproto v0.0.0-20190307195333-5fe7a883aa19 // indirect
+	google.golang.org/grpc v1.19.0 // indirect
 )
@@ -3,8 +3,6 @@ package tdconv
 import (
 	"fmt"
 	"io"
-	"regexp"
-	"strings"
 
 	"github.com/iancoleman/strcase"
 	"github.com/takuoki/gocase"
@@ -70,6 +68,15 @@ func GoFooter(fc func(w io.Writer, ts *TableSet)) GoFormatOption {
 	}
 }
 
+// GoTypeMap overrides the type conversion to Go type.
+// The key is the base SQL type name, like `VARCHAR`, and it's case-insensitive.
+func GoTypeMap(m map[string]string) GoFormatOption {
+	return func(f *GoFormatter) error {
+		f.setTypeMap(m)
+		return nil
+	}
+}
+
 // Extension returns the extension of Go file.
 func (f *GoFormatter) Extension() string {
 	return "go"
@@ -82,22 +89,30 @@ func (f *GoFormatter) Fprint(w io.Writer, t *Table) {
 		return
 	}
 
-	structName := gocase.To(strcase.ToLowerCamel(t.Name))
-	fmt.Fprintf(w, "type %s struct {\n", structName)
+	fmt.Fprintf(w, "type %s struct {\n", goStructName(t))
 
 	for _, c := range t.Columns {
-		propertyName := gocase.To(strcase.ToCamel(c.Name))
-		fmt.Fprintf(w, "\t%s %s\n", propertyName, f.convType(c.Type))
+		typ := f.convType(c.Type, convGoType)
+		if typ == "UNKNOWN" {
+			ReportError(w, typeError("Go", t, c))
+		}
+		fmt.Fprintf(w, "\t%s %s\n", goFieldName(c), typ)
 	}
 
 	fmt.Fprintln(w, "}")
 }
 
-var tRegexp = regexp.MustCompile("^([a-zA-Z]+)[ (]{1}.*$")
+func goStructName(t *Table) string {
+	return gocase.To(strcase.ToLowerCamel(t.Name))
+}
+
+func goFieldName(c Column) string {
+	return gocase.To(strcase.ToCamel(c.Name))
+}
 
-func (f *GoFormatter) convType(t string) string {
+func convGoType(t string) string {
 	var r string
-	switch strings.ToUpper(tRegexp.ReplaceAllString(t, "$1")) {
+	switch parseSQLType(t).name {
 	case "INT", "TINYINT", "BIGINT":
 		r = "*int"
 	case "DOUBLE":
@@ -38,6 +38,7 @@ func TestNewGoFormatter(t *testing.T) {
 				tdconv.GoTableHeader(nil),
 				tdconv.GoTableFooter(nil),
 				tdconv.GoFooter(nil),
+				tdconv.GoTypeMap(map[string]string{"json": "string"}),
 			},
 		},
 		{
@@ -183,6 +184,24 @@ func TestGoFormatter_Fprint(t *testing.T) {
 				"	Baz UNKNOWN\n" +
 				"}\n",
 		},
+		{
+			caseName: "type map",
+			f:        mustGoFormatter(tdconv.GoTypeMap(map[string]string{"varchar": "string", "JSON": "json.RawMessage"})),
+			t: &tdconv.Table{
+				Name: "sample_table",
+				Columns: []tdconv.Column{
+					{Name: "id", Type: "INT UNSIGNED", PKey: true, NotNull: true, Unique: false, Index: false, Option: "AUTO_INCREMENT", Comment: "this is id!", IsCommon: false},
+					{Name: "foo", Type: "VARCHAR(32)", PKey: false, NotNull: true, Unique: false, Index: false, Option: "", Comment: "", IsCommon: false},
+					{Name: "bar", Type: "JSON", PKey: false, NotNull: false, Unique: false, Index: false, Option: "", Comment: "", IsCommon: false},
+				},
+				PKeyColumns: []string{"id"},
+			},
+			expected: "type sampleTable struct {\n" +
+				"	ID *int\n" +
+				"	Foo string\n" +
+				"	Bar json.RawMessage\n" +
+				"}\n",
+		},
 	}
 
 	for _, c := range cases {
@@ -0,0 +1,350 @@
+package tdconv
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/takuoki/gocase"
+)
+
+// GoRepositoryFormatter is a formatter to output the table definision as Go repository code using `database/sql`.
+// The generated code depends on the structs output by GoFormatter, so output both into the same package.
+type GoRepositoryFormatter struct {
+	formatter
+}
+
+// NewGoRepositoryFormatter creates a new GoRepositoryFormatter.
+// You can change some parameters of the GoRepositoryFormatter with GoRepositoryFormatOption.
+func NewGoRepositoryFormatter(options ...GoRepositoryFormatOption) (*GoRepositoryFormatter, error) {
+
+	f := GoRepositoryFormatter{}
+	f.setHeader(func(w io.Writer, ts *TableSet) {
+		f.fprintHeader(w, ts.Tables)
+	})
+	// the imports are decided by the table in the file with multi flag, because Go doesn't allow unused imports
+	f.setMultiHeader(func(w io.Writer, ts *TableSet, i int) {
+		f.fprintHeader(w, ts.Tables[i:i+1])
+	})
+	for _, opt := range options {
+		err := opt(&f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+func (f *GoRepositoryFormatter) fprintHeader(w io.Writer, ts []*Table) {
+	fmt.Fprint(w,
+		"// This file generated by tdconv. DO NOT EDIT.\n"+
+			"// See more details at https://github.com/takuoki/tdconv.\n"+
+			"package main\n\nimport (\n\t\"context\"\n\t\"database/sql\"\n")
+	if f.needsTime(ts) {
+		fmt.Fprint(w, "\t\"time\"\n")
+	}
+	fmt.Fprint(w, ")\n\n")
+}
+
+// needsTime reports whether the methods of the tables have the parameters of `time` package, like `time.Time`.
+func (f *GoRepositoryFormatter) needsTime(ts []*Table) bool {
+	for _, t := range ts {
+		for _, c := range paramColumns(t) {
+			if strings.HasPrefix(strings.TrimPrefix(f.convType(c.Type, convGoType), "*"), "time.") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GoRepositoryFormatOption changes some parameters of the GoRepositoryFormatter.
+type GoRepositoryFormatOption func(*GoRepositoryFormatter) error
+
+// GoRepositoryHeader changes the header.
+func GoRepositoryHeader(fc func(w io.Writer, ts *TableSet)) GoRepositoryFormatOption {
+	return func(f *GoRepositoryFormatter) error {
+		f.setHeader(fc)
+		return nil
+	}
+}
+
+// GoRepositoryTableHeader changes the header of each table.
+func GoRepositoryTableHeader(fc func(w io.Writer, t *Table)) GoRepositoryFormatOption {
+	return func(f *GoRepositoryFormatter) error {
+		f.setTableHeader(fc)
+		return nil
+	}
+}
+
+// GoRepositoryTableFooter changes the footer of each table.
+func GoRepositoryTableFooter(fc func(w io.Writer, t *Table)) GoRepositoryFormatOption {
+	return func(f *GoRepositoryFormatter) error {
+		f.setTableFooter(fc)
+		return nil
+	}
+}
+
+// GoRepositoryFooter changes the footer.
+func GoRepositoryFooter(fc func(w io.Writer, ts *TableSet)) GoRepositoryFormatOption {
+	return func(f *GoRepositoryFormatter) error {
+		f.setFooter(fc)
+		return nil
+	}
+}
+
+// GoRepositoryTypeMap overrides the type conversion to Go type.
+// The key is the base SQL type name, like `VARCHAR`, and it's case-insensitive.
+func GoRepositoryTypeMap(m map[string]string) GoRepositoryFormatOption {
+	return func(f *GoRepositoryFormatter) error {
+		f.setTypeMap(m)
+		return nil
+	}
+}
+
+// Extension returns the extension of Go file.
+func (f *GoRepositoryFormatter) Extension() string {
+	return "go"
+}
+
+// Fprint outputs the table definision as Go repository code.
+// The repository has Insert, GetByPK, Update and Delete methods,
+// and lookup methods for each unique key and index key.
+func (f *GoRepositoryFormatter) Fprint(w io.Writer, t *Table) {
+
+	if f == nil || t == nil {
+		return
+	}
+
+	structName := goStructName(t)
+	repoName := structName + "Repository"
+	scanName := "scan" + strcase.ToCamel(structName)
+
+	var pkeys, others []Column
+	var autoIncrement *Column
+	for i, c := range t.Columns {
+		if f.convType(c.Type, convGoType) == "UNKNOWN" {
+			ReportError(w, typeError("Go", t, c))
+		}
+		if c.PKey {
+			pkeys = append(pkeys, c)
+		} else {
+			others = append(others, c)
+		}
+		if strings.Contains(strings.ToUpper(c.Option), "AUTO_INCREMENT") {
+			autoIncrement = &t.Columns[i]
+		}
+	}
+
+	fmt.Fprintf(w, "type %s struct {\n\tdb *sql.DB\n}\n\n", repoName)
+	fmt.Fprintf(w, "func new%s(db *sql.DB) *%s {\n\treturn &%s{db: db}\n}\n\n", strcase.ToCamel(repoName), repoName, repoName)
+
+	fmt.Fprintf(w, "const %sColumns = %q\n\n", structName, quoteColumns(columnNames(t.Columns)))
+
+	fmt.Fprintf(w, "func %s(s interface{ Scan(dest ...interface{}) error }) (*%s, error) {\n", scanName, structName)
+	fmt.Fprintf(w, "\tv := &%s{}\n", structName)
+	fields := make([]string, 0, len(t.Columns))
+	for _, c := range t.Columns {
+		fields = append(fields, "&v."+goFieldName(c))
+	}
+	fmt.Fprintf(w, "\tif err := s.Scan(%s); err != nil {\n\t\treturn nil, err\n\t}\n\treturn v, nil\n}\n", strings.Join(fields, ", "))
+
+	// Insert
+	var insertColumns []Column
+	for _, c := range t.Columns {
+		if autoIncrement != nil && c.Name == autoIncrement.Name {
+			continue
+		}
+		insertColumns = append(insertColumns, c)
+	}
+	fmt.Fprintf(w, "\n// Insert inserts the record into %s.\n", t.Name)
+	fmt.Fprintf(w, "func (r *%s) Insert(ctx context.Context, v *%s) error {\n", repoName, structName)
+	query := fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)", t.Name, quoteColumns(columnNames(insertColumns)), placeholders(len(insertColumns)))
+	if autoIncrement != nil && f.convType(autoIncrement.Type, convGoType) == "*int" {
+		fmt.Fprintf(w, "\tres, err := r.db.ExecContext(ctx, %q%s)\n", query, fieldArgs("v.", insertColumns))
+		fmt.Fprint(w, "\tif err != nil {\n\t\treturn err\n\t}\n")
+		fmt.Fprint(w, "\tid, err := res.LastInsertId()\n\tif err != nil {\n\t\treturn err\n\t}\n")
+		fmt.Fprintf(w, "\tn := int(id)\n\tv.%s = &n\n\treturn nil\n}\n", goFieldName(*autoIncrement))
+	} else {
+		fmt.Fprintf(w, "\t_, err := r.db.ExecContext(ctx, %q%s)\n\treturn err\n}\n", query, fieldArgs("v.", insertColumns))
+	}
+
+	if len(pkeys) > 0 {
+
+		// GetByPK
+		fmt.Fprintf(w, "\n// GetByPK gets the record from %s by the primary key.\n", t.Name)
+		f.fprintGet(w, t, "GetByPK", pkeys)
+
+		// Update
+		if len(others) > 0 {
+			sets := make([]string, 0, len(others))
+			for _, c := range others {
+				sets = append(sets, "`"+c.Name+"` = ?")
+			}
+			fmt.Fprintf(w, "\n// Update updates the record of %s by the primary key.\n", t.Name)
+			fmt.Fprintf(w, "func (r *%s) Update(ctx context.Context, v *%s) error {\n", repoName, structName)
+			query := fmt.Sprintf("UPDATE `%s` SET %s WHERE %s", t.Name, strings.Join(sets, ", "), whereClause(pkeys))
+			fmt.Fprintf(w, "\t_, err := r.db.ExecContext(ctx, %q%s%s)\n\treturn err\n}\n", query, fieldArgs("v.", others), fieldArgs("v.", pkeys))
+		}
+
+		// Delete
+		fmt.Fprintf(w, "\n// Delete deletes the record from %s by the primary key.\n", t.Name)
+		fmt.Fprintf(w, "func (r *%s) Delete(ctx context.Context, %s) error {\n", repoName, f.params(pkeys))
+		query := fmt.Sprintf("DELETE FROM `%s` WHERE %s", t.Name, whereClause(pkeys))
+		fmt.Fprintf(w, "\t_, err := r.db.ExecContext(ctx, %q%s)\n\treturn err\n}\n", query, paramArgs(pkeys))
+	}
+
+	done := map[string]struct{}{"GetByPK": {}}
+
+	// unique keys
+	var uniqueKeys [][]Column
+	for _, c := range t.Columns {
+		if c.Unique {
+			uniqueKeys = append(uniqueKeys, []Column{c})
+		}
+	}
+	for _, k := range t.UniqueKeys {
+		if cs := keyColumns(t, k); cs != nil {
+			uniqueKeys = append(uniqueKeys, cs)
+		}
+	}
+	for _, cs := range uniqueKeys {
+		method := "GetBy" + methodSuffix(cs)
+		if _, ok := done[method]; ok {
+			continue
+		}
+		done[method] = struct{}{}
+		fmt.Fprintf(w, "\n// %s gets the record from %s by the unique key.\n", method, t.Name)
+		f.fprintGet(w, t, method, cs)
+	}
+
+	// index keys
+	for _, k := range t.IndexKeys {
+		cs := keyColumns(t, k)
+		if cs == nil {
+			continue
+		}
+		method := "FindBy" + methodSuffix(cs)
+		if _, ok := done[method]; ok {
+			continue
+		}
+		done[method] = struct{}{}
+		fmt.Fprintf(w, "\n// %s finds the records from %s by the index key.\n", method, t.Name)
+		fmt.Fprintf(w, "func (r *%s) %s(ctx context.Context, %s) ([]*%s, error) {\n", repoName, method, f.params(cs), structName)
+		query := fmt.Sprintf("SELECT \"+%sColumns+\" FROM `%s` WHERE %s", structName, t.Name, whereClause(cs))
+		fmt.Fprintf(w, "\trows, err := r.db.QueryContext(ctx, \"%s\"%s)\n", query, paramArgs(cs))
+		fmt.Fprint(w, "\tif err != nil {\n\t\treturn nil, err\n\t}\n\tdefer rows.Close()\n")
+		fmt.Fprintf(w, "\tvar vs []*%s\n", structName)
+		fmt.Fprintf(w, "\tfor rows.Next() {\n\t\tv, err := %s(rows)\n\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n\t\tvs = append(vs, v)\n\t}\n", scanName)
+		fmt.Fprint(w, "\treturn vs, rows.Err()\n}\n")
+	}
+}
+
+func (f *GoRepositoryFormatter) fprintGet(w io.Writer, t *Table, method string, cs []Column) {
+	structName := goStructName(t)
+	fmt.Fprintf(w, "func (r *%sRepository) %s(ctx context.Context, %s) (*%s, error) {\n", structName, method, f.params(cs), structName)
+	query := fmt.Sprintf("SELECT \"+%sColumns+\" FROM `%s` WHERE %s", structName, t.Name, whereClause(cs))
+	fmt.Fprintf(w, "\treturn scan%s(r.db.QueryRowContext(ctx, \"%s\"%s))\n}\n", strcase.ToCamel(structName), query, paramArgs(cs))
+}
+
+func columnNames(cs []Column) []string {
+	ns := make([]string, 0, len(cs))
+	for _, c := range cs {
+		ns = append(ns, c.Name)
+	}
+	return ns
+}
+
+func quoteColumns(ns []string) string {
+	return "`" + strings.Join(ns, "`, `") + "`"
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func whereClause(cs []Column) string {
+	es := make([]string, 0, len(cs))
+	for _, c := range cs {
+		es = append(es, "`"+c.Name+"` = ?")
+	}
+	return strings.Join(es, " AND ")
+}
+
+func fieldArgs(prefix string, cs []Column) string {
+	var s string
+	for _, c := range cs {
+		s += ", " + prefix + goFieldName(c)
+	}
+	return s
+}
+
+func paramName(c Column) string {
+	n := gocase.To(strcase.ToLowerCamel(c.Name))
+	if token.IsKeyword(n) || n == "ctx" || n == "r" {
+		n += "_"
+	}
+	return n
+}
+
+func (f *GoRepositoryFormatter) params(cs []Column) string {
+	ps := make([]string, 0, len(cs))
+	for _, c := range cs {
+		ps = append(ps, paramName(c)+" "+strings.TrimPrefix(f.convType(c.Type, convGoType), "*"))
+	}
+	return strings.Join(ps, ", ")
+}
+
+// paramColumns returns the columns passed as the parameters of the methods, that is, the columns of the keys.
+func paramColumns(t *Table) []Column {
+	var cs []Column
+	for _, c := range t.Columns {
+		if c.PKey || c.Unique {
+			cs = append(cs, c)
+		}
+	}
+	for _, ks := range [][]Key{t.UniqueKeys, t.IndexKeys} {
+		for _, k := range ks {
+			cs = append(cs, keyColumns(t, k)...)
+		}
+	}
+	return cs
+}
+
+func paramArgs(cs []Column) string {
+	var s string
+	for _, c := range cs {
+		s += ", " + paramName(c)
+	}
+	return s
+}
+
+func methodSuffix(cs []Column) string {
+	var s string
+	for _, c := range cs {
+		s += goFieldName(c)
+	}
+	return s
+}
+
+// keyColumns returns the columns of the key.
+// If the table doesn't have some columns of the key, it returns nil.
+func keyColumns(t *Table, k Key) []Column {
+	cs := make([]Column, 0, len(k.Columns))
+	for _, n := range k.Columns {
+		var found bool
+		for _, c := range t.Columns {
+			if c.Name == n {
+				cs = append(cs, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return cs
+}
@@ -0,0 +1,279 @@
+package tdconv_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+var mustGoRepositoryFormatter = func(options ...tdconv.GoRepositoryFormatOption) *tdconv.GoRepositoryFormatter {
+	f, err := tdconv.NewGoRepositoryFormatter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// sqliteTestTableSet is the table set of the generated code in internal/sqlitetest.
+var sqliteTestTableSet = &tdconv.TableSet{
+	Name: "sqlitetest",
+	Tables: []*tdconv.Table{
+		{
+			Name: "users",
+			Columns: []tdconv.Column{
+				{Name: "id", Type: "INT", PKey: true, NotNull: true, Option: "AUTO_INCREMENT"},
+				{Name: "email", Type: "VARCHAR(255)", NotNull: true, Unique: true},
+				{Name: "name", Type: "VARCHAR(32)", Index: true},
+				{Name: "score", Type: "DOUBLE"},
+				{Name: "active", Type: "BOOLEAN"},
+				{Name: "created_at", Type: "TIMESTAMP NULL", Index: true, IsCommon: true},
+			},
+			PKeyColumns: []string{"id"},
+			IndexKeys:   []tdconv.Key{{Name: "name_key", Columns: []string{"name"}}, {Name: "created_at_key", Columns: []string{"created_at"}}},
+		},
+		{
+			Name: "user_roles",
+			Columns: []tdconv.Column{
+				{Name: "user_id", Type: "INT", PKey: true, NotNull: true},
+				{Name: "type", Type: "VARCHAR(32)", PKey: true, NotNull: true},
+				{Name: "org", Type: "VARCHAR(32)"},
+				{Name: "note", Type: "TEXT"},
+			},
+			PKeyColumns: []string{"user_id", "type"},
+			UniqueKeys:  []tdconv.Key{{Name: "org_note_key", Columns: []string{"org", "note"}}},
+			IndexKeys:   []tdconv.Key{{Name: "org_key", Columns: []string{"org"}}},
+		},
+	},
+}
+
+func sqliteTestFiles() (models, repository string) {
+	header := func(imports ...string) func(io.Writer, *tdconv.TableSet) {
+		return func(w io.Writer, _ *tdconv.TableSet) {
+			fmt.Fprint(w, "// This file generated by tdconv. DO NOT EDIT.\n"+
+				"// See more details at https://github.com/takuoki/tdconv.\n"+
+				"package sqlitetest\n\nimport (\n")
+			for _, i := range imports {
+				fmt.Fprintf(w, "\t%q\n", i)
+			}
+			fmt.Fprint(w, ")\n\n")
+		}
+	}
+	var mb, rb bytes.Buffer
+	gf := mustGoFormatter(tdconv.GoHeader(header("time")))
+	rf := mustGoRepositoryFormatter(tdconv.GoRepositoryHeader(header("context", "database/sql", "time")))
+	for _, b := range []struct {
+		f tdconv.Formatter
+		w *bytes.Buffer
+	}{{gf, &mb}, {rf, &rb}} {
+		b.f.Header(b.w, sqliteTestTableSet)
+		for i, t := range sqliteTestTableSet.Tables {
+			if i > 0 {
+				fmt.Fprintln(b.w)
+			}
+			b.f.Fprint(b.w, t)
+		}
+		b.f.Footer(b.w, sqliteTestTableSet)
+	}
+	return mb.String(), rb.String()
+}
+
+func TestNewGoRepositoryFormatter(t *testing.T) {
+
+	errOptionFunc := func(*tdconv.GoRepositoryFormatter) error {
+		return errors.New("error")
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.GoRepositoryFormatOption
+		errMsg   string
+	}{
+		{
+			caseName: "success: default",
+		},
+		{
+			caseName: "success: set all options",
+			opts: []tdconv.GoRepositoryFormatOption{
+				tdconv.GoRepositoryHeader(nil),
+				tdconv.GoRepositoryTableHeader(nil),
+				tdconv.GoRepositoryTableFooter(nil),
+				tdconv.GoRepositoryFooter(nil),
+				tdconv.GoRepositoryTypeMap(map[string]string{"json": "string"}),
+			},
+		},
+		{
+			caseName: "failure: option error",
+			opts:     []tdconv.GoRepositoryFormatOption{errOptionFunc},
+			errMsg:   "error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.NewGoRepositoryFormatter(c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if endIndex := strings.Index(err.Error(), ":"); endIndex < 0 {
+					if err.Error() != c.errMsg {
+						t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+						return
+					}
+				} else if err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestGoRepositoryFormatter_Extension(t *testing.T) {
+	var f *tdconv.GoRepositoryFormatter
+	if f.Extension() != "go" {
+		t.Errorf("value doesn't match (expected=go, actual=%s)", f.Extension())
+	}
+}
+
+func TestGoRepositoryFormatter_Header(t *testing.T) {
+
+	header := "// This file generated by tdconv. DO NOT EDIT.\n" +
+		"// See more details at https://github.com/takuoki/tdconv.\n" +
+		"package main\n\nimport (\n\t\"context\"\n\t\"database/sql\"\n"
+
+	cases := []struct {
+		caseName string
+		multi    bool
+		expected map[string]string
+	}{
+		{
+			caseName: "time parameter",
+			expected: map[string]string{"out/sqlitetest.go": header + "\t\"time\"\n)\n\n"},
+		},
+		{
+			caseName: "multi",
+			multi:    true,
+			expected: map[string]string{
+				"out/users.go":      header + "\t\"time\"\n)\n\n",
+				"out/user_roles.go": header + ")\n\n",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			fs := tdconv.NewMemoryFileSystem()
+			if err := tdconv.Output(mustGoRepositoryFormatter(), sqliteTestTableSet, c.multi, "out", tdconv.OutputFileSystem(fs)); err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			files := fs.Files()
+			if len(files) != len(c.expected) {
+				t.Fatalf("number of files doesn't match (expected=%d, actual=%d)", len(c.expected), len(files))
+			}
+			for name, expected := range c.expected {
+				if !strings.HasPrefix(string(files[name]), expected) {
+					t.Errorf("header doesn't match (file=%s, expected=%s, actual=%s)", name, expected, string(files[name]))
+				}
+			}
+		})
+	}
+}
+
+func TestGoRepositoryFormatter_Fprint(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.GoRepositoryFormatter
+		t        *tdconv.Table
+		expected string
+	}{
+		{
+			caseName: "nil formatter",
+			f:        nil,
+			t:        sqliteTestTableSet.Tables[0],
+			expected: "",
+		},
+		{
+			caseName: "table is nil",
+			f:        mustGoRepositoryFormatter(),
+			t:        nil,
+			expected: "",
+		},
+		{
+			caseName: "no primary key",
+			f:        mustGoRepositoryFormatter(),
+			t: &tdconv.Table{
+				Name: "logs",
+				Columns: []tdconv.Column{
+					{Name: "message", Type: "TEXT"},
+				},
+			},
+			expected: "type logsRepository struct {\n" +
+				"\tdb *sql.DB\n" +
+				"}\n\n" +
+				"func newLogsRepository(db *sql.DB) *logsRepository {\n" +
+				"\treturn &logsRepository{db: db}\n" +
+				"}\n\n" +
+				"const logsColumns = \"`message`\"\n\n" +
+				"func scanLogs(s interface{ Scan(dest ...interface{}) error }) (*logs, error) {\n" +
+				"\tv := &logs{}\n" +
+				"\tif err := s.Scan(&v.Message); err != nil {\n" +
+				"\t\treturn nil, err\n" +
+				"\t}\n" +
+				"\treturn v, nil\n" +
+				"}\n\n" +
+				"// Insert inserts the record into logs.\n" +
+				"func (r *logsRepository) Insert(ctx context.Context, v *logs) error {\n" +
+				"\t_, err := r.db.ExecContext(ctx, \"INSERT INTO `logs` (`message`) VALUES (?)\", v.Message)\n" +
+				"\treturn err\n" +
+				"}\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			c.f.Fprint(b, c.t)
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+		})
+	}
+}
+
+// TestGoRepositoryFormatter_Generated checks that the generated code in internal/sqlitetest is up to date.
+// The generated code itself is tested against SQLite in that package.
+func TestGoRepositoryFormatter_Generated(t *testing.T) {
+
+	models, repository := sqliteTestFiles()
+
+	for filename, expected := range map[string]string{
+		"internal/sqlitetest/models.go":     models,
+		"internal/sqlitetest/repository.go": repository,
+	} {
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Errorf("unable to read file (filename=%s): %v", filename, err)
+			continue
+		}
+		if string(b) != expected {
+			t.Errorf("generated code is out of date (filename=%s, expected=%s)", filename, expected)
+		}
+	}
+}
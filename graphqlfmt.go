@@ -0,0 +1,217 @@
+package tdconv
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// GraphQLFormatter is a formatter to output the table definision as GraphQL SDL type.
+type GraphQLFormatter struct {
+	formatter
+}
+
+// NewGraphQLFormatter creates a new GraphQLFormatter.
+// You can change some parameters of the GraphQLFormatter with GraphQLFormatOption.
+func NewGraphQLFormatter(options ...GraphQLFormatOption) (*GraphQLFormatter, error) {
+
+	f := GraphQLFormatter{}
+	f.setHeader(func(w io.Writer, ts *TableSet) {
+		f.fprintHeader(w, ts, true)
+	})
+	// the custom scalars are declared only in the first file with multi flag,
+	// because the files are merged into one schema and a type must not be declared twice
+	f.setMultiHeader(func(w io.Writer, ts *TableSet, i int) {
+		f.fprintHeader(w, ts, i == 0)
+	})
+	for _, opt := range options {
+		err := opt(&f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+// GraphQLFormatOption changes some parameters of the GraphQLFormatter.
+type GraphQLFormatOption func(*GraphQLFormatter) error
+
+// GraphQLHeader changes the header.
+func GraphQLHeader(fc func(w io.Writer, ts *TableSet)) GraphQLFormatOption {
+	return func(f *GraphQLFormatter) error {
+		f.setHeader(fc)
+		return nil
+	}
+}
+
+// GraphQLTableHeader changes the header of each table.
+func GraphQLTableHeader(fc func(w io.Writer, t *Table)) GraphQLFormatOption {
+	return func(f *GraphQLFormatter) error {
+		f.setTableHeader(fc)
+		return nil
+	}
+}
+
+// GraphQLTableFooter changes the footer of each table.
+func GraphQLTableFooter(fc func(w io.Writer, t *Table)) GraphQLFormatOption {
+	return func(f *GraphQLFormatter) error {
+		f.setTableFooter(fc)
+		return nil
+	}
+}
+
+// GraphQLFooter changes the footer.
+func GraphQLFooter(fc func(w io.Writer, ts *TableSet)) GraphQLFormatOption {
+	return func(f *GraphQLFormatter) error {
+		f.setFooter(fc)
+		return nil
+	}
+}
+
+// GraphQLTypeMap overrides the type conversion to GraphQL type.
+// The key is the base SQL type name, like `VARCHAR`, and it's case-insensitive.
+func GraphQLTypeMap(m map[string]string) GraphQLFormatOption {
+	return func(f *GraphQLFormatter) error {
+		f.setTypeMap(m)
+		return nil
+	}
+}
+
+// Extension returns the extension of GraphQL file.
+func (f *GraphQLFormatter) Extension() string {
+	return "graphql"
+}
+
+func (f *GraphQLFormatter) fprintHeader(w io.Writer, ts *TableSet, scalars bool) {
+	fmt.Fprint(w,
+		"# This file generated by tdconv. DO NOT EDIT.\n"+
+			"# See more details at https://github.com/takuoki/tdconv.\n\n")
+	if !scalars {
+		return
+	}
+	for _, s := range graphQLCustomScalars {
+		if f.graphQLUses(ts, s) {
+			fmt.Fprintf(w, "scalar %s\n\n", s)
+		}
+	}
+}
+
+// Fprint outputs the table definision as GraphQL SDL type.
+// The primary key column becomes `ID!`, and the ENUM column becomes an enum type unless the type mapping has ENUM.
+func (f *GraphQLFormatter) Fprint(w io.Writer, t *Table) {
+
+	if f == nil || t == nil {
+		return
+	}
+
+	typeName := strcase.ToCamel(t.Name)
+	type enum struct {
+		name   string
+		values []string
+	}
+	var enums []enum
+
+	fmt.Fprintf(w, "type %s {\n", typeName)
+	for _, c := range t.Columns {
+		var typ string
+		if c.PKey && len(t.PKeyColumns) == 1 {
+			typ = "ID"
+		} else if st := parseSQLType(c.Type); st.name == "ENUM" && !f.isMapped(c.Type) && graphQLEnumValues(st.args) != nil {
+			typ = typeName + strcase.ToCamel(c.Name)
+			enums = append(enums, enum{name: typ, values: graphQLEnumValues(st.args)})
+		} else {
+			typ = f.convType(c.Type, convGraphQLType)
+			if typ == "UNKNOWN" {
+				ReportError(w, typeError("GraphQL", t, c))
+			}
+		}
+		if c.NotNull || c.PKey {
+			typ += "!"
+		}
+		if c.Comment != "" {
+			fmt.Fprintf(w, "  %s\n", graphQLDescription(c.Comment, "  "))
+		}
+		fmt.Fprintf(w, "  %s: %s\n", strcase.ToLowerCamel(c.Name), typ)
+	}
+	fmt.Fprintln(w, "}")
+
+	for _, e := range enums {
+		fmt.Fprintf(w, "\nenum %s {\n", e.name)
+		for _, v := range e.values {
+			fmt.Fprintf(w, "  %s\n", v)
+		}
+		fmt.Fprintln(w, "}")
+	}
+}
+
+var graphQLCustomScalars = []string{"BigInt", "DateTime"}
+
+func (f *GraphQLFormatter) graphQLUses(ts *TableSet, scalar string) bool {
+	if ts == nil {
+		return false
+	}
+	for _, t := range ts.Tables {
+		for _, c := range t.Columns {
+			if f.convType(c.Type, convGraphQLType) == scalar && !(c.PKey && len(t.PKeyColumns) == 1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func convGraphQLType(t string) string {
+	var r string
+	switch parseSQLType(t).name {
+	case "INT", "TINYINT", "SMALLINT", "MEDIUMINT":
+		r = "Int"
+	case "BIGINT":
+		r = "BigInt"
+	case "DOUBLE", "FLOAT", "DECIMAL":
+		r = "Float"
+	case "CHAR", "VARCHAR", "TEXT", "ENUM":
+		r = "String"
+	case "BOOLEAN":
+		r = "Boolean"
+	case "TIMESTAMP", "DATETIME", "DATE", "TIME":
+		r = "DateTime"
+	default:
+		r = "UNKNOWN"
+	}
+	return r
+}
+
+var graphQLNameRegexp = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// graphQLEnumValues converts the ENUM values to GraphQL enum values.
+// If some values cannot be converted, or some converted values are duplicated, it returns nil.
+func graphQLEnumValues(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	vs := make([]string, 0, len(args))
+	seen := map[string]struct{}{}
+	for _, a := range args {
+		v := strcase.ToScreamingSnake(a)
+		if !graphQLNameRegexp.MatchString(v) || v == "TRUE" || v == "FALSE" || v == "NULL" {
+			return nil
+		}
+		if _, ok := seen[v]; ok {
+			return nil
+		}
+		seen[v] = struct{}{}
+		vs = append(vs, v)
+	}
+	return vs
+}
+
+func graphQLDescription(s, indent string) string {
+	if strings.Contains(s, "\n") {
+		s = strings.Replace(s, `"""`, `\"""`, -1)
+		return `"""` + "\n" + indent + strings.Replace(s, "\n", "\n"+indent, -1) + "\n" + indent + `"""`
+	}
+	return `"` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
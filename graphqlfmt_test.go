@@ -0,0 +1,264 @@
+package tdconv_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+var mustGraphQLFormatter = func(options ...tdconv.GraphQLFormatOption) *tdconv.GraphQLFormatter {
+	f, err := tdconv.NewGraphQLFormatter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func TestNewGraphQLFormatter(t *testing.T) {
+
+	errOptionFunc := func(*tdconv.GraphQLFormatter) error {
+		return errors.New("error")
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.GraphQLFormatOption
+		errMsg   string
+	}{
+		{
+			caseName: "success: default",
+		},
+		{
+			caseName: "success: set all options",
+			opts: []tdconv.GraphQLFormatOption{
+				tdconv.GraphQLHeader(nil),
+				tdconv.GraphQLTableHeader(nil),
+				tdconv.GraphQLTableFooter(nil),
+				tdconv.GraphQLFooter(nil),
+				tdconv.GraphQLTypeMap(map[string]string{"json": "string"}),
+			},
+		},
+		{
+			caseName: "failure: option error",
+			opts:     []tdconv.GraphQLFormatOption{errOptionFunc},
+			errMsg:   "error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.NewGraphQLFormatter(c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if endIndex := strings.Index(err.Error(), ":"); endIndex < 0 {
+					if err.Error() != c.errMsg {
+						t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+						return
+					}
+				} else if err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestGraphQLFormatter_Extension(t *testing.T) {
+	var f *tdconv.GraphQLFormatter
+	if f.Extension() != "graphql" {
+		t.Errorf("value doesn't match (expected=graphql, actual=%s)", f.Extension())
+	}
+}
+
+func TestGraphQLFormatter_Header(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		ts       *tdconv.TableSet
+		expected string
+	}{
+		{
+			caseName: "no custom scalars",
+			ts: &tdconv.TableSet{
+				Name: "sample_table_set",
+				Tables: []*tdconv.Table{
+					{Name: "sample_table", Columns: []tdconv.Column{{Name: "id", Type: "BIGINT", PKey: true}}, PKeyColumns: []string{"id"}},
+				},
+			},
+			expected: "# This file generated by tdconv. DO NOT EDIT.\n" +
+				"# See more details at https://github.com/takuoki/tdconv.\n\n",
+		},
+		{
+			caseName: "custom scalars",
+			ts: &tdconv.TableSet{
+				Name: "sample_table_set",
+				Tables: []*tdconv.Table{
+					{Name: "sample_table", Columns: []tdconv.Column{{Name: "foo", Type: "BIGINT"}, {Name: "bar", Type: "TIMESTAMP"}}},
+				},
+			},
+			expected: "# This file generated by tdconv. DO NOT EDIT.\n" +
+				"# See more details at https://github.com/takuoki/tdconv.\n\n" +
+				"scalar BigInt\n\n" +
+				"scalar DateTime\n\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			mustGraphQLFormatter().Header(b, c.ts)
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestGraphQLFormatter_Fprint(t *testing.T) {
+
+	table := &tdconv.Table{
+		Name: "sample_table",
+		Columns: []tdconv.Column{
+			{Name: "id", Type: "INT UNSIGNED", PKey: true, NotNull: true, Unique: false, Index: false, Option: "AUTO_INCREMENT", Comment: "this is id!", IsCommon: false},
+			{Name: "foo", Type: "VARCHAR(32)", PKey: false, NotNull: true, Unique: true, Index: false, Option: "", Comment: "say \"foo\"", IsCommon: false},
+			{Name: "bar_status", Type: "ENUM('active','in active')", PKey: false, NotNull: false, Unique: false, Index: true, Option: "", Comment: "", IsCommon: false},
+			{Name: "baz", Type: "ENUM('1','2')", PKey: false, NotNull: true, Unique: false, Index: false, Option: "", Comment: "", IsCommon: false},
+			{Name: "progress", Type: "ENUM('in-progress','in_progress')", PKey: false, NotNull: true, Unique: false, Index: false, Option: "", Comment: "", IsCommon: false},
+			{Name: "qux", Type: "BOOLEAN", PKey: false, NotNull: true, Unique: false, Index: false, Option: "", Comment: "multi\nline", IsCommon: false},
+			{Name: "created_at", Type: "TIMESTAMP NULL", PKey: false, NotNull: false, Unique: false, Index: false, Option: "DEFAULT CURRENT_TIMESTAMP", Comment: "", IsCommon: true},
+		},
+		PKeyColumns: []string{"id"},
+		IndexKeys:   []tdconv.Key{{Name: "bar_key", Columns: []string{"bar"}}},
+	}
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.GraphQLFormatter
+		t        *tdconv.Table
+		expected string
+	}{
+		{
+			caseName: "nil formatter",
+			f:        nil,
+			t:        table,
+			expected: "",
+		},
+		{
+			caseName: "table is nil",
+			f:        mustGraphQLFormatter(),
+			t:        nil,
+			expected: "",
+		},
+		{
+			caseName: "standard output",
+			f:        mustGraphQLFormatter(),
+			t:        table,
+			expected: "type SampleTable {\n" +
+				"  \"this is id!\"\n" +
+				"  id: ID!\n" +
+				"  \"say \\\"foo\\\"\"\n" +
+				"  foo: String!\n" +
+				"  barStatus: SampleTableBarStatus\n" +
+				"  baz: String!\n" +
+				"  progress: String!\n" +
+				"  \"\"\"\n" +
+				"  multi\n" +
+				"  line\n" +
+				"  \"\"\"\n" +
+				"  qux: Boolean!\n" +
+				"  createdAt: DateTime\n" +
+				"}\n" +
+				"\n" +
+				"enum SampleTableBarStatus {\n" +
+				"  ACTIVE\n" +
+				"  IN_ACTIVE\n" +
+				"}\n",
+		},
+		{
+			caseName: "composite primary key",
+			f:        mustGraphQLFormatter(),
+			t: &tdconv.Table{
+				Name: "sample_table",
+				Columns: []tdconv.Column{
+					{Name: "id", Type: "INT", PKey: true},
+					{Name: "sub_id", Type: "BIGINT", PKey: true},
+					{Name: "data", Type: "LONGBLOB"},
+				},
+				PKeyColumns: []string{"id", "sub_id"},
+			},
+			expected: "type SampleTable {\n" +
+				"  id: Int!\n" +
+				"  subId: BigInt!\n" +
+				"  data: UNKNOWN\n" +
+				"}\n",
+		},
+		{
+			caseName: "type map of enum",
+			f:        mustGraphQLFormatter(tdconv.GraphQLTypeMap(map[string]string{"enum": "String"})),
+			t: &tdconv.Table{
+				Name: "sample_table",
+				Columns: []tdconv.Column{
+					{Name: "status", Type: "ENUM('active','in_active')", NotNull: true},
+				},
+			},
+			expected: "type SampleTable {\n" +
+				"  status: String!\n" +
+				"}\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			c.f.Fprint(b, c.t)
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestGraphQLFormatter_multi(t *testing.T) {
+
+	ts := &tdconv.TableSet{
+		Name: "sample_table_set",
+		Tables: []*tdconv.Table{
+			{Name: "users", Columns: []tdconv.Column{{Name: "created_at", Type: "TIMESTAMP"}}},
+			{Name: "posts", Columns: []tdconv.Column{{Name: "created_at", Type: "TIMESTAMP"}}},
+		},
+	}
+
+	fs := tdconv.NewMemoryFileSystem()
+	if err := tdconv.Output(mustGraphQLFormatter(), ts, true, "out", tdconv.OutputFileSystem(fs)); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+
+	// the custom scalars are declared only in the first file
+	expected := map[string]bool{"out/users.graphql": true, "out/posts.graphql": false}
+	for name, declared := range expected {
+		b, ok := fs.Files()[name]
+		if !ok {
+			t.Fatalf("file must be output (%s)", name)
+		}
+		if actual := strings.Contains(string(b), "scalar DateTime\n"); actual != declared {
+			t.Errorf("declaration of the scalar doesn't match (file=%s, expected=%t, actual=%t)", name, declared, actual)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package diff provides the unified diff of texts.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of the context lines around the changes.
+const context = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns the unified diff from a to b.
+// If a and b are the same, it returns an empty string.
+func Unified(nameA, nameB string, a, b []byte) string {
+
+	if string(a) == string(b) {
+		return ""
+	}
+
+	ops := lineOps(splitLines(string(a)), splitLines(string(b)))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", nameA, nameB)
+
+	// line numbers (0-origin) of a and b at the beginning of each op
+	ia, ib := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, o := range ops {
+		ia[i+1], ib[i+1] = ia[i], ib[i]
+		if o.kind != opInsert {
+			ia[i+1]++
+		}
+		if o.kind != opDelete {
+			ib[i+1]++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+
+		// extend the hunk while the changes are close enough
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			n := 0
+			for end+n < len(ops) && ops[end+n].kind == opEqual {
+				n++
+			}
+			if end+n == len(ops) || n > context*2 {
+				end += min(n, context)
+				break
+			}
+			end += n
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(ia[start], ia[end]-ia[start]), hunkRange(ib[start], ib[end]-ib[start]))
+		for _, o := range ops[start:end] {
+			switch o.kind {
+			case opEqual:
+				sb.WriteString(" ")
+			case opDelete:
+				sb.WriteString("-")
+			case opInsert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(strings.TrimSuffix(o.line, "\n"))
+			sb.WriteString("\n")
+			if !strings.HasSuffix(o.line, "\n") {
+				sb.WriteString("\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineOps returns the edit operations from a to b based on the longest common subsequence.
+func lineOps(a, b []string) []op {
+
+	// skip the common prefix and suffix
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	// lcs[i][j] is the length of LCS of ma[i:] and mb[j:]
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	for _, l := range a[:pre] {
+		ops = append(ops, op{opEqual, l})
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, op{opEqual, ma[i]})
+			i++
+			j++
+		case j == len(mb) || i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{opDelete, ma[i]})
+			i++
+		default:
+			ops = append(ops, op{opInsert, mb[j]})
+			j++
+		}
+	}
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, op{opEqual, l})
+	}
+
+	return ops
+}
+
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
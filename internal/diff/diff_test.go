@@ -0,0 +1,74 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/takuoki/tdconv/internal/diff"
+)
+
+func TestUnified(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		a, b     string
+		expected string
+	}{
+		{
+			caseName: "same",
+			a:        "a\nb\n",
+			b:        "a\nb\n",
+			expected: "",
+		},
+		{
+			caseName: "change in the middle",
+			a:        "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			b:        "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			expected: "--- a\n+++ b\n" +
+				"@@ -2,7 +2,7 @@\n" +
+				" 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			caseName: "separated hunks",
+			a:        "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:        "one\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			expected: "--- a\n+++ b\n" +
+				"@@ -1,4 +1,4 @@\n" +
+				"-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -7,4 +7,3 @@\n" +
+				" 7\n 8\n 9\n-10\n",
+		},
+		{
+			caseName: "joined hunks",
+			a:        "1\n2\n3\n4\n5\n",
+			b:        "one\n2\n3\n4\nfive\n",
+			expected: "--- a\n+++ b\n" +
+				"@@ -1,5 +1,5 @@\n" +
+				"-1\n+one\n 2\n 3\n 4\n-5\n+five\n",
+		},
+		{
+			caseName: "new file",
+			a:        "",
+			b:        "a\nb\n",
+			expected: "--- a\n+++ b\n" +
+				"@@ -0,0 +1,2 @@\n" +
+				"+a\n+b\n",
+		},
+		{
+			caseName: "no newline at end of file",
+			a:        "a\nb",
+			b:        "a\nb\n",
+			expected: "--- a\n+++ b\n" +
+				"@@ -1,2 +1,2 @@\n" +
+				" a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			actual := diff.Unified("a", "b", []byte(c.a), []byte(c.b))
+			if actual != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, actual)
+			}
+		})
+	}
+}
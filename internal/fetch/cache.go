@@ -0,0 +1,165 @@
+package fetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/takuoki/tdconv"
+)
+
+// CacheVersion is the version of the cache format.
+const CacheVersion = 1
+
+// Spreadsheet is the values of the sheets in a spreadsheet.
+// It's saved in the cache as JSON like below.
+//
+//	{
+//	  "version": 1,
+//	  "spreadsheet_id": "1B8iFPPfyx81Q_0YDzGMN-a6zIw82B0x583A1eqcg_xA",
+//	  "revision": "123",
+//	  "title": "tdconverter-sample",
+//	  "sheet_names": ["users", "posts"],
+//	  "sheets": {
+//	    "users": [["", "table name", "users"], ...],
+//	    "posts": [...]
+//	  }
+//	}
+//
+// "sheet_names" is all sheet names in the spreadsheet order,
+// and "sheets" has the raw values of the fetched sheets only.
+type Spreadsheet struct {
+	Version    int                           `json:"version"`
+	ID         string                        `json:"spreadsheet_id"`
+	Revision   string                        `json:"revision"`
+	Title      string                        `json:"title"`
+	SheetNames []string                      `json:"sheet_names"`
+	Sheets     map[string]tdconv.SheetValues `json:"sheets"`
+}
+
+// SelectSheets returns the names of the sheets to fetch. If names is empty, all sheets in the spreadsheet order.
+// The sheets which the filter (optional) reports false are not selected.
+func SelectSheets(all, names []string, filter func(name string) bool) []string {
+	if len(names) == 0 {
+		names = all
+	}
+	selected := make([]string, 0, len(names))
+	for _, n := range names {
+		if filter == nil || filter(n) {
+			selected = append(selected, n)
+		}
+	}
+	return selected
+}
+
+// has reports whether the spreadsheet has all values of the sheets.
+func (s *Spreadsheet) has(names []string) bool {
+	if s == nil {
+		return false
+	}
+	for _, n := range names {
+		if _, ok := s.Sheets[n]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Values returns the values of the sheets in the same order as the names. If names is empty, all sheets.
+func (s *Spreadsheet) Values(names []string) ([]string, []tdconv.SheetValues, error) {
+	if len(names) == 0 {
+		names = s.SheetNames
+	}
+	values := make([]tdconv.SheetValues, len(names))
+	for i, n := range names {
+		v, ok := s.Sheets[n]
+		if !ok {
+			return nil, nil, fmt.Errorf("No values of the sheet (id=%s, sheetname=%s)", s.ID, n)
+		}
+		values[i] = v
+	}
+	return names, values, nil
+}
+
+// Cache is the on-disk cache of the spreadsheets.
+// Each spreadsheet is saved as `<dir>/<spreadsheet id>.json`.
+type Cache struct {
+	dir string
+	fs  tdconv.ReadFileSystem
+}
+
+// NewCache creates a new Cache in the directory.
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir, fs: tdconv.OSFileSystem{}}
+}
+
+func (c *Cache) file(id string) string {
+	return filepath.Join(c.dir, id+".json")
+}
+
+// Load returns the cached spreadsheet, or nil if not cached.
+func (c *Cache) Load(id string) (*Spreadsheet, error) {
+
+	b, err := c.fs.ReadFile(c.file(id))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read cache file: %v", err)
+	}
+
+	s := &Spreadsheet{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("Unable to unmarshal cache file (%s): %v", c.file(id), err)
+	}
+	if s.Version != CacheVersion {
+		// the cache of the other version is ignored, and overwritten on the next save
+		return nil, nil
+	}
+
+	return s, nil
+}
+
+// Save saves the spreadsheet.
+func (c *Cache) Save(s *Spreadsheet) error {
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Unable to marshal cache: %v", err)
+	}
+
+	w, err := c.fs.Create(c.file(s.ID))
+	if err != nil {
+		return fmt.Errorf("Unable to create cache file: %v", err)
+	}
+	if _, err := w.Write(append(b, '\n')); err != nil {
+		if a, ok := w.(tdconv.Aborter); ok {
+			a.Abort()
+		}
+		return fmt.Errorf("Unable to write cache file: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("Unable to write cache file: %v", err)
+	}
+
+	return nil
+}
+
+// Fetch returns the cached spreadsheet with the values of the sheets selected by SelectSheets without any request.
+// If the cache doesn't have them, it fails.
+func (c *Cache) Fetch(id string, names []string, filter func(name string) bool) (*Spreadsheet, error) {
+
+	s, err := c.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, fmt.Errorf("No cache of the spreadsheet (id=%s)", id)
+	}
+	if !s.has(SelectSheets(s.SheetNames, names, filter)) {
+		return nil, fmt.Errorf("No cache of the sheets (id=%s)", id)
+	}
+
+	return s, nil
+}
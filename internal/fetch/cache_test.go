@@ -0,0 +1,92 @@
+package fetch_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+	"github.com/takuoki/tdconv/internal/fetch"
+)
+
+func TestCache_Fetch(t *testing.T) {
+
+	dir := t.TempDir()
+	cache := fetch.NewCache(dir)
+	s := &fetch.Spreadsheet{
+		Version: fetch.CacheVersion, ID: "id", Revision: "1", Title: "sample", SheetNames: []string{"users", "posts"},
+		Sheets: map[string]tdconv.SheetValues{"users": {{"users"}}},
+	}
+	if err := cache.Save(s); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "old.json"), []byte(`{"version":0,"spreadsheet_id":"old"}`), 0644); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+
+	cases := []struct {
+		caseName string
+		id       string
+		names    []string
+		filter   func(name string) bool
+		expected []tdconv.SheetValues
+		errMsg   string
+	}{
+		{
+			caseName: "success",
+			id:       "id",
+			names:    []string{"users"},
+			expected: []tdconv.SheetValues{{{"users"}}},
+		},
+		{
+			caseName: "success: filter",
+			id:       "id",
+			filter:   func(name string) bool { return name != "posts" },
+			expected: []tdconv.SheetValues{{{"users"}}},
+		},
+		{
+			caseName: "failure: not cached sheet",
+			id:       "id",
+			errMsg:   "No cache of the sheets (id=id)",
+		},
+		{
+			caseName: "failure: not cached spreadsheet",
+			id:       "foo",
+			errMsg:   "No cache of the spreadsheet (id=foo)",
+		},
+		{
+			caseName: "failure: other version",
+			id:       "old",
+			errMsg:   "No cache of the spreadsheet (id=old)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			s, err := cache.Fetch(c.id, c.names, c.filter)
+
+			if c.errMsg != "" {
+				if err == nil {
+					t.Fatalf("error must occur")
+				}
+				if err.Error() != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			_, values, err := s.Values(fetch.SelectSheets(s.SheetNames, c.names, c.filter))
+			if err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			if !reflect.DeepEqual(values, c.expected) {
+				t.Errorf("value doesn't match (expected=%v, actual=%v)", c.expected, values)
+			}
+		})
+	}
+}
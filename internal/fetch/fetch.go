@@ -0,0 +1,407 @@
+// Package fetch fetches the sheet values from Google Sheets API
+// with batch requests, bounded concurrency and retries.
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/takuoki/tdconv"
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	sheets "google.golang.org/api/sheets/v4"
+)
+
+// Client is a client of Google Sheets API. Create it using New function.
+type Client struct {
+	srv         *sheets.Service
+	drive       *drive.Service
+	batchSize   int
+	concurrency int
+	retries     int
+	backoff     time.Duration
+	bypass      func(id string, err error)
+}
+
+// New creates a new Client.
+// The client options are passed to Google Sheets API (e.g. option.WithHTTPClient).
+func New(ctx context.Context, clientOptions []option.ClientOption, options ...Option) (*Client, error) {
+
+	srv, err := sheets.NewService(ctx, clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create Sheets service: %v", err)
+	}
+
+	drv, err := drive.NewService(ctx, clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create Drive service: %v", err)
+	}
+
+	c := Client{
+		srv:         srv,
+		drive:       drv,
+		batchSize:   20,
+		concurrency: 4,
+		retries:     5,
+		backoff:     time.Second,
+	}
+	for _, opt := range options {
+		err := opt(&c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+// Option changes some parameters of the Client.
+type Option func(*Client) error
+
+// BatchSize changes the number of the sheets fetched with one request. The default is 20.
+func BatchSize(n int) Option {
+	return func(c *Client) error {
+		if n <= 0 {
+			return errors.New("Batch size must be greater than zero")
+		}
+		c.batchSize = n
+		return nil
+	}
+}
+
+// Concurrency changes the number of the concurrent requests. The default is 4.
+func Concurrency(n int) Option {
+	return func(c *Client) error {
+		if n <= 0 {
+			return errors.New("Concurrency must be greater than zero")
+		}
+		c.concurrency = n
+		return nil
+	}
+}
+
+// Retry changes the maximum number of the retries and the initial backoff, which is doubled on each retry.
+// The default is 5 retries from 1 second.
+func Retry(n int, backoff time.Duration) Option {
+	return func(c *Client) error {
+		if n < 0 || backoff < 0 {
+			return errors.New("Retry count and backoff must not be negative")
+		}
+		c.retries = n
+		c.backoff = backoff
+		return nil
+	}
+}
+
+// CacheBypassHandler sets the function called with the reason when the cache is not used by Fetch,
+// e.g. Drive API is not enabled to get the revision, or the cache file is broken.
+func CacheBypassHandler(fn func(id string, err error)) Option {
+	return func(c *Client) error {
+		c.bypass = fn
+		return nil
+	}
+}
+
+// Metadata returns the title and the sheet names of the spreadsheet.
+func (c *Client) Metadata(ctx context.Context, id string) (string, []string, error) {
+
+	var resp *sheets.Spreadsheet
+	err := c.retry(ctx, func() (err error) {
+		resp, err = c.srv.Spreadsheets.Get(id).
+			Fields("properties.title", "sheets.properties.title").
+			Context(ctx).Do()
+		return err
+	})
+	if e, ok := err.(*googleapi.Error); ok && accessDenied(e) {
+		return "", nil, &AccessError{ID: id, Code: e.Code, err: err}
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("Unable to get spreadsheet (id=%s): %v", id, err)
+	}
+
+	var names []string
+	for _, s := range resp.Sheets {
+		names = append(names, s.Properties.Title)
+	}
+
+	var title string
+	if resp.Properties != nil {
+		title = resp.Properties.Title
+	}
+
+	return title, names, nil
+}
+
+// Revision returns the revision of the spreadsheet, which is changed on every modification.
+// It requires the scope of Drive API (DriveMetadataReadonlyScope), and returns ScopeError without it.
+func (c *Client) Revision(ctx context.Context, id string) (string, error) {
+
+	var resp *drive.File
+	err := c.retry(ctx, func() (err error) {
+		resp, err = c.drive.Files.Get(id).Fields("version").Context(ctx).Do()
+		return err
+	})
+	if e, ok := err.(*googleapi.Error); ok && insufficientScope(e) {
+		return "", &ScopeError{ID: id, err: err}
+	}
+	if err != nil {
+		return "", fmt.Errorf("Unable to get revision of spreadsheet (id=%s): %v", id, err)
+	}
+
+	return strconv.FormatInt(resp.Version, 10), nil
+}
+
+// Fetch returns the spreadsheet with the values of the sheets selected by SelectSheets,
+// so that the sheets skipped by the filter (optional) are not fetched.
+// If the cache (optional) has the spreadsheet of the same revision, the cached values are used.
+// If the scope is not enough to get the revision, ScopeError is returned instead of fetching every time.
+// If the revision can't be got for the other reasons or the cache file is broken,
+// the cache is not used but overwritten, and the reason is passed to CacheBypassHandler.
+// Without the cache, the revision is not got and left empty.
+func (c *Client) Fetch(ctx context.Context, id string, names []string, filter func(name string) bool, cache *Cache) (*Spreadsheet, error) {
+
+	var rev string
+	var cached *Spreadsheet
+	if cache != nil {
+		var err error
+		rev, err = c.Revision(ctx, id)
+		if _, ok := err.(*ScopeError); ok {
+			return nil, err
+		}
+		if err != nil {
+			c.bypassCache(id, err)
+		} else if cached, err = cache.Load(id); err != nil {
+			c.bypassCache(id, err)
+		}
+		if cached != nil && cached.Revision != rev {
+			cached = nil
+		}
+		if cached != nil && cached.has(SelectSheets(cached.SheetNames, names, filter)) {
+			return cached, nil
+		}
+	}
+
+	title, all, err := c.Metadata(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	selected := SelectSheets(all, names, filter)
+
+	values, err := c.Sheets(ctx, id, selected)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Spreadsheet{
+		Version:    CacheVersion,
+		ID:         id,
+		Revision:   rev,
+		Title:      title,
+		SheetNames: all,
+		Sheets:     map[string]tdconv.SheetValues{},
+	}
+	if cached != nil {
+		for n, v := range cached.Sheets {
+			s.Sheets[n] = v
+		}
+	}
+	for i, n := range selected {
+		s.Sheets[n] = values[i]
+	}
+
+	if cache != nil {
+		if err := cache.Save(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (c *Client) bypassCache(id string, err error) {
+	if c.bypass != nil {
+		c.bypass(id, err)
+	}
+}
+
+// Sheets returns the values of the sheets in the same order as the names.
+// The sheets are split into the batches, and the batches are fetched concurrently.
+func (c *Client) Sheets(ctx context.Context, id string, names []string) ([]tdconv.SheetValues, error) {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	values := make([]tdconv.SheetValues, len(names))
+	var firstErr error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.concurrency)
+
+	for start := 0; start < len(names); start += c.batchSize {
+		end := start + c.batchSize
+		if end > len(names) {
+			end = len(names)
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			err := c.batch(ctx, id, names[start:end], values[start:end])
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				cancel()
+			}
+		}(start, end)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return values, nil
+}
+
+func (c *Client) batch(ctx context.Context, id string, names []string, values []tdconv.SheetValues) error {
+
+	ranges := make([]string, len(names))
+	for i, n := range names {
+		ranges[i] = sheetRange(n)
+	}
+
+	var resp *sheets.BatchGetValuesResponse
+	err := c.retry(ctx, func() (err error) {
+		resp, err = c.srv.Spreadsheets.Values.BatchGet(id).Ranges(ranges...).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Unable to get sheet values (sheetname=%s): %v", strings.Join(names, ","), err)
+	}
+	if len(resp.ValueRanges) != len(names) {
+		return fmt.Errorf("Unexpected number of the value ranges (expected=%d, actual=%d)", len(names), len(resp.ValueRanges))
+	}
+
+	for i, vr := range resp.ValueRanges {
+		values[i] = tdconv.SheetValues(vr.Values)
+	}
+
+	return nil
+}
+
+// retry calls fc until it succeeds, or it fails with the error which is not retryable.
+func (c *Client) retry(ctx context.Context, fc func() error) error {
+
+	backoff := c.backoff
+	for i := 0; ; i++ {
+		err := fc()
+		if err == nil || i >= c.retries || !retryable(err) {
+			return err
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether the error is the rate limit or the temporary server error.
+func retryable(err error) bool {
+	e, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	switch e.Code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusForbidden:
+		return rateLimited(e)
+	}
+	return false
+}
+
+// rateLimited reports whether the error has the reason of the rate limit.
+// Google APIs respond some rate limits with forbidden instead of too many requests.
+func rateLimited(e *googleapi.Error) bool {
+	for _, item := range e.Errors {
+		switch item.Reason {
+		case "rateLimitExceeded", "userRateLimitExceeded":
+			return true
+		}
+	}
+	return false
+}
+
+// AccessError is the error when the spreadsheet can't be accessed,
+// like the invalid credentials, the insufficient permission or the wrong spreadsheet ID.
+type AccessError struct {
+	ID string
+	// Code is the HTTP status code of the response.
+	Code int
+	err  error
+}
+
+func (e *AccessError) Error() string {
+	return fmt.Sprintf("Unable to access spreadsheet (id=%s, status=%d): %v", e.ID, e.Code, e.err)
+}
+
+// accessDenied reports whether the error means that the spreadsheet can't be accessed.
+// The invalid API key is responded with bad request. The rate limit is not the access denial even if forbidden.
+func accessDenied(e *googleapi.Error) bool {
+	switch e.Code {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound:
+		return true
+	case http.StatusForbidden:
+		return !rateLimited(e)
+	}
+	return false
+}
+
+// ScopeError is the error when the credentials don't have the scope to get the revision of the spreadsheet,
+// like the OAuth token authorized before the cache is supported.
+type ScopeError struct {
+	ID  string
+	err error
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("Insufficient scope to get revision of spreadsheet (id=%s): %v", e.ID, e.err)
+}
+
+// insufficientScope reports whether the error means that the access token doesn't have the required scope.
+func insufficientScope(e *googleapi.Error) bool {
+	if e.Code != http.StatusForbidden {
+		return false
+	}
+	for _, item := range e.Errors {
+		if item.Reason == "insufficientPermissions" {
+			return true
+		}
+	}
+	return strings.Contains(e.Message, "insufficient authentication scopes")
+}
+
+// sheetRange returns the A1 notation of the whole sheet.
+func sheetRange(name string) string {
+	return "'" + strings.Replace(name, "'", "''", -1) + "'"
+}
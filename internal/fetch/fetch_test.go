@@ -0,0 +1,526 @@
+package fetch_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/takuoki/tdconv"
+	"github.com/takuoki/tdconv/internal/fetch"
+	"google.golang.org/api/option"
+)
+
+// fakeSheets is a fake of Google Sheets API.
+type fakeSheets struct {
+	title  string
+	sheets []string
+	// revision is the version of the file in Drive API. If empty, it responds forbidden.
+	revision string
+	// denied is the status code of the Sheets API responses when the access is denied (optional).
+	denied int
+	// noScope is the flag indicating whether Drive API responds the insufficient scope.
+	noScope bool
+
+	mu sync.Mutex
+	// failures is the number of the rate limit responses before success.
+	failures int
+	// limitReason is the reason of the rate limit responded with forbidden (optional).
+	// If empty, the rate limit is responded with too many requests.
+	limitReason string
+	requests    int
+	revisions   int
+	ranges      [][]string
+}
+
+func (f *fakeSheets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests++
+	if f.failures > 0 {
+		f.failures--
+		if f.limitReason != "" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprintf(w, `{"error":{"code":403,"message":"Rate Limit Exceeded","errors":[{"reason":%q}]}}`, f.limitReason)
+			return
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"code":429,"message":"Quota exceeded"}}`)
+		return
+	}
+
+	if f.denied != 0 && strings.HasPrefix(r.URL.Path, "/v4/") {
+		w.WriteHeader(f.denied)
+		fmt.Fprintf(w, `{"error":{"code":%d,"message":"Denied"}}`, f.denied)
+		return
+	}
+
+	var resp interface{}
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/files/"):
+		f.revisions++
+		if f.noScope {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"error":{"code":403,"message":"Request had insufficient authentication scopes.",`+
+				`"errors":[{"reason":"insufficientPermissions"}]}}`)
+			return
+		}
+		if f.revision == "" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"error":{"code":403,"message":"Insufficient Permission"}}`)
+			return
+		}
+		resp = map[string]interface{}{"version": f.revision}
+	case strings.HasSuffix(r.URL.Path, "/values:batchGet"):
+		ranges := r.URL.Query()["ranges"]
+		f.ranges = append(f.ranges, ranges)
+		var vrs []map[string]interface{}
+		for _, rg := range ranges {
+			name := strings.Replace(strings.Trim(rg, "'"), "''", "'", -1)
+			vrs = append(vrs, map[string]interface{}{
+				"range":  rg,
+				"values": [][]interface{}{{name}},
+			})
+		}
+		resp = map[string]interface{}{"valueRanges": vrs}
+	case strings.HasPrefix(r.URL.Path, "/v4/spreadsheets/"):
+		var ss []map[string]interface{}
+		for _, s := range f.sheets {
+			ss = append(ss, map[string]interface{}{"properties": map[string]interface{}{"title": s}})
+		}
+		resp = map[string]interface{}{
+			"properties": map[string]interface{}{"title": f.title},
+			"sheets":     ss,
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+func newClient(t *testing.T, f *fakeSheets, options ...fetch.Option) *fetch.Client {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	c, err := fetch.New(context.Background(),
+		[]option.ClientOption{option.WithEndpoint(srv.URL + "/"), option.WithHTTPClient(srv.Client())},
+		append([]fetch.Option{fetch.Retry(3, time.Millisecond)}, options...)...)
+	if err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	return c
+}
+
+func TestNew(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		opts     []fetch.Option
+		errMsg   string
+	}{
+		{
+			caseName: "success: default",
+		},
+		{
+			caseName: "success: set all options",
+			opts:     []fetch.Option{fetch.BatchSize(10), fetch.Concurrency(2), fetch.Retry(0, 0)},
+		},
+		{
+			caseName: "failure: invalid batch size",
+			opts:     []fetch.Option{fetch.BatchSize(0)},
+			errMsg:   "Batch size must be greater than zero",
+		},
+		{
+			caseName: "failure: invalid concurrency",
+			opts:     []fetch.Option{fetch.Concurrency(0)},
+			errMsg:   "Concurrency must be greater than zero",
+		},
+		{
+			caseName: "failure: invalid retry",
+			opts:     []fetch.Option{fetch.Retry(-1, 0)},
+			errMsg:   "Retry count and backoff must not be negative",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := fetch.New(context.Background(), []option.ClientOption{option.WithHTTPClient(http.DefaultClient)}, c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if err.Error() != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestClient_Metadata(t *testing.T) {
+
+	f := &fakeSheets{title: "sample", sheets: []string{"users", "posts"}, failures: 2}
+	c := newClient(t, f)
+
+	title, names, err := c.Metadata(context.Background(), "id")
+	if err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	if title != "sample" {
+		t.Errorf("title doesn't match (expected=sample, actual=%s)", title)
+	}
+	if !reflect.DeepEqual(names, []string{"users", "posts"}) {
+		t.Errorf("sheet names don't match (actual=%v)", names)
+	}
+	if f.requests != 3 {
+		t.Errorf("number of requests doesn't match (expected=3, actual=%d)", f.requests)
+	}
+}
+
+func TestClient_Metadata_rateLimit(t *testing.T) {
+
+	for _, reason := range []string{"rateLimitExceeded", "userRateLimitExceeded"} {
+		t.Run(reason, func(t *testing.T) {
+
+			f := &fakeSheets{title: "sample", sheets: []string{"users"}, failures: 2, limitReason: reason}
+			c := newClient(t, f)
+
+			if _, _, err := c.Metadata(context.Background(), "id"); err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			if f.requests != 3 {
+				t.Errorf("number of requests doesn't match (expected=3, actual=%d)", f.requests)
+			}
+
+			f = &fakeSheets{failures: 10, limitReason: reason}
+			c = newClient(t, f)
+
+			_, _, err := c.Metadata(context.Background(), "id")
+			if err == nil {
+				t.Fatalf("error must occur")
+			}
+			if _, ok := err.(*fetch.AccessError); ok {
+				t.Errorf("error must not be AccessError: %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_Metadata_accessError(t *testing.T) {
+
+	for _, code := range []int{http.StatusForbidden, http.StatusNotFound} {
+		t.Run(http.StatusText(code), func(t *testing.T) {
+
+			c := newClient(t, &fakeSheets{denied: code})
+
+			_, _, err := c.Metadata(context.Background(), "id")
+			e, ok := err.(*fetch.AccessError)
+			if !ok {
+				t.Fatalf("error must be AccessError: %v", err)
+			}
+			if e.ID != "id" || e.Code != code {
+				t.Errorf("value doesn't match (expected=id/%d, actual=%s/%d)", code, e.ID, e.Code)
+			}
+		})
+	}
+}
+
+func TestClient_Sheets(t *testing.T) {
+
+	var names []string
+	for i := 0; i < 25; i++ {
+		names = append(names, fmt.Sprintf("sheet's %02d", i))
+	}
+
+	cases := []struct {
+		caseName    string
+		opts        []fetch.Option
+		failures    int
+		limitReason string
+		batches     int
+		errMsg      string
+	}{
+		{
+			caseName: "one batch",
+			opts:     []fetch.Option{fetch.BatchSize(100)},
+			batches:  1,
+		},
+		{
+			caseName: "concurrent batches",
+			opts:     []fetch.Option{fetch.BatchSize(4), fetch.Concurrency(3)},
+			batches:  7,
+		},
+		{
+			caseName: "retry on rate limit",
+			opts:     []fetch.Option{fetch.BatchSize(10)},
+			failures: 2,
+			batches:  3,
+		},
+		{
+			caseName:    "retry on rate limit responded with forbidden",
+			opts:        []fetch.Option{fetch.BatchSize(10)},
+			failures:    2,
+			limitReason: "userRateLimitExceeded",
+			batches:     3,
+		},
+		{
+			caseName: "too many rate limits",
+			opts:     []fetch.Option{fetch.BatchSize(100)},
+			failures: 10,
+			errMsg:   "Unable to get sheet values (sheetname=" + strings.Join(names, ",") + ")",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			f := &fakeSheets{failures: c.failures, limitReason: c.limitReason}
+			cl := newClient(t, f, c.opts...)
+
+			values, err := cl.Sheets(context.Background(), "id", names)
+
+			if c.errMsg != "" {
+				if err == nil {
+					t.Fatalf("error must occur")
+				}
+				if endIndex := strings.Index(err.Error(), ":"); err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			if len(f.ranges) != c.batches {
+				t.Errorf("number of batches doesn't match (expected=%d, actual=%d)", c.batches, len(f.ranges))
+			}
+			expected := make([]tdconv.SheetValues, len(names))
+			for i, n := range names {
+				expected[i] = tdconv.SheetValues{{n}}
+			}
+			if !reflect.DeepEqual(values, expected) {
+				t.Errorf("values don't match (expected=%v, actual=%v)", expected, values)
+			}
+		})
+	}
+}
+
+func TestClient_Fetch(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		cached   *fetch.Spreadsheet
+		// broken is the content of the broken cache file (optional).
+		broken         string
+		revision       string
+		names          []string
+		filter         func(name string) bool
+		expectedFetch  bool
+		expectedBypass bool
+		expected       *fetch.Spreadsheet
+	}{
+		{
+			caseName:      "no cache",
+			revision:      "2",
+			expectedFetch: true,
+			expected: &fetch.Spreadsheet{
+				Version: fetch.CacheVersion, ID: "id", Revision: "2", Title: "sample", SheetNames: []string{"users", "posts"},
+				Sheets: map[string]tdconv.SheetValues{"users": {{"users"}}, "posts": {{"posts"}}},
+			},
+		},
+		{
+			caseName: "same revision",
+			cached: &fetch.Spreadsheet{
+				Version: fetch.CacheVersion, ID: "id", Revision: "2", Title: "cached", SheetNames: []string{"users", "posts"},
+				Sheets: map[string]tdconv.SheetValues{"users": {{"cached"}}, "posts": {{"cached"}}},
+			},
+			revision:      "2",
+			expectedFetch: false,
+			expected: &fetch.Spreadsheet{
+				Version: fetch.CacheVersion, ID: "id", Revision: "2", Title: "cached", SheetNames: []string{"users", "posts"},
+				Sheets: map[string]tdconv.SheetValues{"users": {{"cached"}}, "posts": {{"cached"}}},
+			},
+		},
+		{
+			caseName: "same revision but not cached sheet",
+			cached: &fetch.Spreadsheet{
+				Version: fetch.CacheVersion, ID: "id", Revision: "2", Title: "cached", SheetNames: []string{"users", "posts"},
+				Sheets: map[string]tdconv.SheetValues{"users": {{"cached"}}},
+			},
+			revision:      "2",
+			names:         []string{"posts"},
+			expectedFetch: true,
+			expected: &fetch.Spreadsheet{
+				Version: fetch.CacheVersion, ID: "id", Revision: "2", Title: "sample", SheetNames: []string{"users", "posts"},
+				Sheets: map[string]tdconv.SheetValues{"users": {{"cached"}}, "posts": {{"posts"}}},
+			},
+		},
+		{
+			caseName: "same revision and filtered sheet",
+			cached: &fetch.Spreadsheet{
+				Version: fetch.CacheVersion, ID: "id", Revision: "2", Title: "cached", SheetNames: []string{"users", "posts"},
+				Sheets: map[string]tdconv.SheetValues{"users": {{"cached"}}},
+			},
+			revision:      "2",
+			filter:        func(name string) bool { return name != "posts" },
+			expectedFetch: false,
+			expected: &fetch.Spreadsheet{
+				Version: fetch.CacheVersion, ID: "id", Revision: "2", Title: "cached", SheetNames: []string{"users", "posts"},
+				Sheets: map[string]tdconv.SheetValues{"users": {{"cached"}}},
+			},
+		},
+		{
+			caseName:      "filter",
+			revision:      "2",
+			filter:        func(name string) bool { return name != "users" },
+			expectedFetch: true,
+			expected: &fetch.Spreadsheet{
+				Version: fetch.CacheVersion, ID: "id", Revision: "2", Title: "sample", SheetNames: []string{"users", "posts"},
+				Sheets: map[string]tdconv.SheetValues{"posts": {{"posts"}}},
+			},
+		},
+		{
+			caseName: "revision changed",
+			cached: &fetch.Spreadsheet{
+				Version: fetch.CacheVersion, ID: "id", Revision: "1", Title: "cached", SheetNames: []string{"users"},
+				Sheets: map[string]tdconv.SheetValues{"users": {{"cached"}}},
+			},
+			revision:      "2",
+			expectedFetch: true,
+			expected: &fetch.Spreadsheet{
+				Version: fetch.CacheVersion, ID: "id", Revision: "2", Title: "sample", SheetNames: []string{"users", "posts"},
+				Sheets: map[string]tdconv.SheetValues{"users": {{"users"}}, "posts": {{"posts"}}},
+			},
+		},
+		{
+			caseName: "unknown revision",
+			cached: &fetch.Spreadsheet{
+				Version: fetch.CacheVersion, ID: "id", Revision: "", Title: "cached", SheetNames: []string{"users", "posts"},
+				Sheets: map[string]tdconv.SheetValues{"users": {{"cached"}}, "posts": {{"cached"}}},
+			},
+			revision:       "",
+			expectedFetch:  true,
+			expectedBypass: true,
+			expected: &fetch.Spreadsheet{
+				Version: fetch.CacheVersion, ID: "id", Revision: "", Title: "sample", SheetNames: []string{"users", "posts"},
+				Sheets: map[string]tdconv.SheetValues{"users": {{"users"}}, "posts": {{"posts"}}},
+			},
+		},
+		{
+			caseName:       "broken cache",
+			broken:         `{"version":1,"sheets":`,
+			revision:       "2",
+			expectedFetch:  true,
+			expectedBypass: true,
+			expected: &fetch.Spreadsheet{
+				Version: fetch.CacheVersion, ID: "id", Revision: "2", Title: "sample", SheetNames: []string{"users", "posts"},
+				Sheets: map[string]tdconv.SheetValues{"users": {{"users"}}, "posts": {{"posts"}}},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			f := &fakeSheets{title: "sample", sheets: []string{"users", "posts"}, revision: c.revision}
+			var bypassed error
+			cl := newClient(t, f, fetch.CacheBypassHandler(func(_ string, err error) { bypassed = err }))
+			dir := t.TempDir()
+			cache := fetch.NewCache(dir)
+			if c.cached != nil {
+				if err := cache.Save(c.cached); err != nil {
+					t.Fatalf("error must not occur: %v", err)
+				}
+			}
+			if c.broken != "" {
+				if err := ioutil.WriteFile(filepath.Join(dir, "id.json"), []byte(c.broken), 0644); err != nil {
+					t.Fatalf("error must not occur: %v", err)
+				}
+			}
+
+			s, err := cl.Fetch(context.Background(), "id", c.names, c.filter, cache)
+			if err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			if fetched := len(f.ranges) > 0; fetched != c.expectedFetch {
+				t.Errorf("fetched doesn't match (expected=%t, actual=%t)", c.expectedFetch, fetched)
+			}
+			if (bypassed != nil) != c.expectedBypass {
+				t.Errorf("bypassed doesn't match (expected=%t, actual=%v)", c.expectedBypass, bypassed)
+			}
+			if !reflect.DeepEqual(s, c.expected) {
+				t.Errorf("value doesn't match (expected=%+v, actual=%+v)", c.expected, s)
+			}
+
+			saved, err := cache.Load("id")
+			if err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			if !reflect.DeepEqual(saved, c.expected) {
+				t.Errorf("cache doesn't match (expected=%+v, actual=%+v)", c.expected, saved)
+			}
+		})
+	}
+}
+
+func TestClient_Fetch_scopeError(t *testing.T) {
+
+	f := &fakeSheets{title: "sample", sheets: []string{"users", "posts"}, noScope: true}
+	cl := newClient(t, f, fetch.CacheBypassHandler(func(_ string, err error) {
+		t.Errorf("cache must not be bypassed with insufficient scope: %v", err)
+	}))
+
+	_, err := cl.Fetch(context.Background(), "id", nil, nil, fetch.NewCache(t.TempDir()))
+	e, ok := err.(*fetch.ScopeError)
+	if !ok {
+		t.Fatalf("error must be ScopeError: %v", err)
+	}
+	if e.ID != "id" {
+		t.Errorf("value doesn't match (expected=id, actual=%s)", e.ID)
+	}
+	if len(f.ranges) != 0 {
+		t.Errorf("sheets must not be fetched (actual=%d)", len(f.ranges))
+	}
+}
+
+func TestClient_Fetch_noCache(t *testing.T) {
+
+	f := &fakeSheets{title: "sample", sheets: []string{"users", "posts"}, revision: "2"}
+	cl := newClient(t, f, fetch.CacheBypassHandler(func(_ string, err error) {
+		t.Errorf("cache must not be bypassed without cache: %v", err)
+	}))
+
+	s, err := cl.Fetch(context.Background(), "id", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	if f.revisions != 0 {
+		t.Errorf("revision must not be requested without cache (actual=%d)", f.revisions)
+	}
+	expected := &fetch.Spreadsheet{
+		Version: fetch.CacheVersion, ID: "id", Revision: "", Title: "sample", SheetNames: []string{"users", "posts"},
+		Sheets: map[string]tdconv.SheetValues{"users": {{"users"}}, "posts": {{"posts"}}},
+	}
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("value doesn't match (expected=%+v, actual=%+v)", expected, s)
+	}
+}
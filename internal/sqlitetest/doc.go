@@ -0,0 +1,6 @@
+// Package sqlitetest tests the Go code generated by tdconv against an in-memory SQLite database.
+// models.go and repository.go are generated by GoFormatter and GoRepositoryFormatter,
+// and are kept up to date by the tests of the package tdconv.
+// The generated code is compiled with the other packages, and the tests against SQLite need cgo,
+// so they run only with `sqlite` build tag: `go test -tags sqlite ./internal/sqlitetest`.
+package sqlitetest
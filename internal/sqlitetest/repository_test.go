@@ -0,0 +1,169 @@
+//go:build sqlite
+
+package sqlitetest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const schema = `
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    name VARCHAR(32),
+    score DOUBLE,
+    active BOOLEAN,
+    created_at TIMESTAMP NULL
+);
+CREATE INDEX name_key ON users (name);
+CREATE INDEX created_at_key ON users (created_at);
+CREATE TABLE user_roles (
+    user_id INT NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    org VARCHAR(32),
+    note TEXT,
+    PRIMARY KEY (user_id, type),
+    UNIQUE (org, note)
+);
+CREATE INDEX org_key ON user_roles (org);
+`
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("unable to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("unable to create tables: %v", err)
+	}
+	return db
+}
+
+func strp(s string) *string { return &s }
+
+func TestUsersRepository(t *testing.T) {
+
+	db := openDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	r := newUsersRepository(db)
+
+	now := time.Date(2019, 4, 1, 12, 0, 0, 0, time.UTC)
+	score := float32(1.5)
+	active := true
+	u := &users{Email: strp("foo@example.com"), Name: strp("foo"), Score: &score, Active: &active, CreatedAt: &now}
+	if err := r.Insert(ctx, u); err != nil {
+		t.Fatalf("error must not occur at Insert: %v", err)
+	}
+	if u.ID == nil || *u.ID != 1 {
+		t.Fatalf("auto increment ID must be set (actual=%v)", u.ID)
+	}
+	if err := r.Insert(ctx, &users{Email: strp("bar@example.com"), Name: strp("foo")}); err != nil {
+		t.Fatalf("error must not occur at Insert: %v", err)
+	}
+
+	got, err := r.GetByPK(ctx, 1)
+	if err != nil {
+		t.Fatalf("error must not occur at GetByPK: %v", err)
+	}
+	if *got.Email != "foo@example.com" || *got.Score != score || !*got.Active || !got.CreatedAt.Equal(now) {
+		t.Errorf("value doesn't match (actual=%+v)", got)
+	}
+
+	got, err = r.GetByEmail(ctx, "bar@example.com")
+	if err != nil {
+		t.Fatalf("error must not occur at GetByEmail: %v", err)
+	}
+	if *got.ID != 2 || got.Score != nil || got.CreatedAt != nil {
+		t.Errorf("value doesn't match (actual=%+v)", got)
+	}
+
+	list, err := r.FindByName(ctx, "foo")
+	if err != nil {
+		t.Fatalf("error must not occur at FindByName: %v", err)
+	}
+	if len(list) != 2 {
+		t.Errorf("the number of records doesn't match (expected=2, actual=%d)", len(list))
+	}
+
+	list, err = r.FindByCreatedAt(ctx, now)
+	if err != nil {
+		t.Fatalf("error must not occur at FindByCreatedAt: %v", err)
+	}
+	if len(list) != 1 || *list[0].ID != 1 {
+		t.Errorf("value doesn't match (actual=%+v)", list)
+	}
+
+	got.Name = strp("bar")
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatalf("error must not occur at Update: %v", err)
+	}
+	if list, _ := r.FindByName(ctx, "bar"); len(list) != 1 {
+		t.Errorf("the number of records doesn't match (expected=1, actual=%d)", len(list))
+	}
+
+	if err := r.Delete(ctx, 1); err != nil {
+		t.Fatalf("error must not occur at Delete: %v", err)
+	}
+	if _, err := r.GetByPK(ctx, 1); err != sql.ErrNoRows {
+		t.Errorf("error doesn't match (expected=%v, actual=%v)", sql.ErrNoRows, err)
+	}
+}
+
+func TestUserRolesRepository(t *testing.T) {
+
+	db := openDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	r := newUserRolesRepository(db)
+
+	userID := 1
+	ur := &userRoles{UserID: &userID, Type: strp("admin"), Org: strp("org"), Note: strp("note")}
+	if err := r.Insert(ctx, ur); err != nil {
+		t.Fatalf("error must not occur at Insert: %v", err)
+	}
+
+	got, err := r.GetByPK(ctx, 1, "admin")
+	if err != nil {
+		t.Fatalf("error must not occur at GetByPK: %v", err)
+	}
+	if *got.Org != "org" {
+		t.Errorf("value doesn't match (expected=org, actual=%s)", *got.Org)
+	}
+
+	got, err = r.GetByOrgNote(ctx, "org", "note")
+	if err != nil {
+		t.Fatalf("error must not occur at GetByOrgNote: %v", err)
+	}
+	if *got.Type != "admin" {
+		t.Errorf("value doesn't match (expected=admin, actual=%s)", *got.Type)
+	}
+
+	got.Note = nil
+	if err := r.Update(ctx, got); err != nil {
+		t.Fatalf("error must not occur at Update: %v", err)
+	}
+	list, err := r.FindByOrg(ctx, "org")
+	if err != nil {
+		t.Fatalf("error must not occur at FindByOrg: %v", err)
+	}
+	if len(list) != 1 || list[0].Note != nil {
+		t.Errorf("value doesn't match (actual=%+v)", list)
+	}
+
+	if err := r.Delete(ctx, 1, "admin"); err != nil {
+		t.Fatalf("error must not occur at Delete: %v", err)
+	}
+	if _, err := r.GetByPK(ctx, 1, "admin"); err != sql.ErrNoRows {
+		t.Errorf("error doesn't match (expected=%v, actual=%v)", sql.ErrNoRows, err)
+	}
+}
@@ -0,0 +1,367 @@
+package tdconv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// JSONSchemaFormatter is a formatter to output the table definision as JSON Schema (draft 2020-12).
+// Each table is output as a schema in `$defs`.
+type JSONSchemaFormatter struct {
+	formatter
+}
+
+// NewJSONSchemaFormatter creates a new JSONSchemaFormatter.
+// You can change some parameters of the JSONSchemaFormatter with JSONSchemaFormatOption.
+func NewJSONSchemaFormatter(options ...JSONSchemaFormatOption) (*JSONSchemaFormatter, error) {
+
+	f := JSONSchemaFormatter{}
+	f.setHeader(func(w io.Writer, ts *TableSet) {
+		fmt.Fprintf(w, "{\n"+
+			"  \"$comment\": \"This file generated by tdconv. DO NOT EDIT. See more details at https://github.com/takuoki/tdconv.\",\n"+
+			"  \"$schema\": \"https://json-schema.org/draft/2020-12/schema\",\n"+
+			"  \"title\": %s,\n"+
+			"  \"$defs\": {\n", jsonString(ts.Name))
+	})
+	f.setFooter(func(w io.Writer, _ *TableSet) {
+		fmt.Fprint(w, "\n  }\n}\n")
+	})
+	for _, opt := range options {
+		err := opt(&f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+// JSONSchemaFormatOption changes some parameters of the JSONSchemaFormatter.
+type JSONSchemaFormatOption func(*JSONSchemaFormatter) error
+
+// JSONSchemaHeader changes the header.
+func JSONSchemaHeader(fc func(w io.Writer, ts *TableSet)) JSONSchemaFormatOption {
+	return func(f *JSONSchemaFormatter) error {
+		f.setHeader(fc)
+		return nil
+	}
+}
+
+// JSONSchemaFooter changes the footer.
+func JSONSchemaFooter(fc func(w io.Writer, ts *TableSet)) JSONSchemaFormatOption {
+	return func(f *JSONSchemaFormatter) error {
+		f.setFooter(fc)
+		return nil
+	}
+}
+
+// JSONSchemaTypeMap overrides the type conversion to JSON Schema type.
+// The key is the base SQL type name, like `VARCHAR`, and it's case-insensitive.
+// The value is the type, optionally followed by the format separated by a colon, like `string:byte`.
+func JSONSchemaTypeMap(m map[string]string) JSONSchemaFormatOption {
+	return func(f *JSONSchemaFormatter) error {
+		f.setTypeMap(m)
+		return nil
+	}
+}
+
+// Extension returns the extension of JSON file.
+func (f *JSONSchemaFormatter) Extension() string {
+	return "json"
+}
+
+// Separator outputs the separator between the schemas.
+func (f *JSONSchemaFormatter) Separator(w io.Writer) {
+	fmt.Fprint(w, ",\n")
+}
+
+// Fprint outputs the table definision as JSON Schema.
+func (f *JSONSchemaFormatter) Fprint(w io.Writer, t *Table) {
+
+	if f == nil || t == nil {
+		return
+	}
+
+	s := f.newJSONSchema(w, t, "JSON Schema")
+	fmt.Fprintf(w, "    %s: {\n", jsonString(s.name))
+	fmt.Fprint(w, "      \"type\": \"object\",\n")
+	if len(s.required) > 0 {
+		rs := make([]string, 0, len(s.required))
+		for _, r := range s.required {
+			rs = append(rs, jsonString(r))
+		}
+		fmt.Fprintf(w, "      \"required\": [%s],\n", strings.Join(rs, ", "))
+	}
+	fmt.Fprint(w, "      \"properties\": {")
+	for i, p := range s.properties {
+		if i > 0 {
+			fmt.Fprint(w, ",")
+		}
+		fmt.Fprintf(w, "\n        %s: {", jsonString(p.name))
+		es := make([]string, 0, 5)
+		if len(p.types) == 1 {
+			es = append(es, "\"type\": "+jsonString(p.types[0]))
+		} else if len(p.types) > 1 {
+			ts := make([]string, 0, len(p.types))
+			for _, t := range p.types {
+				ts = append(ts, jsonString(t))
+			}
+			es = append(es, "\"type\": ["+strings.Join(ts, ", ")+"]")
+		}
+		if p.format != "" {
+			es = append(es, "\"format\": "+jsonString(p.format))
+		}
+		if p.maxLength > 0 {
+			es = append(es, "\"maxLength\": "+strconv.Itoa(p.maxLength))
+		}
+		if p.enum != nil {
+			vs := make([]string, 0, len(p.enum)+1)
+			for _, v := range p.enum {
+				vs = append(vs, jsonString(v))
+			}
+			if p.nullable {
+				vs = append(vs, "null")
+			}
+			es = append(es, "\"enum\": ["+strings.Join(vs, ", ")+"]")
+		}
+		if p.description != "" {
+			es = append(es, "\"description\": "+jsonString(p.description))
+		}
+		if len(es) > 0 {
+			fmt.Fprintf(w, "\n          %s\n        ", strings.Join(es, ",\n          "))
+		}
+		fmt.Fprint(w, "}")
+	}
+	fmt.Fprint(w, "\n      }\n    }")
+}
+
+// OpenAPIFormatter is a formatter to output the table definision as OpenAPI 3 `components.schemas` (YAML).
+// The schemas are the same as JSONSchemaFormatter.
+type OpenAPIFormatter struct {
+	formatter
+}
+
+// NewOpenAPIFormatter creates a new OpenAPIFormatter.
+// You can change some parameters of the OpenAPIFormatter with OpenAPIFormatOption.
+func NewOpenAPIFormatter(options ...OpenAPIFormatOption) (*OpenAPIFormatter, error) {
+
+	f := OpenAPIFormatter{}
+	f.setHeader(func(w io.Writer, ts *TableSet) {
+		fmt.Fprintf(w,
+			"# This file generated by tdconv. DO NOT EDIT.\n"+
+				"# See more details at https://github.com/takuoki/tdconv.\n"+
+				"openapi: 3.1.0\n"+
+				"info:\n"+
+				"  title: %s\n"+
+				"  version: 1.0.0\n"+
+				"paths: {}\n"+
+				"components:\n"+
+				"  schemas:\n", jsonString(ts.Name))
+	})
+	for _, opt := range options {
+		err := opt(&f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+// OpenAPIFormatOption changes some parameters of the OpenAPIFormatter.
+type OpenAPIFormatOption func(*OpenAPIFormatter) error
+
+// OpenAPIHeader changes the header.
+func OpenAPIHeader(fc func(w io.Writer, ts *TableSet)) OpenAPIFormatOption {
+	return func(f *OpenAPIFormatter) error {
+		f.setHeader(fc)
+		return nil
+	}
+}
+
+// OpenAPIFooter changes the footer.
+func OpenAPIFooter(fc func(w io.Writer, ts *TableSet)) OpenAPIFormatOption {
+	return func(f *OpenAPIFormatter) error {
+		f.setFooter(fc)
+		return nil
+	}
+}
+
+// OpenAPITypeMap overrides the type conversion to OpenAPI type.
+// It's same as JSONSchemaTypeMap.
+func OpenAPITypeMap(m map[string]string) OpenAPIFormatOption {
+	return func(f *OpenAPIFormatter) error {
+		f.setTypeMap(m)
+		return nil
+	}
+}
+
+// Extension returns the extension of YAML file.
+func (f *OpenAPIFormatter) Extension() string {
+	return "yaml"
+}
+
+// Fprint outputs the table definision as OpenAPI schema.
+func (f *OpenAPIFormatter) Fprint(w io.Writer, t *Table) {
+
+	if f == nil || t == nil {
+		return
+	}
+
+	s := f.newJSONSchema(w, t, "OpenAPI")
+	fmt.Fprintf(w, "    %s:\n", yamlKey(s.name))
+	fmt.Fprint(w, "      type: object\n")
+	if len(s.required) > 0 {
+		fmt.Fprint(w, "      required:\n")
+		for _, r := range s.required {
+			fmt.Fprintf(w, "        - %s\n", yamlKey(r))
+		}
+	}
+	fmt.Fprint(w, "      properties:\n")
+	for _, p := range s.properties {
+		fmt.Fprintf(w, "        %s:", yamlKey(p.name))
+		if len(p.types) == 0 && p.description == "" {
+			fmt.Fprint(w, " {}\n")
+			continue
+		}
+		fmt.Fprintln(w)
+		if len(p.types) == 1 {
+			fmt.Fprintf(w, "          type: %s\n", p.types[0])
+		} else if len(p.types) > 1 {
+			ts := make([]string, 0, len(p.types))
+			for _, t := range p.types {
+				ts = append(ts, jsonString(t))
+			}
+			fmt.Fprintf(w, "          type: [%s]\n", strings.Join(ts, ", "))
+		}
+		if p.format != "" {
+			fmt.Fprintf(w, "          format: %s\n", p.format)
+		}
+		if p.maxLength > 0 {
+			fmt.Fprintf(w, "          maxLength: %d\n", p.maxLength)
+		}
+		if p.enum != nil {
+			fmt.Fprint(w, "          enum:\n")
+			for _, v := range p.enum {
+				fmt.Fprintf(w, "            - %s\n", jsonString(v))
+			}
+			if p.nullable {
+				fmt.Fprint(w, "            - null\n")
+			}
+		}
+		if p.description != "" {
+			fmt.Fprintf(w, "          description: %s\n", jsonString(p.description))
+		}
+	}
+}
+
+type jsonSchema struct {
+	name       string
+	required   []string
+	properties []jsonSchemaProperty
+}
+
+type jsonSchemaProperty struct {
+	name        string
+	types       []string
+	format      string
+	maxLength   int
+	enum        []string
+	nullable    bool
+	description string
+}
+
+// newJSONSchema converts the table to the schema, and reports the error to the writer if the type can't be converted.
+func (f *formatter) newJSONSchema(w io.Writer, t *Table, lang string) *jsonSchema {
+
+	s := &jsonSchema{name: strcase.ToCamel(t.Name)}
+
+	for _, c := range t.Columns {
+		p := jsonSchemaProperty{
+			name:        c.Name,
+			nullable:    !c.NotNull && !c.PKey,
+			description: c.Comment,
+		}
+		if !p.nullable {
+			s.required = append(s.required, c.Name)
+		}
+
+		st := parseSQLType(c.Type)
+		var typ string
+		if m, ok := f.typeMap[st.name]; ok {
+			typ = m
+			if i := strings.Index(m, ":"); i >= 0 {
+				typ, p.format = m[:i], m[i+1:]
+			}
+		} else {
+			typ, p.format, p.maxLength, p.enum = convJSONSchemaType(st)
+			if typ == "" {
+				ReportError(w, typeError(lang, t, c))
+			}
+		}
+		if typ != "" {
+			p.types = []string{typ}
+			if p.nullable {
+				p.types = append(p.types, "null")
+			}
+		}
+		s.properties = append(s.properties, p)
+	}
+
+	return s
+}
+
+func convJSONSchemaType(st sqlType) (typ, format string, maxLength int, enum []string) {
+	switch st.name {
+	case "INT", "TINYINT", "SMALLINT", "MEDIUMINT":
+		return "integer", "int32", 0, nil
+	case "BIGINT":
+		return "integer", "int64", 0, nil
+	case "DOUBLE":
+		return "number", "double", 0, nil
+	case "FLOAT":
+		return "number", "float", 0, nil
+	case "DECIMAL":
+		return "number", "", 0, nil
+	case "CHAR", "VARCHAR":
+		if len(st.args) > 0 {
+			maxLength, _ = strconv.Atoi(st.args[0])
+		}
+		return "string", "", maxLength, nil
+	case "TEXT":
+		return "string", "", 0, nil
+	case "ENUM":
+		return "string", "", 0, st.args
+	case "BOOLEAN":
+		return "boolean", "", 0, nil
+	case "TIMESTAMP", "DATETIME":
+		return "string", "date-time", 0, nil
+	case "DATE":
+		return "string", "date", 0, nil
+	case "TIME":
+		return "string", "time", 0, nil
+	}
+	return "", "", 0, nil
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+var yamlKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func yamlKey(s string) string {
+	switch strings.ToLower(s) {
+	case "true", "false", "null", "yes", "no", "on", "off", "y", "n":
+		return jsonString(s)
+	}
+	if yamlKeyRegexp.MatchString(s) {
+		return s
+	}
+	return jsonString(s)
+}
@@ -0,0 +1,412 @@
+package tdconv_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+var mustJSONSchemaFormatter = func(options ...tdconv.JSONSchemaFormatOption) *tdconv.JSONSchemaFormatter {
+	f, err := tdconv.NewJSONSchemaFormatter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+var mustOpenAPIFormatter = func(options ...tdconv.OpenAPIFormatOption) *tdconv.OpenAPIFormatter {
+	f, err := tdconv.NewOpenAPIFormatter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+var jsonSchemaTestTable = &tdconv.Table{
+	Name: "sample_table",
+	Columns: []tdconv.Column{
+		{Name: "id", Type: "BIGINT UNSIGNED", PKey: true, NotNull: true, Unique: false, Index: false, Option: "AUTO_INCREMENT", Comment: "this is id!", IsCommon: false},
+		{Name: "foo", Type: "VARCHAR(32)", PKey: false, NotNull: true, Unique: true, Index: false, Option: "", Comment: "", IsCommon: false},
+		{Name: "bar", Type: "ENUM('a','b')", PKey: false, NotNull: false, Unique: false, Index: true, Option: "", Comment: "", IsCommon: false},
+		{Name: "baz", Type: "LONGBLOB", PKey: false, NotNull: false, Unique: false, Index: false, Option: "", Comment: "", IsCommon: false},
+		{Name: "created_at", Type: "TIMESTAMP NULL", PKey: false, NotNull: false, Unique: false, Index: false, Option: "DEFAULT CURRENT_TIMESTAMP", Comment: "", IsCommon: true},
+	},
+	PKeyColumns: []string{"id"},
+	IndexKeys:   []tdconv.Key{{Name: "bar_key", Columns: []string{"bar"}}},
+}
+
+func TestNewJSONSchemaFormatter(t *testing.T) {
+
+	errOptionFunc := func(*tdconv.JSONSchemaFormatter) error {
+		return errors.New("error")
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.JSONSchemaFormatOption
+		errMsg   string
+	}{
+		{
+			caseName: "success: default",
+		},
+		{
+			caseName: "success: set all options",
+			opts: []tdconv.JSONSchemaFormatOption{
+				tdconv.JSONSchemaHeader(nil),
+				tdconv.JSONSchemaFooter(nil),
+				tdconv.JSONSchemaTypeMap(map[string]string{"longblob": "string:byte"}),
+			},
+		},
+		{
+			caseName: "failure: option error",
+			opts:     []tdconv.JSONSchemaFormatOption{errOptionFunc},
+			errMsg:   "error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.NewJSONSchemaFormatter(c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if endIndex := strings.Index(err.Error(), ":"); endIndex < 0 {
+					if err.Error() != c.errMsg {
+						t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+						return
+					}
+				} else if err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestJSONSchemaFormatter_Extension(t *testing.T) {
+	var f *tdconv.JSONSchemaFormatter
+	if f.Extension() != "json" {
+		t.Errorf("value doesn't match (expected=json, actual=%s)", f.Extension())
+	}
+}
+
+func TestJSONSchemaFormatter_Fprint(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.JSONSchemaFormatter
+		t        *tdconv.Table
+		expected string
+		errMsg   string
+	}{
+		{
+			caseName: "nil formatter",
+			f:        nil,
+			t:        jsonSchemaTestTable,
+			expected: "",
+		},
+		{
+			caseName: "table is nil",
+			f:        mustJSONSchemaFormatter(),
+			t:        nil,
+			expected: "",
+		},
+		{
+			caseName: "standard output",
+			f:        mustJSONSchemaFormatter(),
+			t:        jsonSchemaTestTable,
+			expected: "    \"SampleTable\": {\n" +
+				"      \"type\": \"object\",\n" +
+				"      \"required\": [\"id\", \"foo\"],\n" +
+				"      \"properties\": {\n" +
+				"        \"id\": {\n" +
+				"          \"type\": \"integer\",\n" +
+				"          \"format\": \"int64\",\n" +
+				"          \"description\": \"this is id!\"\n" +
+				"        },\n" +
+				"        \"foo\": {\n" +
+				"          \"type\": \"string\",\n" +
+				"          \"maxLength\": 32\n" +
+				"        },\n" +
+				"        \"bar\": {\n" +
+				"          \"type\": [\"string\", \"null\"],\n" +
+				"          \"enum\": [\"a\", \"b\", null]\n" +
+				"        },\n" +
+				"        \"baz\": {},\n" +
+				"        \"created_at\": {\n" +
+				"          \"type\": [\"string\", \"null\"],\n" +
+				"          \"format\": \"date-time\"\n" +
+				"        }\n" +
+				"      }\n" +
+				"    }",
+			errMsg: "Unable to convert the type to JSON Schema (table=sample_table, column=baz, type=LONGBLOB)",
+		},
+		{
+			caseName: "type map",
+			f:        mustJSONSchemaFormatter(tdconv.JSONSchemaTypeMap(map[string]string{"longblob": "string:byte", "TIMESTAMP": "integer"})),
+			t:        jsonSchemaTestTable,
+			expected: "    \"SampleTable\": {\n" +
+				"      \"type\": \"object\",\n" +
+				"      \"required\": [\"id\", \"foo\"],\n" +
+				"      \"properties\": {\n" +
+				"        \"id\": {\n" +
+				"          \"type\": \"integer\",\n" +
+				"          \"format\": \"int64\",\n" +
+				"          \"description\": \"this is id!\"\n" +
+				"        },\n" +
+				"        \"foo\": {\n" +
+				"          \"type\": \"string\",\n" +
+				"          \"maxLength\": 32\n" +
+				"        },\n" +
+				"        \"bar\": {\n" +
+				"          \"type\": [\"string\", \"null\"],\n" +
+				"          \"enum\": [\"a\", \"b\", null]\n" +
+				"        },\n" +
+				"        \"baz\": {\n" +
+				"          \"type\": [\"string\", \"null\"],\n" +
+				"          \"format\": \"byte\"\n" +
+				"        },\n" +
+				"        \"created_at\": {\n" +
+				"          \"type\": [\"integer\", \"null\"]\n" +
+				"        }\n" +
+				"      }\n" +
+				"    }",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			err := tdconv.FprintTable(b, c.f, nil, c.t)
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+				}
+			} else if err == nil || err.Error() != c.errMsg {
+				t.Errorf("error message doesn't match (expected=%s, actual=%v)", c.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaFormatter_ValidJSON(t *testing.T) {
+
+	f := mustJSONSchemaFormatter()
+	ts := &tdconv.TableSet{
+		Name:   "sample_table_set",
+		Tables: []*tdconv.Table{jsonSchemaTestTable, {Name: "other_table", Columns: []tdconv.Column{{Name: "id", Type: "INT"}}}},
+	}
+
+	b := &bytes.Buffer{}
+	f.Header(b, ts)
+	f.Fprint(b, ts.Tables[0])
+	f.Separator(b)
+	f.Fprint(b, ts.Tables[1])
+	f.Footer(b, ts)
+
+	var v struct {
+		Schema string                     `json:"$schema"`
+		Defs   map[string]json.RawMessage `json:"$defs"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &v); err != nil {
+		t.Fatalf("output must be valid JSON: %v\n%s", err, b.String())
+	}
+	if v.Schema != "https://json-schema.org/draft/2020-12/schema" {
+		t.Errorf("schema doesn't match (actual=%s)", v.Schema)
+	}
+	if len(v.Defs) != 2 {
+		t.Errorf("the number of schemas doesn't match (expected=2, actual=%d)", len(v.Defs))
+	}
+}
+
+func TestNewOpenAPIFormatter(t *testing.T) {
+
+	errOptionFunc := func(*tdconv.OpenAPIFormatter) error {
+		return errors.New("error")
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.OpenAPIFormatOption
+		errMsg   string
+	}{
+		{
+			caseName: "success: default",
+		},
+		{
+			caseName: "success: set all options",
+			opts: []tdconv.OpenAPIFormatOption{
+				tdconv.OpenAPIHeader(nil),
+				tdconv.OpenAPIFooter(nil),
+				tdconv.OpenAPITypeMap(map[string]string{"longblob": "string:byte"}),
+			},
+		},
+		{
+			caseName: "failure: option error",
+			opts:     []tdconv.OpenAPIFormatOption{errOptionFunc},
+			errMsg:   "error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.NewOpenAPIFormatter(c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if endIndex := strings.Index(err.Error(), ":"); endIndex < 0 {
+					if err.Error() != c.errMsg {
+						t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+						return
+					}
+				} else if err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAPIFormatter_Extension(t *testing.T) {
+	var f *tdconv.OpenAPIFormatter
+	if f.Extension() != "yaml" {
+		t.Errorf("value doesn't match (expected=yaml, actual=%s)", f.Extension())
+	}
+}
+
+func TestOpenAPIFormatter_Fprint(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.OpenAPIFormatter
+		t        *tdconv.Table
+		expected string
+		errMsg   string
+	}{
+		{
+			caseName: "nil formatter",
+			f:        nil,
+			t:        jsonSchemaTestTable,
+			expected: "",
+		},
+		{
+			caseName: "table is nil",
+			f:        mustOpenAPIFormatter(),
+			t:        nil,
+			expected: "",
+		},
+		{
+			caseName: "standard output",
+			f:        mustOpenAPIFormatter(),
+			t:        jsonSchemaTestTable,
+			expected: "    SampleTable:\n" +
+				"      type: object\n" +
+				"      required:\n" +
+				"        - id\n" +
+				"        - foo\n" +
+				"      properties:\n" +
+				"        id:\n" +
+				"          type: integer\n" +
+				"          format: int64\n" +
+				"          description: \"this is id!\"\n" +
+				"        foo:\n" +
+				"          type: string\n" +
+				"          maxLength: 32\n" +
+				"        bar:\n" +
+				"          type: [\"string\", \"null\"]\n" +
+				"          enum:\n" +
+				"            - \"a\"\n" +
+				"            - \"b\"\n" +
+				"            - null\n" +
+				"        baz: {}\n" +
+				"        created_at:\n" +
+				"          type: [\"string\", \"null\"]\n" +
+				"          format: date-time\n",
+			errMsg: "Unable to convert the type to OpenAPI (table=sample_table, column=baz, type=LONGBLOB)",
+		},
+		{
+			caseName: "type map",
+			f:        mustOpenAPIFormatter(tdconv.OpenAPITypeMap(map[string]string{"LONGBLOB": "string:binary"})),
+			t: &tdconv.Table{
+				Name:    "sample_table",
+				Columns: []tdconv.Column{{Name: "baz", Type: "LONGBLOB", NotNull: true}},
+			},
+			expected: "    SampleTable:\n" +
+				"      type: object\n" +
+				"      required:\n" +
+				"        - baz\n" +
+				"      properties:\n" +
+				"        baz:\n" +
+				"          type: string\n" +
+				"          format: binary\n",
+		},
+		{
+			caseName: "keys need quote",
+			f:        mustOpenAPIFormatter(),
+			t: &tdconv.Table{
+				Name: "sample_table",
+				Columns: []tdconv.Column{
+					{Name: "on", Type: "BOOLEAN", NotNull: true},
+					{Name: "1st", Type: "DATE", NotNull: true},
+				},
+			},
+			expected: "    SampleTable:\n" +
+				"      type: object\n" +
+				"      required:\n" +
+				"        - \"on\"\n" +
+				"        - \"1st\"\n" +
+				"      properties:\n" +
+				"        \"on\":\n" +
+				"          type: boolean\n" +
+				"        \"1st\":\n" +
+				"          type: string\n" +
+				"          format: date\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			err := tdconv.FprintTable(b, c.f, nil, c.t)
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+				}
+			} else if err == nil || err.Error() != c.errMsg {
+				t.Errorf("error message doesn't match (expected=%s, actual=%v)", c.errMsg, err)
+			}
+		})
+	}
+}
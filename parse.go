@@ -1,11 +1,13 @@
 package tdconv
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"strings"
+	"text/template"
 
 	"github.com/takuoki/clmconv"
-	"github.com/takuoki/gsheets"
 )
 
 // Parser is a struct to parse the sheet values to the table object.
@@ -28,33 +30,63 @@ type Parser struct {
 	optionColumn,
 	commentColumn int
 
+	// common column groups of each table (optional)
+	commonGroupsRow,
+	commonGroupsColumn int
+	tableCommonGroups map[string][]string
+
 	// other properties
 	boolString  string
-	keyNameFunc func(string) string
+	keyNameFunc func(string) (string, error)
+	overlapFunc func(table, column, group string)
 
 	// non-initialized properties
-	commonColumns []Column
+	commonGroups []*commonGroup
+}
+
+// DefaultCommonGroup is the name of the common column group set by SetCommonColumns.
+const DefaultCommonGroup = "common"
+
+// Placement is the placement of the common columns in the table.
+type Placement int
+
+// Placements of the common columns.
+const (
+	// Append places the common columns after the table columns. It's the default.
+	Append Placement = iota
+	// Prepend places the common columns before the table columns.
+	Prepend
+)
+
+// commonGroup is a named group of the common columns.
+type commonGroup struct {
+	name      string
+	columns   []Column
+	placement Placement
+	optIn     bool
 }
 
 // NewParser creates a new Parser.
 // You can change some parameters of the Parser with ParseOption.
 func NewParser(options ...ParseOption) (*Parser, error) {
 	p := Parser{
-		tableNameRow:    1,
-		tableNameColumn: clmconv.MustAtoi("C"),
-		startRow:        4,
-		noColumn:        clmconv.MustAtoi("B"),
-		nameColumn:      clmconv.MustAtoi("C"),
-		typeColumn:      clmconv.MustAtoi("D"),
-		pKeyColumn:      clmconv.MustAtoi("E"),
-		notNullColumn:   clmconv.MustAtoi("F"),
-		uniqueColumn:    clmconv.MustAtoi("G"),
-		indexColumn:     clmconv.MustAtoi("H"),
-		optionColumn:    clmconv.MustAtoi("I"),
-		commentColumn:   clmconv.MustAtoi("J"),
-		boolString:      "yes",
-		keyNameFunc: func(s string) string {
-			return s + "_key"
+		tableNameRow:       1,
+		tableNameColumn:    clmconv.MustAtoi("C"),
+		startRow:           4,
+		noColumn:           clmconv.MustAtoi("B"),
+		nameColumn:         clmconv.MustAtoi("C"),
+		typeColumn:         clmconv.MustAtoi("D"),
+		pKeyColumn:         clmconv.MustAtoi("E"),
+		notNullColumn:      clmconv.MustAtoi("F"),
+		uniqueColumn:       clmconv.MustAtoi("G"),
+		indexColumn:        clmconv.MustAtoi("H"),
+		optionColumn:       clmconv.MustAtoi("I"),
+		commentColumn:      clmconv.MustAtoi("J"),
+		commonGroupsRow:    -1,
+		commonGroupsColumn: -1,
+		boolString:         "yes",
+		keyNameFunc: func(s string) (string, error) {
+			return s + "_key", nil
 		},
 	}
 	for _, opt := range options {
@@ -91,11 +123,50 @@ func StartRow(row int) ParseOption {
 		if row <= p.tableNameRow {
 			return errors.New("Start row must be greater than the table name row")
 		}
+		if row <= p.commonGroupsRow {
+			return errors.New("Start row must be greater than the common groups row")
+		}
 		p.startRow = row
 		return nil
 	}
 }
 
+// CommonGroupsPos sets the position (row and column) of the cell to select the common column groups of each table.
+// The cell is a comma-separated list of the group names to opt in, the group names with '-' prefix to opt out,
+// or 'none' to opt out all groups. If not set, all groups except the opt-in ones are applied to each table.
+func CommonGroupsPos(row int, clm string) ParseOption {
+	return func(p *Parser) error {
+		if row >= p.startRow {
+			return errors.New("Common groups row must be smaller than the start row")
+		}
+		p.commonGroupsRow = row
+		i, err := clmconv.Atoi(clm)
+		if err != nil {
+			return fmt.Errorf("Unable to convert column string: %v", err)
+		}
+		p.commonGroupsColumn = i
+		return nil
+	}
+}
+
+// TableCommonGroups sets the common column groups of the tables, the key is the table name.
+// The values are same as the cell of CommonGroupsPos, and take precedence over the cell.
+func TableCommonGroups(m map[string][]string) ParseOption {
+	return func(p *Parser) error {
+		p.tableCommonGroups = m
+		return nil
+	}
+}
+
+// OverlapReport sets the function called for each column of the common column group
+// which is skipped because the table or the prior group has the same column.
+func OverlapReport(f func(table, column, group string)) ParseOption {
+	return func(p *Parser) error {
+		p.overlapFunc = f
+		return nil
+	}
+}
+
 // BoolString changes the bool string in the sheet.
 func BoolString(str string) ParseOption {
 	return func(p *Parser) error {
@@ -110,42 +181,223 @@ func KeyNameFunc(f func(string) string) ParseOption {
 		if f == nil {
 			return errors.New("Key name function must not be nil")
 		}
-		p.keyNameFunc = f
+		p.keyNameFunc = func(s string) (string, error) {
+			return f(s), nil
+		}
+		return nil
+	}
+}
+
+// KeyNameTemplate changes the key name to the result of the template executed with the column name (e.g. `idx_{{ . }}`).
+// The functions of TemplateFuncMap are available. If the template fails with a column, the parse fails.
+func KeyNameTemplate(text string) ParseOption {
+	return func(p *Parser) error {
+		tmpl, err := template.New("key_name").Funcs(TemplateFuncMap()).Parse(text)
+		if err != nil {
+			return fmt.Errorf("Unable to parse key name template: %v", err)
+		}
+		p.keyNameFunc = func(s string) (string, error) {
+			var b bytes.Buffer
+			if err := tmpl.Execute(&b, s); err != nil {
+				return "", fmt.Errorf("Unable to execute key name template (column=%s): %v", s, err)
+			}
+			return b.String(), nil
+		}
 		return nil
 	}
 }
 
-// SetCommonColumns parses the common sheet values and sets them as common columns.
-func (p *Parser) SetCommonColumns(s *gsheets.Sheet) error {
+// Sheet is the values of a sheet.
+// The row and column are zero-based, and Value returns an empty string out of range.
+// *gsheets.Sheet of `github.com/takuoki/gsheets` package implements it.
+type Sheet interface {
+	Value(row, clm int) string
+}
+
+// SheetValues is a Sheet of the raw values, like the values returned by Google Sheets API.
+type SheetValues [][]interface{}
+
+// Value returns the string value.
+func (s SheetValues) Value(row, clm int) string {
+	if row < 0 || len(s) <= row || clm < 0 || len(s[row]) <= clm {
+		return ""
+	}
+	return fmt.Sprintf("%v", s[row][clm])
+}
+
+// CommonOption changes the behavior of the common column group.
+type CommonOption func(*commonGroup) error
+
+// CommonPlacement changes the placement of the common columns. The default is Append.
+func CommonPlacement(pl Placement) CommonOption {
+	return func(g *commonGroup) error {
+		if pl != Append && pl != Prepend {
+			return fmt.Errorf("Unknown placement (%d)", pl)
+		}
+		g.placement = pl
+		return nil
+	}
+}
+
+// CommonOptIn makes the common column group applied only to the tables which opt in.
+func CommonOptIn() CommonOption {
+	return func(g *commonGroup) error {
+		g.optIn = true
+		return nil
+	}
+}
+
+// SetCommonColumns parses the common sheet values and sets them as common columns,
+// which are the common column group named DefaultCommonGroup.
+func (p *Parser) SetCommonColumns(s Sheet) error {
+	if p == nil {
+		return nil
+	}
+	for _, g := range p.commonGroups {
+		if g.name == DefaultCommonGroup {
+			return errors.New("The common columns are already set")
+		}
+	}
+	return p.AddCommonColumns(DefaultCommonGroup, s)
+}
+
+// AddCommonColumns parses the common sheet values and adds them as the named common column group.
+// The groups are applied to each table in the order of the addition.
+// The name can have spaces (e.g. "Audit Columns"), but not `,`, a leading `-` and the surrounding spaces,
+// because the names are listed with `,` in the cell of CommonGroupsPos.
+func (p *Parser) AddCommonColumns(name string, s Sheet, options ...CommonOption) error {
 	if p == nil {
 		return nil
 	}
-	if len(p.commonColumns) > 0 {
-		return errors.New("The common columns are already set")
+	if name == "" || name == "none" || strings.Contains(name, ",") || strings.HasPrefix(name, "-") || strings.TrimSpace(name) != name {
+		return fmt.Errorf("Invalid common group name (%s)", name)
+	}
+	for _, g := range p.commonGroups {
+		if g.name == name {
+			return fmt.Errorf("The common group is already set (%s)", name)
+		}
+	}
+	g := &commonGroup{name: name}
+	for _, opt := range options {
+		if err := opt(g); err != nil {
+			return err
+		}
 	}
 	t, err := p.parse(s, true)
 	if err != nil {
 		return err
 	}
-	p.commonColumns = t.Columns
+	g.columns = t.Columns
+	p.commonGroups = append(p.commonGroups, g)
 	return nil
 }
 
+// IsTable reports whether the sheet looks like a table definitions sheet,
+// that is, it has the table name and the first column row.
+// It's useful to skip the other sheets like README before Parse.
+func (p *Parser) IsTable(s Sheet) bool {
+	if p == nil || s == nil {
+		return false
+	}
+	return s.Value(p.tableNameRow, p.tableNameColumn) != "" && s.Value(p.startRow, p.noColumn) != ""
+}
+
 // Parse parses the sheet values to the table object.
-func (p *Parser) Parse(s *gsheets.Sheet) (*Table, error) {
+func (p *Parser) Parse(s Sheet) (*Table, error) {
 
 	if p == nil {
 		return nil, nil
 	}
 
-	if s.Value(p.tableNameRow, p.tableNameColumn) == "" {
+	if s == nil || s.Value(p.tableNameRow, p.tableNameColumn) == "" {
 		return nil, errors.New("Table name is required")
 	}
 
-	return p.parse(s, false)
+	t, err := p.parse(s, false)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := p.commonGroupsOf(s, t.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]struct{}{}
+	for _, c := range t.Columns {
+		names[c.Name] = struct{}{}
+	}
+	var prepend, appended []Column
+	for _, g := range groups {
+		for _, c := range g.columns {
+			if _, ok := names[c.Name]; ok {
+				if p.overlapFunc != nil {
+					p.overlapFunc(t.Name, c.Name, g.name)
+				}
+				continue
+			}
+			names[c.Name] = struct{}{}
+			if g.placement == Prepend {
+				prepend = append(prepend, c)
+			} else {
+				appended = append(appended, c)
+			}
+		}
+	}
+	if len(prepend) > 0 {
+		t.Columns = append(prepend, t.Columns...)
+	}
+	t.Columns = append(t.Columns, appended...)
+
+	return t, nil
+}
+
+// commonGroupsOf returns the common column groups applied to the table,
+// selected by TableCommonGroups or the cell of CommonGroupsPos.
+func (p *Parser) commonGroupsOf(s Sheet, table string) ([]*commonGroup, error) {
+
+	selected := map[string]bool{}
+	for _, g := range p.commonGroups {
+		selected[g.name] = !g.optIn
+	}
+
+	items, ok := p.tableCommonGroups[table]
+	if !ok && p.commonGroupsRow >= 0 && p.commonGroupsColumn >= 0 {
+		items = strings.Split(s.Value(p.commonGroupsRow, p.commonGroupsColumn), ",")
+	}
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if item == "none" {
+			for name := range selected {
+				selected[name] = false
+			}
+			continue
+		}
+		name := strings.TrimSpace(strings.TrimPrefix(item, "-"))
+		if _, ok := selected[name]; !ok {
+			return nil, fmt.Errorf("Unknown common group (%s)", name)
+		}
+		selected[name] = !strings.HasPrefix(item, "-")
+	}
+
+	var groups []*commonGroup
+	for _, g := range p.commonGroups {
+		if selected[g.name] {
+			groups = append(groups, g)
+		}
+	}
+
+	return groups, nil
 }
 
-func (p *Parser) parse(s *gsheets.Sheet, common bool) (*Table, error) {
+func (p *Parser) parse(s Sheet, common bool) (*Table, error) {
+
+	if s == nil {
+		s = SheetValues(nil)
+	}
 
 	t := Table{
 		Name:        s.Value(p.tableNameRow, p.tableNameColumn),
@@ -153,36 +405,33 @@ func (p *Parser) parse(s *gsheets.Sheet, common bool) (*Table, error) {
 		PKeyColumns: make([]string, 0, 4),
 	}
 
-	for i, r := range s.Rows() {
+	for i := p.startRow; ; i++ {
 
-		if i < p.startRow {
-			continue
-		}
-		if r.Value(p.noColumn) == "" {
+		if s.Value(i, p.noColumn) == "" {
 			break
 		}
-		if r.Value(p.typeColumn) == "" {
+		if s.Value(i, p.typeColumn) == "" {
 			continue
 		}
 
 		if common {
-			if r.Value(p.pKeyColumn) == p.boolString {
+			if s.Value(i, p.pKeyColumn) == p.boolString {
 				return nil, errors.New("The common column must not be PK")
 			}
-			if r.Value(p.indexColumn) == p.boolString {
+			if s.Value(i, p.indexColumn) == p.boolString {
 				return nil, errors.New("The common column must not have index")
 			}
 		}
 
 		c := Column{
-			Name:     r.Value(p.nameColumn),
-			Type:     r.Value(p.typeColumn),
-			PKey:     r.Value(p.pKeyColumn) == p.boolString,
-			NotNull:  r.Value(p.notNullColumn) == p.boolString,
-			Unique:   r.Value(p.uniqueColumn) == p.boolString,
-			Index:    r.Value(p.indexColumn) == p.boolString,
-			Option:   r.Value(p.optionColumn),
-			Comment:  r.Value(p.commentColumn),
+			Name:     s.Value(i, p.nameColumn),
+			Type:     s.Value(i, p.typeColumn),
+			PKey:     s.Value(i, p.pKeyColumn) == p.boolString,
+			NotNull:  s.Value(i, p.notNullColumn) == p.boolString,
+			Unique:   s.Value(i, p.uniqueColumn) == p.boolString,
+			Index:    s.Value(i, p.indexColumn) == p.boolString,
+			Option:   s.Value(i, p.optionColumn),
+			Comment:  s.Value(i, p.commentColumn),
 			IsCommon: common,
 		}
 		t.Columns = append(t.Columns, c)
@@ -191,7 +440,11 @@ func (p *Parser) parse(s *gsheets.Sheet, common bool) (*Table, error) {
 			t.PKeyColumns = append(t.PKeyColumns, c.Name)
 		}
 		if c.Index {
-			t.IndexKeys = append(t.IndexKeys, Key{Name: p.keyNameFunc(c.Name), Columns: []string{c.Name}})
+			k, err := p.keyNameFunc(c.Name)
+			if err != nil {
+				return nil, err
+			}
+			t.IndexKeys = append(t.IndexKeys, Key{Name: k, Columns: []string{c.Name}})
 		}
 	}
 
@@ -199,9 +452,5 @@ func (p *Parser) parse(s *gsheets.Sheet, common bool) (*Table, error) {
 		return nil, errors.New("The length of table columns must not be zero")
 	}
 
-	if len(p.commonColumns) > 0 {
-		t.Columns = append(t.Columns, p.commonColumns...)
-	}
-
 	return &t, nil
 }
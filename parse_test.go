@@ -53,11 +53,26 @@ func TestNewParser(t *testing.T) {
 			opts:     []tdconv.ParseOption{tdconv.StartRow(2), tdconv.TableNamePos(3, "C")},
 			errMsg:   "Table name row must be smaller than the start row",
 		},
+		{
+			caseName: "failure: CommonGroupsPos row",
+			opts:     []tdconv.ParseOption{tdconv.CommonGroupsPos(4, "C")},
+			errMsg:   "Common groups row must be smaller than the start row",
+		},
+		{
+			caseName: "failure: CommonGroupsPos -> StartRow",
+			opts:     []tdconv.ParseOption{tdconv.CommonGroupsPos(2, "C"), tdconv.StartRow(2)},
+			errMsg:   "Start row must be greater than the common groups row",
+		},
 		{
 			caseName: "failure: KeyNameFunc",
 			opts:     []tdconv.ParseOption{tdconv.KeyNameFunc(nil)},
 			errMsg:   "Key name function must not be nil",
 		},
+		{
+			caseName: "failure: KeyNameTemplate",
+			opts:     []tdconv.ParseOption{tdconv.KeyNameTemplate("{{ . ")},
+			errMsg:   "Unable to parse key name template",
+		},
 	}
 
 	for _, c := range cases {
@@ -184,6 +199,196 @@ func TestParser_SetCommonColumns(t *testing.T) {
 	}
 }
 
+func TestParser_AddCommonColumns(t *testing.T) {
+
+	audit := [][]interface{}{
+		row(t, "1", "created_at", "TIMESTAMP", "no", "yes", "no", "no", "", ""),
+		row(t, "2", "updated_at", "TIMESTAMP", "no", "yes", "no", "no", "", ""),
+	}
+	softDelete := [][]interface{}{
+		row(t, "1", "deleted_at", "TIMESTAMP NULL", "no", "no", "no", "no", "", ""),
+	}
+	tenant := [][]interface{}{
+		row(t, "1", "tenant_id", "INT", "no", "yes", "no", "no", "", ""),
+		row(t, "2", "updated_at", "TIMESTAMP", "no", "yes", "no", "no", "", ""),
+	}
+
+	type group struct {
+		name string
+		rows [][]interface{}
+		opts []tdconv.CommonOption
+	}
+	type overlap struct {
+		table, column, group string
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.ParseOption
+		groups   []group
+		cell     string
+		expected []string
+		overlaps []overlap
+		errMsg   string
+	}{
+		{
+			caseName: "success:append and prepend",
+			groups: []group{
+				{name: "audit", rows: audit},
+				{name: "tenant", rows: tenant[:1], opts: []tdconv.CommonOption{tdconv.CommonPlacement(tdconv.Prepend)}},
+			},
+			expected: []string{"tenant_id", "id", "name", "created_at", "updated_at"},
+		},
+		{
+			caseName: "success:opt-in group is not applied by default",
+			groups: []group{
+				{name: "audit", rows: audit},
+				{name: "soft_delete", rows: softDelete, opts: []tdconv.CommonOption{tdconv.CommonOptIn()}},
+			},
+			cell:     "soft_delete",
+			expected: []string{"id", "name", "created_at", "updated_at"},
+		},
+		{
+			caseName: "success:opt in and out with the cell",
+			opts:     []tdconv.ParseOption{tdconv.CommonGroupsPos(2, "C")},
+			groups: []group{
+				{name: "audit", rows: audit},
+				{name: "soft_delete", rows: softDelete, opts: []tdconv.CommonOption{tdconv.CommonOptIn()}},
+			},
+			cell:     "-audit, soft_delete",
+			expected: []string{"id", "name", "deleted_at"},
+		},
+		{
+			caseName: "success:group name with spaces",
+			opts:     []tdconv.ParseOption{tdconv.CommonGroupsPos(2, "C")},
+			groups: []group{
+				{name: "Audit Columns", rows: audit},
+				{name: "Soft Delete", rows: softDelete, opts: []tdconv.CommonOption{tdconv.CommonOptIn()}},
+			},
+			cell:     "- Audit Columns, Soft Delete",
+			expected: []string{"id", "name", "deleted_at"},
+		},
+		{
+			caseName: "success:none",
+			opts:     []tdconv.ParseOption{tdconv.CommonGroupsPos(2, "C")},
+			groups:   []group{{name: "audit", rows: audit}},
+			cell:     "none",
+			expected: []string{"id", "name"},
+		},
+		{
+			caseName: "success:table common groups take precedence over the cell",
+			opts: []tdconv.ParseOption{
+				tdconv.CommonGroupsPos(2, "C"),
+				tdconv.TableCommonGroups(map[string][]string{"users": {"soft_delete"}}),
+			},
+			groups: []group{
+				{name: "audit", rows: audit},
+				{name: "soft_delete", rows: softDelete, opts: []tdconv.CommonOption{tdconv.CommonOptIn()}},
+			},
+			cell:     "none",
+			expected: []string{"id", "name", "created_at", "updated_at", "deleted_at"},
+		},
+		{
+			caseName: "success:overlap",
+			groups: []group{
+				{name: "audit", rows: audit},
+				{name: "tenant", rows: tenant},
+				{name: "names", rows: [][]interface{}{row(t, "1", "name", "TEXT", "no", "no", "no", "no", "", "")}},
+			},
+			expected: []string{"id", "name", "created_at", "updated_at", "tenant_id"},
+			overlaps: []overlap{{"users", "updated_at", "tenant"}, {"users", "name", "names"}},
+		},
+		{
+			caseName: "failure:unknown group",
+			opts:     []tdconv.ParseOption{tdconv.CommonGroupsPos(2, "C")},
+			groups:   []group{{name: "audit", rows: audit}},
+			cell:     "-audit, foo",
+			errMsg:   "Unknown common group (foo)",
+		},
+		{
+			caseName: "failure:duplicated group",
+			groups:   []group{{name: "audit", rows: audit}, {name: "audit", rows: softDelete}},
+			errMsg:   "The common group is already set (audit)",
+		},
+		{
+			caseName: "failure:invalid group name",
+			groups:   []group{{name: "-audit", rows: audit}},
+			errMsg:   "Invalid common group name (-audit)",
+		},
+		{
+			caseName: "failure:group name with comma",
+			groups:   []group{{name: "audit,tenant", rows: audit}},
+			errMsg:   "Invalid common group name (audit,tenant)",
+		},
+		{
+			caseName: "failure:group name with surrounding spaces",
+			groups:   []group{{name: " audit", rows: audit}},
+			errMsg:   "Invalid common group name ( audit)",
+		},
+		{
+			caseName: "failure:invalid placement",
+			groups:   []group{{name: "audit", rows: audit, opts: []tdconv.CommonOption{tdconv.CommonPlacement(2)}}},
+			errMsg:   "Unknown placement (2)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			var overlaps []overlap
+			opts := append(c.opts, tdconv.OverlapReport(func(table, column, group string) {
+				overlaps = append(overlaps, overlap{table, column, group})
+			}))
+			p := mustNewParser(opts...)
+
+			err := func() error {
+				for _, g := range c.groups {
+					if err := p.AddCommonColumns(g.name, sheet(t, p, "", g.rows...), g.opts...); err != nil {
+						return err
+					}
+				}
+				s := tdconv.SheetValues{
+					{},
+					{"", "", "users"},
+					{"", "", c.cell},
+					{},
+					row(t, "1", "id", "INT", "yes", "yes", "no", "no", "", ""),
+					row(t, "2", "name", "VARCHAR(32)", "no", "yes", "no", "no", "", ""),
+				}
+				tb, err := p.Parse(s)
+				if err != nil {
+					return err
+				}
+				var names []string
+				for _, clm := range tb.Columns {
+					names = append(names, clm.Name)
+				}
+				if !reflect.DeepEqual(names, c.expected) {
+					t.Errorf("columns don't match (expected=%v, actual=%v)", c.expected, names)
+				}
+				return nil
+			}()
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+				if !reflect.DeepEqual(overlaps, c.overlaps) {
+					t.Errorf("overlaps don't match (expected=%v, actual=%v)", c.overlaps, overlaps)
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if err.Error() != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
 func TestParser_Parse(t *testing.T) {
 
 	cases := []struct {
@@ -307,6 +512,25 @@ func TestParser_Parse(t *testing.T) {
 				IndexKeys:   []tdconv.Key{{Name: "key_bar", Columns: []string{"bar"}}},
 			},
 		},
+		{
+			caseName:  "success:change key name template",
+			p:         mustNewParser(tdconv.KeyNameTemplate("idx_{{ upper . }}")),
+			tableName: "sample_table",
+			rows: [][]interface{}{
+				row(t, "1", "id", "INT UNSIGNED", "yes", "yes", "no", "no", "AUTO_INCREMENT", "this is id!"),
+				row(t, "2", "bar", "VARCHAR(32)", "no", "no", "no", "yes", "", ""),
+			},
+			expected: &tdconv.Table{
+				Name: "sample_table",
+				Columns: []tdconv.Column{
+					{Name: "id", Type: "INT UNSIGNED", PKey: true, NotNull: true, Unique: false, Index: false, Option: "AUTO_INCREMENT", Comment: "this is id!", IsCommon: false},
+					{Name: "bar", Type: "VARCHAR(32)", PKey: false, NotNull: false, Unique: false, Index: true, Option: "", Comment: "", IsCommon: false},
+				},
+				PKeyColumns: []string{"id"},
+				UniqueKeys:  nil, // single unique key is not stored in this slice
+				IndexKeys:   []tdconv.Key{{Name: "idx_BAR", Columns: []string{"bar"}}},
+			},
+		},
 		{
 			caseName:  "success:common columns",
 			p:         mustNewParser(),
@@ -354,6 +578,16 @@ func TestParser_Parse(t *testing.T) {
 			rows:      [][]interface{}{},
 			errMsg:    "The length of table columns must not be zero",
 		},
+		{
+			caseName:  "failure:key name template",
+			p:         mustNewParser(tdconv.KeyNameTemplate("idx_{{ index . 5 }}")),
+			tableName: "sample_table",
+			rows: [][]interface{}{
+				row(t, "1", "id", "INT UNSIGNED", "yes", "yes", "no", "no", "AUTO_INCREMENT", "this is id!"),
+				row(t, "2", "bar", "VARCHAR(32)", "no", "no", "no", "yes", "", ""),
+			},
+			errMsg: "Unable to execute key name template (column=bar)",
+		},
 	}
 
 	for _, c := range cases {
@@ -396,6 +630,96 @@ func TestParser_Parse(t *testing.T) {
 	}
 }
 
+func TestParser_IsTable(t *testing.T) {
+
+	cases := []struct {
+		caseName  string
+		p         *tdconv.Parser
+		tableName string
+		rows      [][]interface{}
+		expected  bool
+	}{
+		{
+			caseName: "nil parser",
+			p:        nil,
+			expected: false,
+		},
+		{
+			caseName:  "table",
+			p:         mustNewParser(),
+			tableName: "sample_table",
+			rows: [][]interface{}{
+				row(t, "1", "id", "INT UNSIGNED", "yes", "yes", "no", "no", "AUTO_INCREMENT", "this is id!"),
+			},
+			expected: true,
+		},
+		{
+			caseName:  "change start row",
+			p:         mustNewParser(tdconv.StartRow(5)),
+			tableName: "sample_table",
+			rows: [][]interface{}{
+				row(t, "1", "id", "INT UNSIGNED", "yes", "yes", "no", "no", "AUTO_INCREMENT", "this is id!"),
+			},
+			expected: true,
+		},
+		{
+			caseName:  "no table name",
+			p:         mustNewParser(),
+			tableName: "",
+			rows: [][]interface{}{
+				row(t, "1", "id", "INT UNSIGNED", "yes", "yes", "no", "no", "AUTO_INCREMENT", "this is id!"),
+			},
+			expected: false,
+		},
+		{
+			caseName:  "no columns",
+			p:         mustNewParser(),
+			tableName: "README",
+			rows:      [][]interface{}{{"this sheet describes the tables."}},
+			expected:  false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			if actual := c.p.IsTable(sheet(t, c.p, c.tableName, c.rows...)); actual != c.expected {
+				t.Errorf("value doesn't match (expected=%t, actual=%t)", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSheetValues_Value(t *testing.T) {
+
+	s := tdconv.SheetValues{
+		{"a", "b"},
+		{},
+		{1, true},
+	}
+
+	cases := []struct {
+		caseName string
+		row, clm int
+		expected string
+	}{
+		{caseName: "string", row: 0, clm: 1, expected: "b"},
+		{caseName: "number", row: 2, clm: 0, expected: "1"},
+		{caseName: "bool", row: 2, clm: 1, expected: "true"},
+		{caseName: "empty row", row: 1, clm: 0, expected: ""},
+		{caseName: "row out of range", row: 3, clm: 0, expected: ""},
+		{caseName: "column out of range", row: 0, clm: 2, expected: ""},
+		{caseName: "negative", row: -1, clm: -1, expected: ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			if v := s.Value(c.row, c.clm); v != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, v)
+			}
+		})
+	}
+}
+
 func sheet(t *testing.T, p *tdconv.Parser, tableName string, rows ...[]interface{}) *gsheets.Sheet {
 	t.Helper()
 	var header [][]interface{}
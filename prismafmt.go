@@ -0,0 +1,216 @@
+package tdconv
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// PrismaFormatter is a formatter to output the table definision as Prisma schema (`schema.prisma`).
+type PrismaFormatter struct {
+	formatter
+	provider string
+}
+
+// NewPrismaFormatter creates a new PrismaFormatter.
+// You can change some parameters of the PrismaFormatter with PrismaFormatOption.
+func NewPrismaFormatter(options ...PrismaFormatOption) (*PrismaFormatter, error) {
+
+	f := PrismaFormatter{
+		provider: "mysql",
+	}
+	f.setHeader(func(w io.Writer, _ *TableSet) {
+		prismaHeader(w, f.provider, true)
+	})
+	// the datasource and the generator are output only in the first file with multi flag,
+	// because they must be defined once in the schema folder
+	f.setMultiHeader(func(w io.Writer, _ *TableSet, i int) {
+		prismaHeader(w, f.provider, i == 0)
+	})
+	for _, opt := range options {
+		err := opt(&f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+func prismaHeader(w io.Writer, provider string, blocks bool) {
+	fmt.Fprint(w,
+		"// This file generated by tdconv. DO NOT EDIT.\n"+
+			"// See more details at https://github.com/takuoki/tdconv.\n\n")
+	if !blocks {
+		return
+	}
+	fmt.Fprintf(w,
+		"datasource db {\n  provider = %q\n  url      = env(\"DATABASE_URL\")\n}\n\n"+
+			"generator client {\n  provider = \"prisma-client-js\"\n}\n\n", provider)
+}
+
+// PrismaFormatOption changes some parameters of the PrismaFormatter.
+type PrismaFormatOption func(*PrismaFormatter) error
+
+// PrismaHeader changes the header.
+func PrismaHeader(fc func(w io.Writer, ts *TableSet)) PrismaFormatOption {
+	return func(f *PrismaFormatter) error {
+		f.setHeader(fc)
+		return nil
+	}
+}
+
+// PrismaTableHeader changes the header of each table.
+func PrismaTableHeader(fc func(w io.Writer, t *Table)) PrismaFormatOption {
+	return func(f *PrismaFormatter) error {
+		f.setTableHeader(fc)
+		return nil
+	}
+}
+
+// PrismaTableFooter changes the footer of each table.
+func PrismaTableFooter(fc func(w io.Writer, t *Table)) PrismaFormatOption {
+	return func(f *PrismaFormatter) error {
+		f.setTableFooter(fc)
+		return nil
+	}
+}
+
+// PrismaFooter changes the footer.
+func PrismaFooter(fc func(w io.Writer, ts *TableSet)) PrismaFormatOption {
+	return func(f *PrismaFormatter) error {
+		f.setFooter(fc)
+		return nil
+	}
+}
+
+// PrismaTypeMap overrides the type conversion to Prisma type.
+// The key is the base SQL type name, like `VARCHAR`, and it's case-insensitive.
+// The type which Prisma doesn't support can be mapped to `Unsupported`, like `Unsupported("geometry")`.
+func PrismaTypeMap(m map[string]string) PrismaFormatOption {
+	return func(f *PrismaFormatter) error {
+		f.setTypeMap(m)
+		return nil
+	}
+}
+
+var prismaProviders = []string{"postgresql", "mysql", "sqlite", "sqlserver", "mongodb", "cockroachdb"}
+
+// PrismaProvider changes the provider of the datasource in the default header.
+// The default provider is `mysql`.
+func PrismaProvider(p string) PrismaFormatOption {
+	return func(f *PrismaFormatter) error {
+		for _, pp := range prismaProviders {
+			if p == pp {
+				f.provider = p
+				return nil
+			}
+		}
+		return fmt.Errorf("Unknown datasource provider (%s)", p)
+	}
+}
+
+// Extension returns the extension of Prisma schema file.
+func (f *PrismaFormatter) Extension() string {
+	return "prisma"
+}
+
+// Fprint outputs the table definision as Prisma model.
+// The snake_case names are mapped to the camel case names with `@map` and `@@map`.
+func (f *PrismaFormatter) Fprint(w io.Writer, t *Table) {
+
+	if f == nil || t == nil {
+		return
+	}
+
+	compositePK := len(t.PKeyColumns) > 1
+
+	fmt.Fprintf(w, "model %s {\n", strcase.ToCamel(t.Name))
+	for _, c := range t.Columns {
+		if c.Comment != "" {
+			for _, l := range strings.Split(c.Comment, "\n") {
+				fmt.Fprintf(w, "  /// %s\n", l)
+			}
+		}
+		typ := f.convType(c.Type, convPrismaType)
+		if strings.HasPrefix(typ, "Unsupported(") && !f.isMapped(c.Type) {
+			ReportError(w, typeError("Prisma", t, c))
+		}
+		if !c.NotNull && !c.PKey {
+			typ += "?"
+		}
+		s := fmt.Sprintf("  %s %s", prismaFieldName(c.Name), typ)
+		if c.PKey && !compositePK {
+			s += " @id"
+		}
+		if strings.Contains(strings.ToUpper(c.Option), "AUTO_INCREMENT") {
+			s += " @default(autoincrement())"
+		}
+		if c.Unique {
+			s += " @unique"
+		}
+		if prismaFieldName(c.Name) != c.Name {
+			s += fmt.Sprintf(" @map(%q)", c.Name)
+		}
+		fmt.Fprintln(w, s)
+	}
+
+	var attrs []string
+	if compositePK {
+		attrs = append(attrs, fmt.Sprintf("@@id([%s])", prismaFieldNames(t.PKeyColumns)))
+	}
+	for _, k := range t.UniqueKeys {
+		attrs = append(attrs, fmt.Sprintf("@@unique([%s], map: %q)", prismaFieldNames(k.Columns), k.Name))
+	}
+	for _, k := range t.IndexKeys {
+		attrs = append(attrs, fmt.Sprintf("@@index([%s], map: %q)", prismaFieldNames(k.Columns), k.Name))
+	}
+	if strcase.ToCamel(t.Name) != t.Name {
+		attrs = append(attrs, fmt.Sprintf("@@map(%q)", t.Name))
+	}
+	if len(attrs) > 0 {
+		fmt.Fprintln(w)
+		for _, a := range attrs {
+			fmt.Fprintf(w, "  %s\n", a)
+		}
+	}
+
+	fmt.Fprintln(w, "}")
+}
+
+func convPrismaType(t string) string {
+	st := parseSQLType(t)
+	var r string
+	switch st.name {
+	case "INT", "TINYINT", "SMALLINT", "MEDIUMINT":
+		r = "Int"
+	case "BIGINT":
+		r = "BigInt"
+	case "DECIMAL":
+		r = "Decimal"
+	case "FLOAT", "DOUBLE":
+		r = "Float"
+	case "CHAR", "VARCHAR", "TEXT", "ENUM":
+		r = "String"
+	case "BOOLEAN":
+		r = "Boolean"
+	case "TIMESTAMP", "DATETIME", "DATE", "TIME":
+		r = "DateTime"
+	default:
+		r = fmt.Sprintf("Unsupported(%q)", strings.TrimSpace(t))
+	}
+	return r
+}
+
+func prismaFieldName(s string) string {
+	return strcase.ToLowerCamel(s)
+}
+
+func prismaFieldNames(cs []string) string {
+	ns := make([]string, 0, len(cs))
+	for _, c := range cs {
+		ns = append(ns, prismaFieldName(c))
+	}
+	return strings.Join(ns, ", ")
+}
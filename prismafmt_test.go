@@ -0,0 +1,248 @@
+package tdconv_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+var mustPrismaFormatter = func(options ...tdconv.PrismaFormatOption) *tdconv.PrismaFormatter {
+	f, err := tdconv.NewPrismaFormatter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func TestNewPrismaFormatter(t *testing.T) {
+
+	errOptionFunc := func(*tdconv.PrismaFormatter) error {
+		return errors.New("error")
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.PrismaFormatOption
+		errMsg   string
+	}{
+		{
+			caseName: "success: default",
+		},
+		{
+			caseName: "success: set all options",
+			opts: []tdconv.PrismaFormatOption{
+				tdconv.PrismaHeader(nil),
+				tdconv.PrismaTableHeader(nil),
+				tdconv.PrismaTableFooter(nil),
+				tdconv.PrismaFooter(nil),
+				tdconv.PrismaTypeMap(map[string]string{"json": "string"}),
+				tdconv.PrismaProvider("sqlite"),
+			},
+		},
+		{
+			caseName: "failure: unknown provider",
+			opts:     []tdconv.PrismaFormatOption{tdconv.PrismaProvider("oracle")},
+			errMsg:   "Unknown datasource provider (oracle)",
+		},
+		{
+			caseName: "failure: option error",
+			opts:     []tdconv.PrismaFormatOption{errOptionFunc},
+			errMsg:   "error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.NewPrismaFormatter(c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if endIndex := strings.Index(err.Error(), ":"); endIndex < 0 {
+					if err.Error() != c.errMsg {
+						t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+						return
+					}
+				} else if err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestPrismaFormatter_Extension(t *testing.T) {
+	var f *tdconv.PrismaFormatter
+	if f.Extension() != "prisma" {
+		t.Errorf("value doesn't match (expected=prisma, actual=%s)", f.Extension())
+	}
+}
+
+func TestPrismaFormatter_Header(t *testing.T) {
+
+	expected := "// This file generated by tdconv. DO NOT EDIT.\n" +
+		"// See more details at https://github.com/takuoki/tdconv.\n\n" +
+		"datasource db {\n  provider = \"postgresql\"\n  url      = env(\"DATABASE_URL\")\n}\n\n" +
+		"generator client {\n  provider = \"prisma-client-js\"\n}\n\n"
+
+	b := &bytes.Buffer{}
+	mustPrismaFormatter(tdconv.PrismaProvider("postgresql")).Header(b, &tdconv.TableSet{Name: "sample"})
+
+	if b.String() != expected {
+		t.Errorf("value doesn't match (expected=%s, actual=%s)", expected, b.String())
+	}
+}
+
+func TestPrismaFormatter_multi(t *testing.T) {
+
+	ts := &tdconv.TableSet{
+		Name: "sample_table_set",
+		Tables: []*tdconv.Table{
+			{Name: "sample_table_1", Columns: []tdconv.Column{{Name: "id", Type: "INT", PKey: true}}},
+			{Name: "sample_table_2", Columns: []tdconv.Column{{Name: "id", Type: "INT", PKey: true}}},
+		},
+	}
+	fs := tdconv.NewMemoryFileSystem()
+	if err := tdconv.Output(mustPrismaFormatter(), ts, true, "out", tdconv.OutputFileSystem(fs)); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+
+	cases := []struct {
+		caseName string
+		file     string
+		expected int
+	}{
+		{caseName: "first file", file: "out/sample_table_1.prisma", expected: 1},
+		{caseName: "other file", file: "out/sample_table_2.prisma", expected: 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			b, ok := fs.Files()[c.file]
+			if !ok {
+				t.Fatalf("file must be output (%s)", c.file)
+			}
+			s := string(b)
+			if !strings.HasPrefix(s, "// This file generated by tdconv. DO NOT EDIT.\n") {
+				t.Errorf("file must start with the generated comment: %s", s)
+			}
+			for _, block := range []string{"datasource db {", "generator client {"} {
+				if n := strings.Count(s, block); n != c.expected {
+					t.Errorf("number of %q doesn't match (expected=%d, actual=%d)", block, c.expected, n)
+				}
+			}
+			if !strings.Contains(s, "@@map(\"sample_table_") {
+				t.Errorf("file must have the model: %s", s)
+			}
+		})
+	}
+}
+
+func TestPrismaFormatter_Fprint(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.PrismaFormatter
+		t        *tdconv.Table
+		expected string
+		errMsg   string
+	}{
+		{
+			caseName: "nil formatter",
+			f:        nil,
+			t:        docTestTable,
+			expected: "",
+		},
+		{
+			caseName: "table is nil",
+			f:        mustPrismaFormatter(),
+			t:        nil,
+			expected: "",
+		},
+		{
+			caseName: "standard output",
+			f:        mustPrismaFormatter(),
+			t:        docTestTable,
+			expected: "model SampleTable {\n" +
+				"  /// this is id!\n" +
+				"  id Int @id @default(autoincrement())\n" +
+				"  /// a|b\n" +
+				"  foo String @unique\n" +
+				"  /// <bar>\n" +
+				"  bar String?\n" +
+				"  createdAt DateTime? @map(\"created_at\")\n" +
+				"\n" +
+				"  @@unique([foo, bar], map: \"foo_bar_key\")\n" +
+				"  @@index([bar], map: \"bar_key\")\n" +
+				"  @@map(\"sample_table\")\n" +
+				"}\n",
+		},
+		{
+			caseName: "composite primary key",
+			f:        mustPrismaFormatter(),
+			t: &tdconv.Table{
+				Name: "user_roles",
+				Columns: []tdconv.Column{
+					{Name: "user_id", Type: "INT", PKey: true, NotNull: true},
+					{Name: "type", Type: "VARCHAR(32)", PKey: true, NotNull: true},
+					{Name: "geom", Type: "GEOMETRY"},
+				},
+				PKeyColumns: []string{"user_id", "type"},
+			},
+			expected: "model UserRoles {\n" +
+				"  userId Int @map(\"user_id\")\n" +
+				"  type String\n" +
+				"  geom Unsupported(\"GEOMETRY\")?\n" +
+				"\n" +
+				"  @@id([userId, type])\n" +
+				"  @@map(\"user_roles\")\n" +
+				"}\n",
+			errMsg: "Unable to convert the type to Prisma (table=user_roles, column=geom, type=GEOMETRY)",
+		},
+		{
+			caseName: "type map to unsupported",
+			f:        mustPrismaFormatter(tdconv.PrismaTypeMap(map[string]string{"GEOMETRY": `Unsupported("geometry")`})),
+			t: &tdconv.Table{
+				Name:        "places",
+				Columns:     []tdconv.Column{{Name: "id", Type: "INT", PKey: true, NotNull: true}, {Name: "geom", Type: "GEOMETRY", NotNull: true}},
+				PKeyColumns: []string{"id"},
+			},
+			expected: "model Places {\n" +
+				"  id Int @id\n" +
+				"  geom Unsupported(\"geometry\")\n" +
+				"\n" +
+				"  @@map(\"places\")\n" +
+				"}\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			err := tdconv.FprintTable(b, c.f, nil, c.t)
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+				}
+			} else if err == nil || err.Error() != c.errMsg {
+				t.Errorf("error message doesn't match (expected=%s, actual=%v)", c.errMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,409 @@
+package tdconv
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// ProtoFormatter is a formatter to output the table definision as Protocol Buffers (proto3) message.
+type ProtoFormatter struct {
+	formatter
+	pkg, goPackage string
+	lock           ProtoFieldNumbers
+}
+
+// ProtoFieldNumbers is a set of the field numbers for each message.
+// The key is the message name.
+// This is used to keep the field numbers stable across regenerations.
+type ProtoFieldNumbers map[string]*ProtoMessageNumbers
+
+// ProtoMessageNumbers is a set of the field numbers of a message.
+type ProtoMessageNumbers struct {
+	Fields   map[string]int `json:"fields"`
+	Reserved []int          `json:"reserved,omitempty"`
+}
+
+// NewProtoFormatter creates a new ProtoFormatter.
+// You can change some parameters of the ProtoFormatter with ProtoFormatOption.
+func NewProtoFormatter(options ...ProtoFormatOption) (*ProtoFormatter, error) {
+
+	f := ProtoFormatter{
+		lock: ProtoFieldNumbers{},
+	}
+	f.setHeader(func(w io.Writer, ts *TableSet) {
+		f.fprintHeader(w, ts, ts.Tables)
+	})
+	// the imports are decided by the table in the file with multi flag, because unused imports cause warnings
+	f.setMultiHeader(func(w io.Writer, ts *TableSet, i int) {
+		f.fprintHeader(w, ts, ts.Tables[i:i+1])
+	})
+	for _, opt := range options {
+		err := opt(&f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+func (f *ProtoFormatter) fprintHeader(w io.Writer, ts *TableSet, tables []*Table) {
+	fmt.Fprint(w,
+		"// This file generated by tdconv. DO NOT EDIT.\n"+
+			"// See more details at https://github.com/takuoki/tdconv.\n"+
+			"syntax = \"proto3\";\n\n")
+	pkg := f.pkg
+	if pkg == "" {
+		pkg = strcase.ToSnake(ts.Name)
+	}
+	fmt.Fprintf(w, "package %s;\n\n", pkg)
+	if f.goPackage != "" {
+		fmt.Fprintf(w, "option go_package = %q;\n\n", f.goPackage)
+	}
+	var timestamp, wrappers bool
+	for _, t := range tables {
+		for _, c := range t.Columns {
+			typ := f.protoType(c)
+			timestamp = timestamp || typ == "google.protobuf.Timestamp"
+			wrappers = wrappers || strings.HasSuffix(typ, "Value")
+		}
+	}
+	if timestamp {
+		fmt.Fprintln(w, "import \"google/protobuf/timestamp.proto\";")
+	}
+	if wrappers {
+		fmt.Fprintln(w, "import \"google/protobuf/wrappers.proto\";")
+	}
+	if timestamp || wrappers {
+		fmt.Fprintln(w)
+	}
+}
+
+// ProtoFormatOption changes some parameters of the ProtoFormatter.
+type ProtoFormatOption func(*ProtoFormatter) error
+
+// ProtoHeader changes the header.
+func ProtoHeader(fc func(w io.Writer, ts *TableSet)) ProtoFormatOption {
+	return func(f *ProtoFormatter) error {
+		f.setHeader(fc)
+		return nil
+	}
+}
+
+// ProtoTableHeader changes the header of each table.
+func ProtoTableHeader(fc func(w io.Writer, t *Table)) ProtoFormatOption {
+	return func(f *ProtoFormatter) error {
+		f.setTableHeader(fc)
+		return nil
+	}
+}
+
+// ProtoTableFooter changes the footer of each table.
+func ProtoTableFooter(fc func(w io.Writer, t *Table)) ProtoFormatOption {
+	return func(f *ProtoFormatter) error {
+		f.setTableFooter(fc)
+		return nil
+	}
+}
+
+// ProtoFooter changes the footer.
+func ProtoFooter(fc func(w io.Writer, ts *TableSet)) ProtoFormatOption {
+	return func(f *ProtoFormatter) error {
+		f.setFooter(fc)
+		return nil
+	}
+}
+
+// ProtoTypeMap overrides the type conversion to Protocol Buffers type.
+// The key is the base SQL type name, like `VARCHAR`, and it's case-insensitive.
+func ProtoTypeMap(m map[string]string) ProtoFormatOption {
+	return func(f *ProtoFormatter) error {
+		f.setTypeMap(m)
+		return nil
+	}
+}
+
+var protoPackageRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)*$`)
+
+// ProtoPackage changes the package name.
+// By default, the snake case of the table set name is used.
+func ProtoPackage(pkg string) ProtoFormatOption {
+	return func(f *ProtoFormatter) error {
+		if !protoPackageRegexp.MatchString(pkg) {
+			return fmt.Errorf("Invalid package name (%s)", pkg)
+		}
+		f.pkg = pkg
+		return nil
+	}
+}
+
+// ProtoGoPackage sets the `go_package` option.
+func ProtoGoPackage(goPackage string) ProtoFormatOption {
+	return func(f *ProtoFormatter) error {
+		f.goPackage = goPackage
+		return nil
+	}
+}
+
+// ProtoFieldNumberLock sets the field numbers of the previous generation.
+// Fields which already exist keep the same numbers, new fields get numbers which have never been used,
+// and the numbers of removed fields are reserved.
+func ProtoFieldNumberLock(n ProtoFieldNumbers) ProtoFormatOption {
+	return func(f *ProtoFormatter) error {
+		for k, v := range n {
+			f.lock[k] = v
+		}
+		return nil
+	}
+}
+
+// Extension returns the extension of Protocol Buffers file.
+func (f *ProtoFormatter) Extension() string {
+	return "proto"
+}
+
+// FieldNumbers returns the field numbers used in the output of the table set.
+// The messages in the lock which are not in the table set are kept.
+// Save them with WriteProtoFieldNumbers to keep the field numbers stable.
+func (f *ProtoFormatter) FieldNumbers(ts *TableSet) ProtoFieldNumbers {
+	if f == nil {
+		return nil
+	}
+	n := ProtoFieldNumbers{}
+	for k, v := range f.lock {
+		n[k] = v
+	}
+	if ts != nil {
+		for _, t := range ts.Tables {
+			n[strcase.ToCamel(t.Name)] = f.messageNumbers(t)
+		}
+	}
+	return n
+}
+
+// messageNumbers returns the field numbers of the message for the table, based on the lock.
+func (f *ProtoFormatter) messageNumbers(t *Table) *ProtoMessageNumbers {
+
+	prev := f.lock[strcase.ToCamel(t.Name)]
+	if prev == nil {
+		prev = &ProtoMessageNumbers{}
+	}
+	next := &ProtoMessageNumbers{Fields: map[string]int{}}
+
+	max := 0
+	for _, n := range prev.Fields {
+		if n > max {
+			max = n
+		}
+	}
+	for _, n := range prev.Reserved {
+		if n > max {
+			max = n
+		}
+	}
+
+	for _, c := range t.Columns {
+		field := strcase.ToSnake(c.Name)
+		n, ok := prev.Fields[field]
+		if !ok {
+			max++
+			n = max
+		}
+		next.Fields[field] = n
+	}
+
+	next.Reserved = append(next.Reserved, prev.Reserved...)
+	for field, n := range prev.Fields {
+		if _, ok := next.Fields[field]; !ok {
+			next.Reserved = append(next.Reserved, n)
+		}
+	}
+	sort.Ints(next.Reserved)
+
+	return next
+}
+
+// Fprint outputs the table definision as Protocol Buffers message.
+func (f *ProtoFormatter) Fprint(w io.Writer, t *Table) {
+
+	if f == nil || t == nil {
+		return
+	}
+
+	numbers := f.messageNumbers(t)
+
+	fmt.Fprintf(w, "message %s {\n", strcase.ToCamel(t.Name))
+	for _, c := range t.Columns {
+		field := strcase.ToSnake(c.Name)
+		if c.Comment != "" {
+			for _, l := range strings.Split(c.Comment, "\n") {
+				fmt.Fprintf(w, "  // %s\n", l)
+			}
+		}
+		typ := f.protoType(c)
+		if typ == "UNKNOWN" {
+			ReportError(w, typeError("Protocol Buffers", t, c))
+		}
+		fmt.Fprintf(w, "  %s %s = %d;\n", typ, field, numbers.Fields[field])
+	}
+
+	if len(numbers.Reserved) > 0 {
+		ns := make([]string, 0, len(numbers.Reserved))
+		for _, n := range numbers.Reserved {
+			ns = append(ns, strconv.Itoa(n))
+		}
+		fmt.Fprintf(w, "  reserved %s;\n", strings.Join(ns, ", "))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// protoType converts the column type using the type mapping if mapped.
+func (f *ProtoFormatter) protoType(c Column) string {
+	return f.convType(c.Type, func(string) string { return convProtoType(c) })
+}
+
+func convProtoType(c Column) string {
+	var r string
+	unsigned := strings.Contains(strings.ToUpper(c.Type), "UNSIGNED")
+	switch parseSQLType(c.Type).name {
+	case "INT", "TINYINT", "SMALLINT", "MEDIUMINT":
+		r = "int32"
+		if unsigned {
+			r = "uint32"
+		}
+	case "BIGINT":
+		r = "int64"
+		if unsigned {
+			r = "uint64"
+		}
+	case "DOUBLE", "DECIMAL":
+		r = "double"
+	case "FLOAT":
+		r = "float"
+	case "CHAR", "VARCHAR", "TEXT", "ENUM":
+		r = "string"
+	case "BOOLEAN":
+		r = "bool"
+	case "BLOB", "BINARY", "VARBINARY":
+		r = "bytes"
+	case "TIMESTAMP", "DATETIME", "DATE", "TIME":
+		return "google.protobuf.Timestamp"
+	default:
+		return "UNKNOWN"
+	}
+	if !c.NotNull && !c.PKey {
+		r = "google.protobuf." + protoWrappers[r]
+	}
+	return r
+}
+
+var protoWrappers = map[string]string{
+	"int32":  "Int32Value",
+	"uint32": "UInt32Value",
+	"int64":  "Int64Value",
+	"uint64": "UInt64Value",
+	"double": "DoubleValue",
+	"float":  "FloatValue",
+	"string": "StringValue",
+	"bool":   "BoolValue",
+	"bytes":  "BytesValue",
+}
+
+// ReadProtoFieldNumbers reads the field numbers from the lock file written by WriteProtoFieldNumbers.
+func ReadProtoFieldNumbers(r io.Reader) (ProtoFieldNumbers, error) {
+	n := ProtoFieldNumbers{}
+	if err := json.NewDecoder(r).Decode(&n); err != nil {
+		return nil, fmt.Errorf("Unable to decode field numbers: %v", err)
+	}
+	return n, nil
+}
+
+// WriteProtoFieldNumbers writes the field numbers as the lock file.
+func WriteProtoFieldNumbers(w io.Writer, n ProtoFieldNumbers) error {
+	b, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Unable to encode field numbers: %v", err)
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+var (
+	protoMessageRegexp  = regexp.MustCompile(`^message\s+(\w+)\s*{$`)
+	protoFieldRegexp    = regexp.MustCompile(`^(?:(?:repeated|optional)\s+)?[\w.]+\s+(\w+)\s*=\s*(\d+)\s*(?:\[.*\])?\s*;$`)
+	protoReservedRegexp = regexp.MustCompile(`^reserved\s+(.+);$`)
+)
+
+// ParseProtoFieldNumbers reads the field numbers from the previous .proto file.
+// Only top-level messages are read.
+func ParseProtoFieldNumbers(r io.Reader) (ProtoFieldNumbers, error) {
+
+	n := ProtoFieldNumbers{}
+	var msg *ProtoMessageNumbers
+	depth := 0
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case depth == 0 && protoMessageRegexp.MatchString(line):
+			name := protoMessageRegexp.FindStringSubmatch(line)[1]
+			msg = &ProtoMessageNumbers{Fields: map[string]int{}}
+			n[name] = msg
+			depth = 1
+		case depth == 1 && protoFieldRegexp.MatchString(line):
+			m := protoFieldRegexp.FindStringSubmatch(line)
+			i, _ := strconv.Atoi(m[2])
+			msg.Fields[m[1]] = i
+		case depth == 1 && protoReservedRegexp.MatchString(line):
+			for _, v := range strings.Split(protoReservedRegexp.FindStringSubmatch(line)[1], ",") {
+				v = strings.TrimSpace(v)
+				if strings.HasPrefix(v, "\"") {
+					continue
+				}
+				rng := strings.Fields(v)
+				if len(rng) == 3 && rng[1] == "to" {
+					from, err1 := strconv.Atoi(rng[0])
+					to, err2 := strconv.Atoi(rng[2])
+					if err1 != nil || err2 != nil {
+						return nil, fmt.Errorf("Invalid reserved range (%s)", v)
+					}
+					for i := from; i <= to; i++ {
+						msg.Reserved = append(msg.Reserved, i)
+					}
+					continue
+				}
+				i, err := strconv.Atoi(v)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid reserved number (%s)", v)
+				}
+				msg.Reserved = append(msg.Reserved, i)
+			}
+		default:
+			if depth > 0 {
+				depth += strings.Count(line, "{") - strings.Count(line, "}")
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to read proto file: %v", err)
+	}
+	if depth != 0 {
+		return nil, errors.New("Unexpected end of proto file")
+	}
+
+	return n, nil
+}
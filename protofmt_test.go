@@ -0,0 +1,373 @@
+package tdconv_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/takuoki/gostr"
+	"github.com/takuoki/tdconv"
+)
+
+var mustProtoFormatter = func(options ...tdconv.ProtoFormatOption) *tdconv.ProtoFormatter {
+	f, err := tdconv.NewProtoFormatter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func TestNewProtoFormatter(t *testing.T) {
+
+	errOptionFunc := func(*tdconv.ProtoFormatter) error {
+		return errors.New("error")
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.ProtoFormatOption
+		errMsg   string
+	}{
+		{
+			caseName: "success: default",
+		},
+		{
+			caseName: "success: set all options",
+			opts: []tdconv.ProtoFormatOption{
+				tdconv.ProtoHeader(nil),
+				tdconv.ProtoTableHeader(nil),
+				tdconv.ProtoTableFooter(nil),
+				tdconv.ProtoFooter(nil),
+				tdconv.ProtoTypeMap(map[string]string{"json": "string"}),
+				tdconv.ProtoPackage("foo.bar"),
+				tdconv.ProtoGoPackage("github.com/foo/bar"),
+				tdconv.ProtoFieldNumberLock(nil),
+			},
+		},
+		{
+			caseName: "failure: invalid package",
+			opts:     []tdconv.ProtoFormatOption{tdconv.ProtoPackage("foo-bar")},
+			errMsg:   "Invalid package name (foo-bar)",
+		},
+		{
+			caseName: "failure: option error",
+			opts:     []tdconv.ProtoFormatOption{errOptionFunc},
+			errMsg:   "error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.NewProtoFormatter(c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if endIndex := strings.Index(err.Error(), ":"); endIndex < 0 {
+					if err.Error() != c.errMsg {
+						t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+						return
+					}
+				} else if err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestProtoFormatter_Extension(t *testing.T) {
+	var f *tdconv.ProtoFormatter
+	if f.Extension() != "proto" {
+		t.Errorf("value doesn't match (expected=proto, actual=%s)", f.Extension())
+	}
+}
+
+func TestProtoFormatter_Header(t *testing.T) {
+
+	ts := &tdconv.TableSet{
+		Name: "SampleTableSet",
+		Tables: []*tdconv.Table{
+			{
+				Name: "sample_table",
+				Columns: []tdconv.Column{
+					{Name: "id", Type: "INT UNSIGNED", PKey: true, NotNull: true},
+					{Name: "foo", Type: "VARCHAR(32)"},
+					{Name: "created_at", Type: "TIMESTAMP NULL"},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.ProtoFormatter
+		expected string
+	}{
+		{
+			caseName: "default",
+			f:        mustProtoFormatter(),
+			expected: "// This file generated by tdconv. DO NOT EDIT.\n" +
+				"// See more details at https://github.com/takuoki/tdconv.\n" +
+				"syntax = \"proto3\";\n\n" +
+				"package sample_table_set;\n\n" +
+				"import \"google/protobuf/timestamp.proto\";\n" +
+				"import \"google/protobuf/wrappers.proto\";\n\n",
+		},
+		{
+			caseName: "package and go_package",
+			f:        mustProtoFormatter(tdconv.ProtoPackage("foo.v1"), tdconv.ProtoGoPackage("github.com/foo/v1;foo")),
+			expected: "// This file generated by tdconv. DO NOT EDIT.\n" +
+				"// See more details at https://github.com/takuoki/tdconv.\n" +
+				"syntax = \"proto3\";\n\n" +
+				"package foo.v1;\n\n" +
+				"option go_package = \"github.com/foo/v1;foo\";\n\n" +
+				"import \"google/protobuf/timestamp.proto\";\n" +
+				"import \"google/protobuf/wrappers.proto\";\n\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			c.f.Header(b, ts)
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestProtoFormatter_Fprint(t *testing.T) {
+
+	table := &tdconv.Table{
+		Name: "sample_table",
+		Columns: []tdconv.Column{
+			{Name: "id", Type: "INT UNSIGNED", PKey: true, NotNull: true, Unique: false, Index: false, Option: "AUTO_INCREMENT", Comment: "this is id!", IsCommon: false},
+			{Name: "foo", Type: "VARCHAR(32)", PKey: false, NotNull: true, Unique: true, Index: false, Option: "", Comment: "foo is\nmulti-line", IsCommon: false},
+			{Name: "bar", Type: "BIGINT", PKey: false, NotNull: false, Unique: false, Index: true, Option: "", Comment: "", IsCommon: false},
+			{Name: "baz", Type: "LONGBLOB", PKey: false, NotNull: true, Unique: false, Index: false, Option: "", Comment: "", IsCommon: false},
+			{Name: "created_at", Type: "TIMESTAMP NULL", PKey: false, NotNull: false, Unique: false, Index: false, Option: "DEFAULT CURRENT_TIMESTAMP", Comment: "", IsCommon: true},
+		},
+		PKeyColumns: []string{"id"},
+		IndexKeys:   []tdconv.Key{{Name: "bar_key", Columns: []string{"bar"}}},
+	}
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.ProtoFormatter
+		t        *tdconv.Table
+		expected string
+		numbers  tdconv.ProtoFieldNumbers
+	}{
+		{
+			caseName: "nil formatter",
+			f:        nil,
+			t:        table,
+			expected: "",
+		},
+		{
+			caseName: "table is nil",
+			f:        mustProtoFormatter(),
+			t:        nil,
+			expected: "",
+			numbers:  tdconv.ProtoFieldNumbers{},
+		},
+		{
+			caseName: "standard output",
+			f:        mustProtoFormatter(),
+			t:        table,
+			expected: "message SampleTable {\n" +
+				"  // this is id!\n" +
+				"  uint32 id = 1;\n" +
+				"  // foo is\n" +
+				"  // multi-line\n" +
+				"  string foo = 2;\n" +
+				"  google.protobuf.Int64Value bar = 3;\n" +
+				"  UNKNOWN baz = 4;\n" +
+				"  google.protobuf.Timestamp created_at = 5;\n" +
+				"}\n",
+			numbers: tdconv.ProtoFieldNumbers{
+				"SampleTable": {Fields: map[string]int{"id": 1, "foo": 2, "bar": 3, "baz": 4, "created_at": 5}},
+			},
+		},
+		{
+			caseName: "field number lock",
+			f: mustProtoFormatter(tdconv.ProtoFieldNumberLock(tdconv.ProtoFieldNumbers{
+				"SampleTable": {Fields: map[string]int{"id": 1, "bar": 2, "qux": 3, "created_at": 4}, Reserved: []int{5}},
+			})),
+			t: table,
+			expected: "message SampleTable {\n" +
+				"  // this is id!\n" +
+				"  uint32 id = 1;\n" +
+				"  // foo is\n" +
+				"  // multi-line\n" +
+				"  string foo = 6;\n" +
+				"  google.protobuf.Int64Value bar = 2;\n" +
+				"  UNKNOWN baz = 7;\n" +
+				"  google.protobuf.Timestamp created_at = 4;\n" +
+				"  reserved 3, 5;\n" +
+				"}\n",
+			numbers: tdconv.ProtoFieldNumbers{
+				"SampleTable": {Fields: map[string]int{"id": 1, "foo": 6, "bar": 2, "baz": 7, "created_at": 4}, Reserved: []int{3, 5}},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			c.f.Fprint(b, c.t)
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+			ts := &tdconv.TableSet{}
+			if c.t != nil {
+				ts.Tables = []*tdconv.Table{c.t}
+			}
+			if n := c.f.FieldNumbers(ts); !reflect.DeepEqual(n, c.numbers) {
+				t.Errorf("field numbers don't match (expected=%s, actual=%s)", gostr.Stringify(c.numbers), gostr.Stringify(n))
+			}
+		})
+	}
+}
+
+func TestProtoFormatter_multi(t *testing.T) {
+
+	ts := &tdconv.TableSet{
+		Name: "sample_table_set",
+		Tables: []*tdconv.Table{
+			{Name: "users", Columns: []tdconv.Column{{Name: "id", Type: "INT", NotNull: true}, {Name: "created_at", Type: "TIMESTAMP", NotNull: true}}},
+			{Name: "posts", Columns: []tdconv.Column{{Name: "id", Type: "INT", NotNull: true}, {Name: "body", Type: "TEXT"}}},
+		},
+	}
+
+	fs := tdconv.NewMemoryFileSystem()
+	if err := tdconv.Output(mustProtoFormatter(), ts, true, "out", tdconv.OutputFileSystem(fs)); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+
+	// the imports are decided by the table in each file
+	cases := []struct {
+		file                string
+		timestamp, wrappers bool
+	}{
+		{file: "out/users.proto", timestamp: true, wrappers: false},
+		{file: "out/posts.proto", timestamp: false, wrappers: true},
+	}
+	for _, c := range cases {
+		b, ok := fs.Files()[c.file]
+		if !ok {
+			t.Fatalf("file must be output (%s)", c.file)
+		}
+		if actual := strings.Contains(string(b), "import \"google/protobuf/timestamp.proto\";"); actual != c.timestamp {
+			t.Errorf("import of timestamp doesn't match (file=%s, expected=%t, actual=%t)", c.file, c.timestamp, actual)
+		}
+		if actual := strings.Contains(string(b), "import \"google/protobuf/wrappers.proto\";"); actual != c.wrappers {
+			t.Errorf("import of wrappers doesn't match (file=%s, expected=%t, actual=%t)", c.file, c.wrappers, actual)
+		}
+	}
+}
+
+func TestProtoFieldNumbers(t *testing.T) {
+
+	n := tdconv.ProtoFieldNumbers{
+		"SampleTable": {Fields: map[string]int{"id": 1, "foo": 3}, Reserved: []int{2}},
+	}
+
+	b := &bytes.Buffer{}
+	if err := tdconv.WriteProtoFieldNumbers(b, n); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	actual, err := tdconv.ReadProtoFieldNumbers(b)
+	if err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	if !reflect.DeepEqual(actual, n) {
+		t.Errorf("value doesn't match (expected=%s, actual=%s)", gostr.Stringify(n), gostr.Stringify(actual))
+	}
+
+	if _, err := tdconv.ReadProtoFieldNumbers(strings.NewReader("{")); err == nil {
+		t.Errorf("error must occur")
+	}
+}
+
+func TestParseProtoFieldNumbers(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		proto    string
+		expected tdconv.ProtoFieldNumbers
+		errMsg   string
+	}{
+		{
+			caseName: "success",
+			proto: "syntax = \"proto3\";\n" +
+				"package foo;\n" +
+				"message SampleTable {\n" +
+				"  // this is id!\n" +
+				"  uint32 id = 1;\n" +
+				"  google.protobuf.StringValue foo = 6; // comment\n" +
+				"  message Nested {\n" +
+				"    string nested = 1;\n" +
+				"  }\n" +
+				"  repeated string bar = 2 [deprecated = true];\n" +
+				"  reserved 3, 8 to 9, \"old\";\n" +
+				"}\n" +
+				"message Other {\n" +
+				"}\n",
+			expected: tdconv.ProtoFieldNumbers{
+				"SampleTable": {Fields: map[string]int{"id": 1, "foo": 6, "bar": 2}, Reserved: []int{3, 8, 9}},
+				"Other":       {Fields: map[string]int{}},
+			},
+		},
+		{
+			caseName: "failure: invalid reserved",
+			proto:    "message SampleTable {\n  reserved a;\n}\n",
+			errMsg:   "Invalid reserved number (a)",
+		},
+		{
+			caseName: "failure: unexpected end",
+			proto:    "message SampleTable {\n  string foo = 1;\n",
+			errMsg:   "Unexpected end of proto file",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			n, err := tdconv.ParseProtoFieldNumbers(strings.NewReader(c.proto))
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+				if !reflect.DeepEqual(n, c.expected) {
+					t.Errorf("value doesn't match (expected=%s, actual=%s)", gostr.Stringify(c.expected), gostr.Stringify(n))
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if err.Error() != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+				}
+			}
+		})
+	}
+}
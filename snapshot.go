@@ -0,0 +1,332 @@
+package tdconv
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SnapshotVersion is the version of the snapshot format written by this package.
+const SnapshotVersion = 1
+
+// SnapshotEncoding is the encoding of the snapshot.
+type SnapshotEncoding int
+
+// Snapshot encodings.
+const (
+	SnapshotJSON SnapshotEncoding = iota
+	SnapshotYAML
+)
+
+// Extension returns the file extension of the encoding.
+func (e SnapshotEncoding) Extension() string {
+	if e == SnapshotYAML {
+		return "yaml"
+	}
+	return "json"
+}
+
+// SnapshotEncodingOf returns the encoding of the snapshot file from the extension (.json, .yaml or .yml).
+func SnapshotEncodingOf(name string) (SnapshotEncoding, error) {
+	switch filepath.Ext(name) {
+	case ".json":
+		return SnapshotJSON, nil
+	case ".yaml", ".yml":
+		return SnapshotYAML, nil
+	}
+	return 0, fmt.Errorf("Unknown snapshot extension (%s)", name)
+}
+
+// snapshot is the serialized form of TableSet.
+type snapshot struct {
+	Version int             `json:"version" yaml:"version"`
+	Name    string          `json:"name" yaml:"name"`
+	Tables  []snapshotTable `json:"tables" yaml:"tables"`
+}
+
+type snapshotTable struct {
+	Name       string           `json:"name" yaml:"name"`
+	Columns    []snapshotColumn `json:"columns" yaml:"columns"`
+	PrimaryKey []string         `json:"primary_key,omitempty" yaml:"primary_key,omitempty"`
+	UniqueKeys []snapshotKey    `json:"unique_keys,omitempty" yaml:"unique_keys,omitempty"`
+	IndexKeys  []snapshotKey    `json:"index_keys,omitempty" yaml:"index_keys,omitempty"`
+}
+
+type snapshotColumn struct {
+	Name       string `json:"name" yaml:"name"`
+	Type       string `json:"type" yaml:"type"`
+	PrimaryKey bool   `json:"primary_key,omitempty" yaml:"primary_key,omitempty"`
+	NotNull    bool   `json:"not_null,omitempty" yaml:"not_null,omitempty"`
+	Unique     bool   `json:"unique,omitempty" yaml:"unique,omitempty"`
+	Index      bool   `json:"index,omitempty" yaml:"index,omitempty"`
+	Option     string `json:"option,omitempty" yaml:"option,omitempty"`
+	Comment    string `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Common     bool   `json:"common,omitempty" yaml:"common,omitempty"`
+}
+
+type snapshotKey struct {
+	Name    string   `json:"name" yaml:"name"`
+	Columns []string `json:"columns" yaml:"columns"`
+}
+
+func newSnapshot(ts *TableSet) *snapshot {
+	s := &snapshot{
+		Version: SnapshotVersion,
+		Name:    ts.Name,
+		Tables:  make([]snapshotTable, 0, len(ts.Tables)),
+	}
+	for _, t := range ts.Tables {
+		if t == nil {
+			continue
+		}
+		st := snapshotTable{
+			Name:       t.Name,
+			Columns:    make([]snapshotColumn, 0, len(t.Columns)),
+			PrimaryKey: t.PKeyColumns,
+			UniqueKeys: snapshotKeys(t.UniqueKeys),
+			IndexKeys:  snapshotKeys(t.IndexKeys),
+		}
+		for _, c := range t.Columns {
+			st.Columns = append(st.Columns, snapshotColumn{
+				Name:       c.Name,
+				Type:       c.Type,
+				PrimaryKey: c.PKey,
+				NotNull:    c.NotNull,
+				Unique:     c.Unique,
+				Index:      c.Index,
+				Option:     c.Option,
+				Comment:    c.Comment,
+				Common:     c.IsCommon,
+			})
+		}
+		s.Tables = append(s.Tables, st)
+	}
+	return s
+}
+
+func snapshotKeys(ks []Key) []snapshotKey {
+	if len(ks) == 0 {
+		return nil
+	}
+	sks := make([]snapshotKey, 0, len(ks))
+	for _, k := range ks {
+		sks = append(sks, snapshotKey{Name: k.Name, Columns: k.Columns})
+	}
+	return sks
+}
+
+func (s *snapshot) tableSet() *TableSet {
+	ts := &TableSet{
+		Name:   s.Name,
+		Tables: make([]*Table, 0, len(s.Tables)),
+	}
+	for _, st := range s.Tables {
+		t := &Table{
+			Name:        st.Name,
+			Columns:     make([]Column, 0, len(st.Columns)),
+			PKeyColumns: st.PrimaryKey,
+			UniqueKeys:  keys(st.UniqueKeys),
+			IndexKeys:   keys(st.IndexKeys),
+		}
+		for _, c := range st.Columns {
+			t.Columns = append(t.Columns, Column{
+				Name:     c.Name,
+				Type:     c.Type,
+				PKey:     c.PrimaryKey,
+				NotNull:  c.NotNull,
+				Unique:   c.Unique,
+				Index:    c.Index,
+				Option:   c.Option,
+				Comment:  c.Comment,
+				IsCommon: c.Common,
+			})
+		}
+		ts.Tables = append(ts.Tables, t)
+	}
+	return ts
+}
+
+func keys(sks []snapshotKey) []Key {
+	if len(sks) == 0 {
+		return nil
+	}
+	ks := make([]Key, 0, len(sks))
+	for _, k := range sks {
+		ks = append(ks, Key{Name: k.Name, Columns: k.Columns})
+	}
+	return ks
+}
+
+// WriteSnapshot writes the table set as the snapshot, which is the versioned serialization of TableSet.
+func WriteSnapshot(w io.Writer, ts *TableSet, enc SnapshotEncoding) error {
+
+	if ts == nil {
+		return errors.New("Table set is nil")
+	}
+
+	s := newSnapshot(ts)
+
+	var b []byte
+	var err error
+	switch enc {
+	case SnapshotJSON:
+		b, err = json.MarshalIndent(s, "", "  ")
+		b = append(b, '\n')
+	case SnapshotYAML:
+		b, err = yaml.Marshal(s)
+	default:
+		return fmt.Errorf("Unknown snapshot encoding (%d)", enc)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to marshal snapshot: %v", err)
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+// ReadSnapshot reads the snapshot written by WriteSnapshot.
+// The unknown fields and the newer version than SnapshotVersion are treated as an error.
+func ReadSnapshot(r io.Reader, enc SnapshotEncoding) (*TableSet, error) {
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read snapshot: %v", err)
+	}
+
+	s := &snapshot{}
+	switch enc {
+	case SnapshotJSON:
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.DisallowUnknownFields()
+		err = d.Decode(s)
+	case SnapshotYAML:
+		err = yaml.UnmarshalStrict(b, s)
+	default:
+		return nil, fmt.Errorf("Unknown snapshot encoding (%d)", enc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to unmarshal snapshot: %v", err)
+	}
+
+	if s.Version <= 0 {
+		return nil, errors.New("Snapshot version is required")
+	}
+	if s.Version > SnapshotVersion {
+		return nil, fmt.Errorf("Unsupported snapshot version (%d)", s.Version)
+	}
+
+	return s.tableSet(), nil
+}
+
+// SaveSnapshot saves the table set as the snapshot file.
+// The encoding is decided by the extension (.json, .yaml or .yml).
+func SaveSnapshot(name string, ts *TableSet) error {
+
+	enc, err := SnapshotEncodingOf(name)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	if err := WriteSnapshot(&b, ts, enc); err != nil {
+		return err
+	}
+
+	_, err = writeFile(OSFileSystem{}, name, b.Bytes())
+	return err
+}
+
+// LoadSnapshot loads the snapshot file saved by SaveSnapshot.
+// The encoding is decided by the extension (.json, .yaml or .yml).
+func LoadSnapshot(name string) (*TableSet, error) {
+
+	enc, err := SnapshotEncodingOf(name)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open snapshot file: %v", err)
+	}
+	defer file.Close()
+
+	ts, err := ReadSnapshot(file, enc)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load snapshot file (%s): %v", name, err)
+	}
+	return ts, nil
+}
+
+// SnapshotFormatter is a formatter to output the table definision as the snapshot.
+// It collects the tables in a file, and outputs them in the footer.
+// The header and footer can't be changed, because the snapshot must be valid JSON or YAML.
+type SnapshotFormatter struct {
+	formatter
+	encoding SnapshotEncoding
+}
+
+// NewSnapshotFormatter creates a new SnapshotFormatter.
+// You can change some parameters of the SnapshotFormatter with SnapshotFormatOption.
+func NewSnapshotFormatter(options ...SnapshotFormatOption) (*SnapshotFormatter, error) {
+
+	f := SnapshotFormatter{}
+	for _, opt := range options {
+		err := opt(&f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+// SnapshotFormatOption changes some parameters of the SnapshotFormatter.
+type SnapshotFormatOption func(*SnapshotFormatter) error
+
+// SnapshotFormat changes the encoding of the snapshot. The default is SnapshotJSON.
+func SnapshotFormat(enc SnapshotEncoding) SnapshotFormatOption {
+	return func(f *SnapshotFormatter) error {
+		if enc != SnapshotJSON && enc != SnapshotYAML {
+			return fmt.Errorf("Unknown snapshot encoding (%d)", enc)
+		}
+		f.encoding = enc
+		return nil
+	}
+}
+
+// Extension returns the extension of the snapshot file.
+func (f *SnapshotFormatter) Extension() string {
+	if f == nil {
+		return SnapshotJSON.Extension()
+	}
+	return f.encoding.Extension()
+}
+
+// Fprint outputs nothing, because the tables in the file are output in the footer.
+func (f *SnapshotFormatter) Fprint(w io.Writer, t *Table) {}
+
+// Separator outputs nothing between the tables.
+func (f *SnapshotFormatter) Separator(w io.Writer) {}
+
+// Footer outputs the snapshot of the tables in the file.
+// If the writer is not passed by Output or FprintTableSet, all tables in the table set are output.
+func (f *SnapshotFormatter) Footer(w io.Writer, ts *TableSet) {
+	if f == nil || ts == nil {
+		return
+	}
+	tables := ts.Tables
+	if tableSetOf(w) != nil {
+		tables = fileTablesOf(w)
+	}
+	if err := WriteSnapshot(w, &TableSet{Name: ts.Name, Tables: tables}, f.encoding); err != nil {
+		ReportError(w, err)
+	}
+}
@@ -0,0 +1,274 @@
+package tdconv_test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+var snapshotTableSet = &tdconv.TableSet{
+	Name: "sample",
+	Tables: []*tdconv.Table{
+		{
+			Name: "users",
+			Columns: []tdconv.Column{
+				{Name: "id", Type: "INT", PKey: true, NotNull: true, Option: "AUTO_INCREMENT", Comment: "this is id!"},
+				{Name: "name", Type: "VARCHAR(32)", NotNull: true, Unique: true, Index: true},
+				{Name: "created_at", Type: "TIMESTAMP", IsCommon: true},
+			},
+			PKeyColumns: []string{"id"},
+			UniqueKeys:  []tdconv.Key{{Name: "name_uk", Columns: []string{"name"}}},
+			IndexKeys:   []tdconv.Key{{Name: "name_key", Columns: []string{"name"}}},
+		},
+		{
+			Name: "logs",
+			Columns: []tdconv.Column{
+				{Name: "message", Type: "TEXT"},
+			},
+		},
+	},
+}
+
+var mustSnapshotFormatter = func(options ...tdconv.SnapshotFormatOption) *tdconv.SnapshotFormatter {
+	f, err := tdconv.NewSnapshotFormatter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func TestWriteSnapshot(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		enc      tdconv.SnapshotEncoding
+		expected string
+	}{
+		{
+			caseName: "json",
+			enc:      tdconv.SnapshotJSON,
+			expected: "{\n" +
+				"  \"version\": 1,\n" +
+				"  \"name\": \"sample\",\n" +
+				"  \"tables\": [\n" +
+				"    {\n" +
+				"      \"name\": \"logs\",\n" +
+				"      \"columns\": [\n" +
+				"        {\n" +
+				"          \"name\": \"message\",\n" +
+				"          \"type\": \"TEXT\"\n" +
+				"        }\n" +
+				"      ]\n" +
+				"    }\n" +
+				"  ]\n" +
+				"}\n",
+		},
+		{
+			caseName: "yaml",
+			enc:      tdconv.SnapshotYAML,
+			expected: "version: 1\n" +
+				"name: sample\n" +
+				"tables:\n" +
+				"- name: logs\n" +
+				"  columns:\n" +
+				"  - name: message\n" +
+				"    type: TEXT\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			ts := &tdconv.TableSet{Name: "sample", Tables: snapshotTableSet.Tables[1:]}
+			if err := tdconv.WriteSnapshot(b, ts, c.enc); err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestReadSnapshot(t *testing.T) {
+
+	for _, enc := range []tdconv.SnapshotEncoding{tdconv.SnapshotJSON, tdconv.SnapshotYAML} {
+		t.Run("round trip: "+enc.Extension(), func(t *testing.T) {
+			b := &bytes.Buffer{}
+			if err := tdconv.WriteSnapshot(b, snapshotTableSet, enc); err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			ts, err := tdconv.ReadSnapshot(b, enc)
+			if err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			if !reflect.DeepEqual(ts, snapshotTableSet) {
+				t.Errorf("value doesn't match (expected=%+v, actual=%+v)", snapshotTableSet, ts)
+			}
+		})
+	}
+
+	cases := []struct {
+		caseName string
+		enc      tdconv.SnapshotEncoding
+		data     string
+		errMsg   string
+	}{
+		{
+			caseName: "failure: no version",
+			enc:      tdconv.SnapshotJSON,
+			data:     `{"name": "sample", "tables": []}`,
+			errMsg:   "Snapshot version is required",
+		},
+		{
+			caseName: "failure: newer version",
+			enc:      tdconv.SnapshotYAML,
+			data:     "version: 2\nname: sample\n",
+			errMsg:   "Unsupported snapshot version (2)",
+		},
+		{
+			caseName: "failure: unknown field",
+			enc:      tdconv.SnapshotJSON,
+			data:     `{"version": 1, "foo": "bar"}`,
+			errMsg:   "Unable to unmarshal snapshot",
+		},
+		{
+			caseName: "failure: unknown encoding",
+			enc:      tdconv.SnapshotEncoding(-1),
+			data:     `{"version": 1}`,
+			errMsg:   "Unknown snapshot encoding (-1)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.ReadSnapshot(strings.NewReader(c.data), c.enc)
+			if err == nil {
+				t.Fatalf("error must occur")
+			}
+			msg := err.Error()
+			if i := strings.Index(msg, ":"); i >= 0 {
+				msg = msg[:i]
+			}
+			if msg != c.errMsg {
+				t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, msg)
+			}
+		})
+	}
+}
+
+func TestSaveSnapshot(t *testing.T) {
+
+	dir := t.TempDir()
+
+	for _, name := range []string{"snapshot.json", "snapshot.yml"} {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(dir, name)
+			if err := tdconv.SaveSnapshot(file, snapshotTableSet); err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			ts, err := tdconv.LoadSnapshot(file)
+			if err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			if !reflect.DeepEqual(ts, snapshotTableSet) {
+				t.Errorf("value doesn't match (expected=%+v, actual=%+v)", snapshotTableSet, ts)
+			}
+		})
+	}
+
+	t.Run("failure: unknown extension", func(t *testing.T) {
+		err := tdconv.SaveSnapshot(filepath.Join(dir, "snapshot.txt"), snapshotTableSet)
+		if err == nil {
+			t.Fatalf("error must occur")
+		}
+	})
+}
+
+func TestNewSnapshotFormatter(t *testing.T) {
+
+	errOptionFunc := func(*tdconv.SnapshotFormatter) error {
+		return errors.New("error")
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.SnapshotFormatOption
+		errMsg   string
+	}{
+		{
+			caseName: "success: default",
+		},
+		{
+			caseName: "success: set all options",
+			opts:     []tdconv.SnapshotFormatOption{tdconv.SnapshotFormat(tdconv.SnapshotYAML)},
+		},
+		{
+			caseName: "failure: unknown encoding",
+			opts:     []tdconv.SnapshotFormatOption{tdconv.SnapshotFormat(tdconv.SnapshotEncoding(9))},
+			errMsg:   "Unknown snapshot encoding (9)",
+		},
+		{
+			caseName: "failure: option error",
+			opts:     []tdconv.SnapshotFormatOption{errOptionFunc},
+			errMsg:   "error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.NewSnapshotFormatter(c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if err.Error() != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestSnapshotFormatter_Extension(t *testing.T) {
+
+	var f *tdconv.SnapshotFormatter
+	if f.Extension() != "json" {
+		t.Errorf("value doesn't match (expected=json, actual=%s)", f.Extension())
+	}
+	if ext := mustSnapshotFormatter(tdconv.SnapshotFormat(tdconv.SnapshotYAML)).Extension(); ext != "yaml" {
+		t.Errorf("value doesn't match (expected=yaml, actual=%s)", ext)
+	}
+}
+
+func TestSnapshotFormatter_Fprint(t *testing.T) {
+
+	for _, enc := range []tdconv.SnapshotEncoding{tdconv.SnapshotJSON, tdconv.SnapshotYAML} {
+		t.Run(enc.Extension(), func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			if err := tdconv.FprintTableSet(b, mustSnapshotFormatter(tdconv.SnapshotFormat(enc)), snapshotTableSet); err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+
+			ts, err := tdconv.ReadSnapshot(b, enc)
+			if err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			if !reflect.DeepEqual(ts, snapshotTableSet) {
+				t.Errorf("value doesn't match (expected=%+v, actual=%+v)", snapshotTableSet, ts)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package tdconv
+
+import (
+	"strings"
+	"unicode"
+)
+
+// sqlType is a SQL type parsed from the type string of the column, like `VARCHAR(32)` or `ENUM('a','b')`.
+type sqlType struct {
+	name string   // base type name in upper case
+	args []string // arguments in parentheses (quotes are removed)
+}
+
+func parseSQLType(t string) sqlType {
+
+	t = strings.TrimSpace(t)
+	i := strings.IndexFunc(t, func(r rune) bool { return !unicode.IsLetter(r) })
+	if i < 0 {
+		return sqlType{name: strings.ToUpper(t)}
+	}
+	st := sqlType{name: strings.ToUpper(t[:i])}
+
+	rest := strings.TrimLeft(t[i:], " ")
+	if !strings.HasPrefix(rest, "(") {
+		return st
+	}
+
+	var arg []rune
+	var quote rune
+	for _, r := range rest[1:] {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg = append(arg, r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ',' || r == ')':
+			st.args = append(st.args, strings.TrimSpace(string(arg)))
+			arg = nil
+			if r == ')' {
+				return st
+			}
+		default:
+			arg = append(arg, r)
+		}
+	}
+	return st
+}
@@ -0,0 +1,37 @@
+package tdconv_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+func TestParseSQLType(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		t        string
+		name     string
+		args     []string
+	}{
+		{caseName: "no args", t: "int", name: "INT"},
+		{caseName: "with attribute", t: "INT UNSIGNED", name: "INT"},
+		{caseName: "length", t: "VARCHAR(32)", name: "VARCHAR", args: []string{"32"}},
+		{caseName: "precision", t: "DECIMAL (10, 2)", name: "DECIMAL", args: []string{"10", "2"}},
+		{caseName: "enum", t: "ENUM('a', 'b,c')", name: "ENUM", args: []string{"a", "b,c"}},
+		{caseName: "with null", t: "TIMESTAMP NULL", name: "TIMESTAMP"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			name, args := tdconv.ParseSQLType(c.t)
+			if name != c.name {
+				t.Errorf("name doesn't match (expected=%s, actual=%s)", c.name, name)
+			}
+			if !reflect.DeepEqual(args, c.args) {
+				t.Errorf("args don't match (expected=%v, actual=%v)", c.args, args)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package tdconv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/iancoleman/strcase"
+	"github.com/takuoki/gocase"
+)
+
+// Template file names loaded by TemplateDir.
+const (
+	TemplateHeaderFile      = "header.tmpl"
+	TemplateTableHeaderFile = "table_header.tmpl"
+	TemplateBodyFile        = "body.tmpl"
+	TemplateTableFooterFile = "table_footer.tmpl"
+	TemplateFooterFile      = "footer.tmpl"
+)
+
+// TemplateFormatter is a formatter to output the table definision using `text/template`.
+// The header and footer templates are executed with *TableSet,
+// and the table header, body and table footer templates are executed with *Table.
+// The functions returned by TemplateFuncMap are available in the templates.
+type TemplateFormatter struct {
+	formatter
+	extension string
+	funcs     template.FuncMap
+	texts     map[string]string
+	body      *template.Template
+}
+
+// NewTemplateFormatter creates a new TemplateFormatter.
+// The body template is required, so set it with TemplateBody or TemplateDir option.
+func NewTemplateFormatter(options ...TemplateFormatOption) (*TemplateFormatter, error) {
+
+	f := TemplateFormatter{
+		extension: "txt",
+		funcs:     template.FuncMap{},
+		texts:     map[string]string{},
+	}
+	for _, opt := range options {
+		err := opt(&f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := f.texts[TemplateBodyFile]; !ok {
+		return nil, errors.New("Body template must be specified")
+	}
+
+	funcs := TemplateFuncMap()
+	funcs["mapType"] = func(t string) string {
+		return f.convType(t, func(t string) string { return t })
+	}
+	for k, v := range f.funcs {
+		funcs[k] = v
+	}
+
+	parse := func(name string) (*template.Template, error) {
+		text, ok := f.texts[name]
+		if !ok {
+			return nil, nil
+		}
+		tmpl, err := template.New(name).Funcs(funcs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse template (%s): %v", name, err)
+		}
+		return tmpl, nil
+	}
+
+	for _, n := range []string{TemplateHeaderFile, TemplateFooterFile} {
+		tmpl, err := parse(n)
+		if err != nil {
+			return nil, err
+		}
+		if tmpl == nil {
+			continue
+		}
+		fc := func(w io.Writer, ts *TableSet) {
+			f.execute(tmpl, w, ts)
+		}
+		if n == TemplateHeaderFile {
+			f.setHeader(fc)
+		} else {
+			f.setFooter(fc)
+		}
+	}
+	for _, n := range []string{TemplateTableHeaderFile, TemplateTableFooterFile} {
+		tmpl, err := parse(n)
+		if err != nil {
+			return nil, err
+		}
+		if tmpl == nil {
+			continue
+		}
+		fc := func(w io.Writer, t *Table) {
+			f.execute(tmpl, w, t)
+		}
+		if n == TemplateTableHeaderFile {
+			f.setTableHeader(fc)
+		} else {
+			f.setTableFooter(fc)
+		}
+	}
+	body, err := parse(TemplateBodyFile)
+	if err != nil {
+		return nil, err
+	}
+	f.body = body
+
+	return &f, nil
+}
+
+// TemplateFormatOption changes some parameters of the TemplateFormatter.
+type TemplateFormatOption func(*TemplateFormatter) error
+
+// TemplateExtension changes the extension of the output file.
+// The default extension is `txt`.
+func TemplateExtension(ext string) TemplateFormatOption {
+	return func(f *TemplateFormatter) error {
+		ext = strings.TrimPrefix(ext, ".")
+		if ext == "" {
+			return errors.New("Extension must not be empty")
+		}
+		f.extension = ext
+		return nil
+	}
+}
+
+// TemplateHeader sets the header template.
+func TemplateHeader(text string) TemplateFormatOption {
+	return templateText(TemplateHeaderFile, text)
+}
+
+// TemplateTableHeader sets the table header template.
+func TemplateTableHeader(text string) TemplateFormatOption {
+	return templateText(TemplateTableHeaderFile, text)
+}
+
+// TemplateBody sets the body template.
+func TemplateBody(text string) TemplateFormatOption {
+	return templateText(TemplateBodyFile, text)
+}
+
+// TemplateTableFooter sets the table footer template.
+func TemplateTableFooter(text string) TemplateFormatOption {
+	return templateText(TemplateTableFooterFile, text)
+}
+
+// TemplateFooter sets the footer template.
+func TemplateFooter(text string) TemplateFormatOption {
+	return templateText(TemplateFooterFile, text)
+}
+
+func templateText(name, text string) TemplateFormatOption {
+	return func(f *TemplateFormatter) error {
+		f.texts[name] = text
+		return nil
+	}
+}
+
+// TemplateDir loads the template files in the directory.
+// The file names are `header.tmpl`, `table_header.tmpl`, `body.tmpl`, `table_footer.tmpl` and `footer.tmpl`,
+// and only `body.tmpl` is required.
+func TemplateDir(dir string) TemplateFormatOption {
+	return func(f *TemplateFormatter) error {
+		for _, n := range []string{TemplateHeaderFile, TemplateTableHeaderFile, TemplateBodyFile, TemplateTableFooterFile, TemplateFooterFile} {
+			b, err := ioutil.ReadFile(filepath.Join(dir, n))
+			if os.IsNotExist(err) && n != TemplateBodyFile {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("Unable to read template file: %v", err)
+			}
+			f.texts[n] = string(b)
+		}
+		return nil
+	}
+}
+
+// TemplateFuncs adds the functions to the templates.
+// The functions with the same name as TemplateFuncMap override them.
+func TemplateFuncs(funcs template.FuncMap) TemplateFormatOption {
+	return func(f *TemplateFormatter) error {
+		for k, v := range funcs {
+			f.funcs[k] = v
+		}
+		return nil
+	}
+}
+
+// TemplateTypeMap sets the type mapping used by `mapType` function.
+// The key is the base SQL type name, like `VARCHAR`, and it's case-insensitive.
+func TemplateTypeMap(m map[string]string) TemplateFormatOption {
+	return func(f *TemplateFormatter) error {
+		f.setTypeMap(m)
+		return nil
+	}
+}
+
+// Extension returns the extension set by TemplateExtension option.
+func (f *TemplateFormatter) Extension() string {
+	if f == nil {
+		return "txt"
+	}
+	return f.extension
+}
+
+// Fprint outputs the table definision using the body template.
+func (f *TemplateFormatter) Fprint(w io.Writer, t *Table) {
+
+	if f == nil || t == nil {
+		return
+	}
+
+	f.execute(f.body, w, t)
+}
+
+func (f *TemplateFormatter) execute(tmpl *template.Template, w io.Writer, data interface{}) {
+	if err := tmpl.Execute(w, data); err != nil {
+		ReportError(w, fmt.Errorf("Unable to execute template (%s): %v", tmpl.Name(), err))
+	}
+}
+
+// TemplateFuncMap returns the functions available in the templates of TemplateFormatter.
+//
+//   - case conversion: snake, screamingSnake, kebab, camel, lowerCamel, goCase, lower, upper
+//   - type mapping: typeName, typeArgs, goType, tsType, protoType, graphqlType, prismaType, mapType
+//   - quoting: quote, squote, backquote
+//   - strings and lists: join, replace, trimPrefix, trimSuffix, hasPrefix, hasSuffix, contains, columnNames, pkeyColumns, first, last
+//
+// `mapType` looks up the type mapping set by TemplateTypeMap option, and returns the type as is if not found.
+func TemplateFuncMap() template.FuncMap {
+	return template.FuncMap{
+		"snake":          strcase.ToSnake,
+		"screamingSnake": strcase.ToScreamingSnake,
+		"kebab":          strcase.ToKebab,
+		"camel":          strcase.ToCamel,
+		"lowerCamel":     strcase.ToLowerCamel,
+		"goCase":         gocase.To,
+		"lower":          strings.ToLower,
+		"upper":          strings.ToUpper,
+
+		"typeName":    func(t string) string { return parseSQLType(t).name },
+		"typeArgs":    func(t string) []string { return parseSQLType(t).args },
+		"goType":      convGoType,
+		"tsType":      convTSType,
+		"protoType":   func(t string) string { return convProtoType(Column{Type: t}) },
+		"graphqlType": convGraphQLType,
+		"prismaType":  convPrismaType,
+		"mapType":     func(t string) string { return t },
+
+		"quote":      strconv.Quote,
+		"squote":     func(s string) string { return "'" + strings.Replace(s, "'", "\\'", -1) + "'" },
+		"backquote":  func(s string) string { return "`" + s + "`" },
+		"join":       func(sep string, s []string) string { return strings.Join(s, sep) },
+		"replace":    func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+
+		"columnNames": columnNames,
+		"pkeyColumns": func(t *Table) []Column {
+			var cs []Column
+			for _, c := range t.Columns {
+				if c.PKey {
+					cs = append(cs, c)
+				}
+			}
+			return cs
+		},
+		"first": func(i int) bool { return i == 0 },
+		"last": func(i int, list interface{}) bool {
+			v := reflect.ValueOf(list)
+			switch v.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
+				return i == v.Len()-1
+			}
+			return false
+		},
+	}
+}
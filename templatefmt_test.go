@@ -0,0 +1,228 @@
+package tdconv_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/takuoki/tdconv"
+)
+
+var mustTemplateFormatter = func(options ...tdconv.TemplateFormatOption) *tdconv.TemplateFormatter {
+	f, err := tdconv.NewTemplateFormatter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func TestNewTemplateFormatter(t *testing.T) {
+
+	errOptionFunc := func(*tdconv.TemplateFormatter) error {
+		return errors.New("error")
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.TemplateFormatOption
+		errMsg   string
+	}{
+		{
+			caseName: "success: body only",
+			opts:     []tdconv.TemplateFormatOption{tdconv.TemplateBody("{{ .Name }}")},
+		},
+		{
+			caseName: "success: set all options",
+			opts: []tdconv.TemplateFormatOption{
+				tdconv.TemplateExtension(".rs"),
+				tdconv.TemplateHeader("header"),
+				tdconv.TemplateTableHeader("table header"),
+				tdconv.TemplateBody("body"),
+				tdconv.TemplateTableFooter("table footer"),
+				tdconv.TemplateFooter("footer"),
+				tdconv.TemplateFuncs(template.FuncMap{"foo": strings.ToLower}),
+				tdconv.TemplateTypeMap(map[string]string{"int": "i32"}),
+			},
+		},
+		{
+			caseName: "success: directory",
+			opts:     []tdconv.TemplateFormatOption{tdconv.TemplateDir("testdata/template")},
+		},
+		{
+			caseName: "failure: no body",
+			opts:     []tdconv.TemplateFormatOption{tdconv.TemplateHeader("header")},
+			errMsg:   "Body template must be specified",
+		},
+		{
+			caseName: "failure: empty extension",
+			opts:     []tdconv.TemplateFormatOption{tdconv.TemplateExtension("")},
+			errMsg:   "Extension must not be empty",
+		},
+		{
+			caseName: "failure: directory not found",
+			opts:     []tdconv.TemplateFormatOption{tdconv.TemplateDir("testdata/not_found")},
+			errMsg:   "Unable to read template file",
+		},
+		{
+			caseName: "failure: parse error",
+			opts:     []tdconv.TemplateFormatOption{tdconv.TemplateBody("{{ .Name ")},
+			errMsg:   "Unable to parse template (body.tmpl)",
+		},
+		{
+			caseName: "failure: option error",
+			opts:     []tdconv.TemplateFormatOption{errOptionFunc},
+			errMsg:   "error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.NewTemplateFormatter(c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if endIndex := strings.Index(err.Error(), ":"); endIndex < 0 {
+					if err.Error() != c.errMsg {
+						t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+						return
+					}
+				} else if err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateFormatter_Extension(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.TemplateFormatter
+		expected string
+	}{
+		{caseName: "nil formatter", f: nil, expected: "txt"},
+		{caseName: "default", f: mustTemplateFormatter(tdconv.TemplateBody("")), expected: "txt"},
+		{caseName: "set extension", f: mustTemplateFormatter(tdconv.TemplateBody(""), tdconv.TemplateExtension(".rs")), expected: "rs"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			if c.f.Extension() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, c.f.Extension())
+			}
+		})
+	}
+}
+
+func TestTemplateFormatter_Fprint(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.TemplateFormatter
+		t        *tdconv.Table
+		expected string
+		errMsg   string
+	}{
+		{
+			caseName: "nil formatter",
+			f:        nil,
+			t:        docTestTable,
+			expected: "",
+		},
+		{
+			caseName: "table is nil",
+			f:        mustTemplateFormatter(tdconv.TemplateBody("{{ .Name }}")),
+			t:        nil,
+			expected: "",
+		},
+		{
+			caseName: "template directory",
+			f:        mustTemplateFormatter(tdconv.TemplateDir("testdata/template"), tdconv.TemplateTypeMap(map[string]string{"INT": "u32", "VARCHAR": "String", "TIMESTAMP": "DateTime"})),
+			t:        docTestTable,
+			expected: "pub struct SampleTable {\n" +
+				"    pub id: u32,\n" +
+				"    pub foo: String,\n" +
+				"    pub bar: Option<String>,\n" +
+				"    pub created_at: Option<DateTime>,\n" +
+				"}\n",
+		},
+		{
+			caseName: "functions",
+			f: mustTemplateFormatter(tdconv.TemplateBody(
+				`{{ range $i, $c := .Columns }}{{ if not (first $i) }}, {{ end }}{{ goCase (camel $c.Name) }} {{ goType $c.Type }}{{ end }}` + "\n" +
+					`{{ join ", " (columnNames .Columns) }}` + "\n" +
+					`{{ range $i, $c := .Columns }}{{ backquote $c.Name }}{{ if not (last $i $.Columns) }},{{ end }}{{ end }}` + "\n" +
+					`{{ range pkeyColumns . }}{{ quote .Name }} {{ typeName .Type }}{{ end }}` + "\n" +
+					`{{ range .Columns }}{{ protoType .Type }};{{ end }}` + "\n",
+			)),
+			t: docTestTable,
+			expected: "ID *int, Foo *string, Bar *string, CreatedAt *time.Time\n" +
+				"id, foo, bar, created_at\n" +
+				"`id`,`foo`,`bar`,`created_at`\n" +
+				"\"id\" INT\n" +
+				"google.protobuf.UInt32Value;google.protobuf.StringValue;google.protobuf.StringValue;google.protobuf.Timestamp;\n",
+		},
+		{
+			caseName: "custom function",
+			f:        mustTemplateFormatter(tdconv.TemplateBody("{{ shout .Name }}"), tdconv.TemplateFuncs(template.FuncMap{"shout": func(s string) string { return strings.ToUpper(s) + "!" }})),
+			t:        docTestTable,
+			expected: "SAMPLE_TABLE!",
+		},
+		{
+			caseName: "execution error",
+			f:        mustTemplateFormatter(tdconv.TemplateBody("{{ .Foo }}")),
+			t:        docTestTable,
+			expected: "",
+			errMsg:   "Unable to execute template (body.tmpl)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			err := tdconv.FprintTable(b, c.f, nil, c.t)
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+				} else if !strings.HasPrefix(err.Error(), c.errMsg) {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateFormatter_Header(t *testing.T) {
+
+	f := mustTemplateFormatter(tdconv.TemplateDir("testdata/template"))
+	expected := "// This file generated by tdconv. DO NOT EDIT.\n// Sample Table Set\n\n"
+
+	b := &bytes.Buffer{}
+	f.Header(b, &tdconv.TableSet{Name: "Sample Table Set"})
+
+	if b.String() != expected {
+		t.Errorf("value doesn't match (expected=%s, actual=%s)", expected, b.String())
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/takuoki/tdconv/internal/fetch"
+	"github.com/urfave/cli"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+)
+
+const (
+	credentialsFile = "credentials.json"
+	tokenFile       = "token.json"
+	sheetsScope     = "https://www.googleapis.com/auth/spreadsheets.readonly"
+	// driveScope is used to get the revision of the spreadsheet for the cache.
+	driveScope = "https://www.googleapis.com/auth/drive.metadata.readonly"
+)
+
+// authFlags are the global options of the authentication.
+var authFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "credentials",
+		Value:  credentialsFile,
+		EnvVar: "TDCONV_CREDENTIALS,GOOGLE_APPLICATION_CREDENTIALS",
+		Usage:  "OAuth client ID file, or service account key file for the non-interactive use.",
+	},
+	cli.StringFlag{
+		Name:   "token",
+		Value:  tokenFile,
+		EnvVar: "TDCONV_TOKEN",
+		Usage:  "file to save the OAuth token, which is used with the OAuth client ID.",
+	},
+	cli.StringFlag{
+		Name:   "api-key",
+		EnvVar: "TDCONV_API_KEY",
+		Usage:  "API key to read the public spreadsheets without OAuth. if specified, 'credentials' option is ignored.",
+	},
+	cli.BoolFlag{
+		Name:   "non-interactive",
+		EnvVar: "TDCONV_NON_INTERACTIVE",
+		Usage:  "flag indicating whether to fail instead of the interactive OAuth flow when no token. it's also disabled when the standard input is not a terminal.",
+	},
+}
+
+// authKind is the kind of the authentication.
+type authKind int
+
+const (
+	authOAuth authKind = iota
+	authServiceAccount
+	authAPIKey
+)
+
+// auth is the authentication of Google API decided by the global options.
+type auth struct {
+	kind authKind
+	// file is the credentials file, or the token file with OAuth.
+	file string
+	// account is the email of the service account (optional).
+	account string
+	options []option.ClientOption
+}
+
+// newAuth returns the authentication with the API key, the service account key, or the OAuth client ID.
+// With the OAuth client ID, the token is read from the token file.
+// If there is no token file, the token is got from the Web interactively and saved to the token file.
+func newAuth(ctx context.Context, c *cli.Context) (*auth, error) {
+
+	if key := c.GlobalString("api-key"); key != "" {
+		return &auth{kind: authAPIKey, options: []option.ClientOption{option.WithAPIKey(key)}}, nil
+	}
+
+	name := c.GlobalString("credentials")
+	cb, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("Credentials file not found (%s). "+
+			"Put the OAuth client ID or the service account key, and specify it with 'credentials' option or TDCONV_CREDENTIALS. "+
+			"For the public spreadsheets, 'api-key' option is also available", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read credentials file (%s): %v", name, err)
+	}
+
+	var key struct {
+		Type        string          `json:"type"`
+		ClientEmail string          `json:"client_email"`
+		Installed   json.RawMessage `json:"installed"`
+		Web         json.RawMessage `json:"web"`
+	}
+	if err := json.Unmarshal(cb, &key); err != nil {
+		return nil, fmt.Errorf("Unable to parse credentials file (%s): %v", name, err)
+	}
+
+	if key.Installed == nil && key.Web == nil {
+		creds, err := google.CredentialsFromJSON(ctx, cb, sheetsScope, driveScope)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse credentials file (%s). "+
+				"It must be the OAuth client ID or the service account key: %v", name, err)
+		}
+		return &auth{
+			kind:    authServiceAccount,
+			file:    name,
+			account: key.ClientEmail,
+			options: []option.ClientOption{option.WithTokenSource(creds.TokenSource)},
+		}, nil
+	}
+
+	config, err := google.ConfigFromJSON(cb, sheetsScope, driveScope)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse client secret file to config: %v", err)
+	}
+
+	tok, err := oauthToken(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth{
+		kind:    authOAuth,
+		file:    c.GlobalString("token"),
+		options: []option.ClientOption{option.WithHTTPClient(config.Client(ctx, tok))},
+	}, nil
+}
+
+// oauthToken returns the OAuth token saved in the token file, or got from the Web interactively.
+func oauthToken(ctx context.Context, c *cli.Context, config *oauth2.Config) (*oauth2.Token, error) {
+
+	name := c.GlobalString("token")
+
+	tok := &oauth2.Token{}
+	tb, err := ioutil.ReadFile(name)
+	if err == nil {
+		if err := json.Unmarshal(tb, tok); err != nil {
+			return nil, fmt.Errorf("Unable to parse json to token (%s): %v", name, err)
+		}
+		return tok, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("Unable to read token file (%s): %v", name, err)
+	}
+
+	if c.GlobalBool("non-interactive") || !isTerminal(os.Stdin) {
+		return nil, fmt.Errorf("Token file not found (%s), and the interactive authorization is disabled. "+
+			"Authorize once in a terminal and pass the token file with 'token' option or TDCONV_TOKEN, "+
+			"or use the service account key with 'credentials' option", name)
+	}
+
+	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
+	fmt.Fprintf(os.Stderr, "Go to the following link in your browser then type the "+
+		"authorization code: \n%v\n", authURL)
+
+	var authCode string
+	if _, err := fmt.Scan(&authCode); err != nil {
+		return nil, fmt.Errorf("Unable to read authorization code: %v", err)
+	}
+
+	tok, err = config.Exchange(ctx, authCode)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve token from web: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Saving credential file to: %s\n", name)
+	if dir := filepath.Dir(name); dir != "." {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("Unable to cache oauth token: %v", err)
+		}
+	}
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to cache oauth token: %v", err)
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(tok); err != nil {
+		return nil, fmt.Errorf("Unable to cache oauth token: %v", err)
+	}
+
+	return tok, nil
+}
+
+// guide adds the guidance to the error if the spreadsheet can't be accessed,
+// or the scope is not enough to use the cache.
+func (a *auth) guide(err error) error {
+
+	if _, ok := err.(*fetch.ScopeError); ok {
+		hint := "The credentials don't have the read-only scope of Google Drive metadata, which is required for the cache."
+		if a.kind == authOAuth {
+			hint = fmt.Sprintf("The token file (%s) may be authorized before the cache is supported. "+
+				"Remove the token file and authorize again.", a.file)
+		}
+		return fmt.Errorf("%v\n%s Or use 'no-cache' option to run without the cache.", err, hint)
+	}
+
+	e, ok := err.(*fetch.AccessError)
+	if !ok {
+		return err
+	}
+
+	var hint string
+	switch a.kind {
+	case authAPIKey:
+		hint = "Check that the API key is valid, and the spreadsheet is shared with 'Anyone with the link'."
+	case authServiceAccount:
+		hint = "Check that the spreadsheet is shared with the service account"
+		if a.account != "" {
+			hint += " (" + a.account + ")"
+		}
+		hint += ", and Google Sheets API is enabled in the project of the service account."
+	default:
+		hint = "Check that your account can view the spreadsheet, and Google Sheets API is enabled in the project of the OAuth client ID."
+		if e.Code == 401 {
+			hint = fmt.Sprintf("The token may be expired or revoked. Remove the token file (%s) and authorize again.", a.file)
+		}
+	}
+	if e.Code == 404 {
+		hint = "Check the spreadsheet ID (or the alias in the configuration file). " + hint
+	}
+
+	return fmt.Errorf("%v\n%s", err, hint)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/takuoki/tdconv"
+	"github.com/takuoki/tdconv/internal/diff"
+)
+
+// check renders the files in memory, and compares them with the files in the output directory.
+// It prints the unified diff for each stale file, and returns the number of the stale files.
+func check(f tdconv.Formatter, outdir string, ts *tdconv.TableSet, multi bool, opts ...tdconv.OutputOption) (int, error) {
+
+	fs := tdconv.NewMemoryFileSystem()
+	if err := tdconv.Output(f, ts, multi, outdir, append(opts, tdconv.OutputFileSystem(fs))...); err != nil {
+		return 0, classify(failureOutput, fmt.Errorf("Fail to output table definitions: %v", err))
+	}
+	files := fs.Files()
+
+	var stale int
+	for _, name := range fs.Names() {
+		current, err := ioutil.ReadFile(name)
+		nameA := "a/" + filepath.ToSlash(name)
+		if os.IsNotExist(err) {
+			nameA = "/dev/null"
+		} else if err != nil {
+			return 0, classify(failureOutput, fmt.Errorf("Unable to read file (%s): %v", name, err))
+		}
+		if !bytes.Equal(current, files[name]) {
+			fmt.Print(diff.Unified(nameA, "b/"+filepath.ToSlash(name), current, files[name]))
+			stale++
+		}
+	}
+
+	// the files which are not generated anymore
+	err := filepath.Walk(outdir, func(name string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(name, "."+f.Extension()) {
+			return nil
+		}
+		if _, ok := files[name]; ok {
+			return nil
+		}
+		current, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		fmt.Print(diff.Unified("a/"+filepath.ToSlash(name), "/dev/null", current, nil))
+		stale++
+		return nil
+	})
+	if err != nil {
+		return 0, classify(failureOutput, fmt.Errorf("Unable to read output directory (%s): %v", outdir, err))
+	}
+
+	return stale, nil
+}
+
+// staleError returns an error with failureChanged if any file is stale.
+func staleError(stale int) error {
+
+	if stale > 0 {
+		return classify(failureChanged, fmt.Errorf("%d file(s) are stale", stale))
+	}
+
+	log.info("up to date!")
+
+	return nil
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli"
@@ -21,21 +23,136 @@ func init() {
 					fmt.Printf("There are no configuration file (%s).\n", configFile)
 					return nil
 				default:
-					return err
+					return classify(failureConfig, err)
 				}
 			}
 
-			table := tablewriter.NewWriter(os.Stdout)
-			table.SetHeader([]string{"Name", "Alias", "Spreadsheet ID"})
-			table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-			table.SetAlignment(tablewriter.ALIGN_LEFT)
-			table.SetCenterSeparator("-")
-			table.SetBorder(false)
+			// create all formatters to validate the options of the targets
+			for _, t := range conf.Targets {
+				ft, _ := findFormat(t.Format)
+				if _, err := ft.new(t.Options, conf.TypeMap(t.Format, &t)); err != nil {
+					return classify(failureConfig, fmt.Errorf("Invalid config file (%s): Unable to create formatter (%s): %v", conf.file, t.Format, err))
+				}
+			}
+
+			fmt.Printf("%s\n\n", conf.file)
+
+			table := newConfTable([]string{"Name", "Alias", "Spreadsheet ID"})
 			for _, s := range conf.Sheets {
 				table.Append([]string{s.Name, s.Alias, s.SpreadsheetID})
 			}
 			table.Render()
+
+			fmt.Println()
+			table = newConfTable([]string{"Setting", "Value"})
+			table.AppendBulk([][]string{
+				{"input.sheetid", conf.Input.SheetID},
+				{"input.sheetname", conf.Input.SheetName.String()},
+				{"input.include", conf.Input.Include.String()},
+				{"input.exclude", conf.Input.Exclude.String()},
+				{"input.skip_prefix", conf.Input.SkipPrefix.String()},
+				{"input.common", conf.Input.Common},
+				{"input.common_sheet", conf.Input.CommonSheet.String()},
+				{"input.snapshot", conf.Input.Snapshot},
+				{"parser.table_name_row", confInt(conf.Parser.TableNameRow)},
+				{"parser.table_name_column", conf.Parser.TableNameColumn},
+				{"parser.start_row", confInt(conf.Parser.StartRow)},
+				{"parser.common_row", confInt(conf.Parser.CommonRow)},
+				{"parser.common_column", conf.Parser.CommonColumn},
+				{"parser.bool_string", conf.Parser.BoolString},
+				{"parser.key_name", conf.Parser.KeyName},
+			})
+			table.Render()
+
+			if len(conf.Common.Groups) > 0 || len(conf.Common.Tables) > 0 {
+				fmt.Println()
+				table := newConfTable([]string{"Common Group", "Placement", "Opt-in", "Tables"})
+				for _, g := range conf.Common.Groups {
+					placement := g.Placement
+					if placement == "" {
+						placement = "append"
+					}
+					table.Append([]string{g.Sheet, placement, strconv.FormatBool(g.OptIn), ""})
+				}
+				tables := make([]string, 0, len(conf.Common.Tables))
+				for t := range conf.Common.Tables {
+					tables = append(tables, t)
+				}
+				sort.Strings(tables)
+				for _, t := range tables {
+					table.Append([]string{"", "", "", t + ": " + conf.Common.Tables[t].String()})
+				}
+				table.Render()
+			}
+
+			if len(conf.Types) > 0 {
+				fmt.Println()
+				table := newConfTable([]string{"Format", "SQL Type", "Type"})
+				formats := make([]string, 0, len(conf.Types))
+				for f := range conf.Types {
+					formats = append(formats, f)
+				}
+				sort.Strings(formats)
+				for _, f := range formats {
+					table.AppendBulk(typeRows(f, conf.Types[f]))
+				}
+				table.Render()
+			}
+
+			if len(conf.Targets) > 0 {
+				fmt.Println()
+				table := newConfTable([]string{"Format", "Output Dir", "Multi", "Naming", "Options", "Types"})
+				for _, t := range conf.Targets {
+					multi := "-"
+					if t.Multi != nil {
+						multi = strconv.FormatBool(*t.Multi)
+					}
+					naming := targetOptions{"filename": t.Filename, "prefix": t.Prefix, "suffix": t.Suffix}
+					for k, v := range naming {
+						if v == "" {
+							delete(naming, k)
+						}
+					}
+					types := targetOptions{}
+					for k, v := range t.Types {
+						types[k] = v
+					}
+					table.Append([]string{t.Format, t.outdir(), multi, naming.describe(), t.Options.describe(), types.describe()})
+				}
+				table.Render()
+			}
 			return nil
 		},
 	})
 }
+
+func newConfTable(header []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("-")
+	table.SetBorder(false)
+	return table
+}
+
+// confInt returns the string of the integer setting, or "" if it's the default (zero).
+func confInt(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+func typeRows(format string, m map[string]string) [][]string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	rows := make([][]string, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, []string{format, k, m[k]})
+	}
+	return rows
+}
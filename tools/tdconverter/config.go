@@ -1,17 +1,156 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/takuoki/tdconv"
+	"gopkg.in/yaml.v2"
 )
 
 type config struct {
 	Sheets []struct {
-		Name          string `json:"name"`
-		Alias         string `json:"alias"`
-		SpreadsheetID string `json:"spreadsheet_id"`
-	} `json:"sheets"`
+		Name          string `json:"name" yaml:"name"`
+		Alias         string `json:"alias" yaml:"alias"`
+		SpreadsheetID string `json:"spreadsheet_id" yaml:"spreadsheet_id"`
+	} `json:"sheets" yaml:"sheets"`
+	Input   input                        `json:"input" yaml:"input"`
+	Common  commonConfig                 `json:"common" yaml:"common"`
+	Parser  parserConfig                 `json:"parser" yaml:"parser"`
+	Types   map[string]map[string]string `json:"types" yaml:"types"`
+	Targets []target                     `json:"targets" yaml:"targets"`
+
+	// file is the name of the configuration file read.
+	file string
+}
+
+// input is the input source of the table definitions.
+// The global options take precedence over it.
+type input struct {
+	SheetID string `json:"sheetid" yaml:"sheetid"`
+	// SheetName is a sheet name or a list of the sheet names. If empty, all sheets are parsed.
+	SheetName stringList `json:"sheetname" yaml:"sheetname"`
+	// Include, Exclude and SkipPrefix select the sheets to parse (see sheetFilter).
+	Include    stringList `json:"include" yaml:"include"`
+	Exclude    stringList `json:"exclude" yaml:"exclude"`
+	SkipPrefix stringList `json:"skip_prefix" yaml:"skip_prefix"`
+	Common     string     `json:"common" yaml:"common"`
+	// CommonSheet is the sheet names of the common column groups in the common spreadsheet (see commonConfig).
+	CommonSheet stringList `json:"common_sheet" yaml:"common_sheet"`
+	// Snapshot is the snapshot file exported by `export` command, which is used instead of the spreadsheet.
+	Snapshot string `json:"snapshot" yaml:"snapshot"`
+}
+
+// commonConfig is the common column groups. Each group is a sheet of the common spreadsheet ('common' option).
+type commonConfig struct {
+	Groups []commonGroup `json:"groups" yaml:"groups"`
+	// Tables selects the groups of each table like `[audit, -soft_delete]` or `none`, which takes precedence over the cell of the sheet.
+	Tables map[string]stringList `json:"tables" yaml:"tables"`
+}
+
+// commonGroup is a common column group, which is named by the sheet name.
+type commonGroup struct {
+	Sheet string `json:"sheet" yaml:"sheet"`
+	// Placement is 'append' (default) or 'prepend'.
+	Placement string `json:"placement" yaml:"placement"`
+	// OptIn means that the group is applied only to the tables which opt in.
+	OptIn bool `json:"opt_in" yaml:"opt_in"`
+}
+
+func (g commonGroup) options() ([]tdconv.CommonOption, error) {
+
+	var opts []tdconv.CommonOption
+
+	switch g.Placement {
+	case "", "append":
+	case "prepend":
+		opts = append(opts, tdconv.CommonPlacement(tdconv.Prepend))
+	default:
+		return nil, fmt.Errorf("Unknown placement of the common group (%s): %s", g.Sheet, g.Placement)
+	}
+	if g.OptIn {
+		opts = append(opts, tdconv.CommonOptIn())
+	}
+
+	return opts, nil
+}
+
+// CommonGroups returns the common column groups of the sheet names.
+// The group in the configuration file is used if exists. If no sheet name, the groups in the configuration file,
+// or the sheet named DefaultCommonGroup are used.
+func (c *config) CommonGroups(sheets []string) []commonGroup {
+
+	var groups []commonGroup
+	if c != nil {
+		groups = c.Common.Groups
+	}
+
+	if len(sheets) > 0 {
+		gs := make([]commonGroup, 0, len(sheets))
+		for _, s := range sheets {
+			g := commonGroup{Sheet: s}
+			for _, cg := range groups {
+				if cg.Sheet == s {
+					g = cg
+				}
+			}
+			gs = append(gs, g)
+		}
+		return gs
+	}
+
+	if len(groups) == 0 {
+		return []commonGroup{{Sheet: tdconv.DefaultCommonGroup}}
+	}
+
+	return groups
+}
+
+// parserConfig is the parameters of the parser. The zero value means the default.
+type parserConfig struct {
+	TableNameRow    int    `json:"table_name_row" yaml:"table_name_row"`
+	TableNameColumn string `json:"table_name_column" yaml:"table_name_column"`
+	StartRow        int    `json:"start_row" yaml:"start_row"`
+	// CommonRow and CommonColumn are the position of the cell to select the common column groups of the table.
+	// The cell is not used if CommonColumn is empty.
+	CommonRow    int    `json:"common_row" yaml:"common_row"`
+	CommonColumn string `json:"common_column" yaml:"common_column"`
+	BoolString   string `json:"bool_string" yaml:"bool_string"`
+	// KeyName is the template to convert the column name to the key name (e.g. '{{ . }}_key').
+	KeyName string `json:"key_name" yaml:"key_name"`
+}
+
+// naming is the naming of the output files.
+// The empty fields are given by the global options.
+type naming struct {
+	Filename string `json:"filename" yaml:"filename"`
+	Prefix   string `json:"prefix" yaml:"prefix"`
+	Suffix   string `json:"suffix" yaml:"suffix"`
+}
+
+// target is an output target of `gen` command.
+type target struct {
+	Format  string            `json:"format" yaml:"format"`
+	Outdir  string            `json:"outdir" yaml:"outdir"`
+	Multi   *bool             `json:"multi" yaml:"multi"`
+	Options targetOptions     `json:"options" yaml:"options"`
+	Types   map[string]string `json:"types" yaml:"types"`
+	naming  `yaml:",inline"`
+}
+
+// outdir returns the output directory of the target. The default is `./out/<format>`.
+func (t target) outdir() string {
+	if t.Outdir != "" {
+		return t.Outdir
+	}
+	return filepath.Join("out", t.Format)
 }
 
 func (c *config) AliasMap() map[string]string {
@@ -25,7 +164,77 @@ func (c *config) AliasMap() map[string]string {
 	return m
 }
 
-const configFile = "tdconverter.json"
+// TypeMap returns the type mapping of the format.
+// The type mapping of the target (optional) takes precedence over the one of the format.
+func (c *config) TypeMap(format string, t *target) map[string]string {
+	m := map[string]string{}
+	if c != nil {
+		for k, v := range c.Types[format] {
+			m[k] = v
+		}
+	}
+	if t != nil {
+		for k, v := range t.Types {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// ParseOptions returns the options of the parser.
+func (c *config) ParseOptions() ([]tdconv.ParseOption, error) {
+	if c == nil {
+		return nil, nil
+	}
+	opts, err := c.Parser.options()
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Common.Tables) > 0 {
+		m := make(map[string][]string, len(c.Common.Tables))
+		for t, gs := range c.Common.Tables {
+			m[t] = gs
+		}
+		opts = append(opts, tdconv.TableCommonGroups(m))
+	}
+	return opts, nil
+}
+
+func (p parserConfig) options() ([]tdconv.ParseOption, error) {
+
+	var opts []tdconv.ParseOption
+
+	// the start row is set first, because the table name row must be smaller than it
+	if p.StartRow != 0 {
+		opts = append(opts, tdconv.StartRow(p.StartRow))
+	}
+	if p.TableNameRow != 0 || p.TableNameColumn != "" {
+		row, clm := p.TableNameRow, p.TableNameColumn
+		if row == 0 {
+			row = 1
+		}
+		if clm == "" {
+			clm = "C"
+		}
+		opts = append(opts, tdconv.TableNamePos(row, clm))
+	}
+	if p.CommonColumn != "" {
+		opts = append(opts, tdconv.CommonGroupsPos(p.CommonRow, p.CommonColumn))
+	}
+	if p.BoolString != "" {
+		opts = append(opts, tdconv.BoolString(p.BoolString))
+	}
+	if p.KeyName != "" {
+		opts = append(opts, tdconv.KeyNameTemplate(p.KeyName))
+	}
+
+	return opts, nil
+}
+
+// configFiles are the names of the configuration file in order of priority.
+var configFiles = []string{"tdconverter.json", "tdconverter.yaml", "tdconverter.yml"}
+
+var configFile = strings.Join(configFiles, ", ")
 
 type unableToReadConfigError struct {
 	err error
@@ -37,27 +246,129 @@ func (e *unableToReadConfigError) Error() string {
 
 func readConfig() (*config, error) {
 
-	s, err := ioutil.ReadFile(configFile)
+	var name string
+	var s []byte
+	for _, n := range configFiles {
+		b, err := ioutil.ReadFile(n)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, &unableToReadConfigError{err: err}
+		}
+		name, s = n, b
+		break
+	}
+	if name == "" {
+		return nil, &unableToReadConfigError{err: os.ErrNotExist}
+	}
+
+	conf := &config{file: name}
+	var err error
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(s, conf)
+	default:
+		d := json.NewDecoder(bytes.NewReader(s))
+		d.DisallowUnknownFields()
+		err = d.Decode(conf)
+	}
 	if err != nil {
-		return nil, &unableToReadConfigError{err: err}
+		return nil, fmt.Errorf("Unabel to marshal config file (%s): %v", name, err)
 	}
 
-	conf := &config{}
-	if err := json.Unmarshal(s, conf); err != nil {
-		return nil, fmt.Errorf("Unabel to marshal config file (%s): %v", configFile, err)
+	if err := conf.validate(); err != nil {
+		return nil, fmt.Errorf("Invalid config file (%s): %v", name, err)
 	}
 
-	// validate
+	return conf, nil
+}
+
+// readConfigIfExists reads the configuration file, and returns nil if not exist.
+func readConfigIfExists() (*config, error) {
+
+	conf, err := readConfig()
+	if err != nil {
+		switch err.(type) {
+		case *unableToReadConfigError:
+			return nil, nil
+		default:
+			return nil, classify(failureConfig, err)
+		}
+	}
+
+	return conf, nil
+}
+
+func (c *config) validate() error {
+
 	am := map[string]struct{}{}
-	for _, s := range conf.Sheets {
+	for _, s := range c.Sheets {
 		if s.SpreadsheetID == "" {
-			return nil, fmt.Errorf("SpreadsheetID must not be empty (%s)", s.Name)
+			return fmt.Errorf("SpreadsheetID must not be empty (%s)", s.Name)
 		}
 		if _, ok := am[s.Alias]; ok {
-			return nil, fmt.Errorf("Alias must not be duplicated (%s)", s.Alias)
+			return fmt.Errorf("Alias must not be duplicated (%s)", s.Alias)
 		}
 		am[s.Alias] = struct{}{}
 	}
 
-	return conf, nil
+	if c.Input.Snapshot != "" {
+		if _, err := tdconv.SnapshotEncodingOf(c.Input.Snapshot); err != nil {
+			return fmt.Errorf("Invalid input snapshot: %v", err)
+		}
+	}
+
+	if _, err := newSheetFilter(c.Input); err != nil {
+		return fmt.Errorf("Invalid input: %v", err)
+	}
+
+	opts, err := c.Parser.options()
+	if err != nil {
+		return err
+	}
+	if _, err := tdconv.NewParser(opts...); err != nil {
+		return fmt.Errorf("Invalid parser options: %v", err)
+	}
+
+	gm := map[string]struct{}{}
+	for _, g := range c.Common.Groups {
+		if g.Sheet == "" {
+			return errors.New("Sheet of the common group must not be empty")
+		}
+		if _, ok := gm[g.Sheet]; ok {
+			return fmt.Errorf("Sheet of the common group must not be duplicated (%s)", g.Sheet)
+		}
+		gm[g.Sheet] = struct{}{}
+		if _, err := g.options(); err != nil {
+			return err
+		}
+	}
+
+	for name, m := range c.Types {
+		ft, ok := findFormat(name)
+		if !ok {
+			return fmt.Errorf("Unknown format of the types (%s)", name)
+		}
+		if !ft.typeMap && len(m) > 0 {
+			return fmt.Errorf("Format doesn't support the type mapping (%s)", name)
+		}
+	}
+
+	for _, t := range c.Targets {
+		ft, ok := findFormat(t.Format)
+		if !ok {
+			return fmt.Errorf("Unknown format of the target (%s)", t.Format)
+		}
+		if !ft.typeMap && len(t.Types) > 0 {
+			return fmt.Errorf("Format doesn't support the type mapping (%s)", t.Format)
+		}
+		if t.Filename != "" {
+			if _, err := template.New("filename").Funcs(tdconv.TemplateFuncMap()).Parse(t.Filename); err != nil {
+				return fmt.Errorf("Unable to parse file name template of the target (%s): %v", t.Format, err)
+			}
+		}
+	}
+
+	return nil
 }
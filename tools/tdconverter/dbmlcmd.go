@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/takuoki/tdconv"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	formatList = append(formatList, format{
+		name:    "dbml",
+		usage:   "Converts the table definitions to DBML.",
+		typeMap: true,
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+			return tdconv.NewDBMLFormatter(tdconv.DBMLTypeMap(types))
+		},
+	}, format{
+		name:  "prisma",
+		usage: "Converts the table definitions to Prisma schema.",
+		flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "provider",
+				Value: "mysql",
+				Usage: "provider of the datasource (postgresql, mysql, sqlite, sqlserver, mongodb or cockroachdb).",
+			},
+		},
+		typeMap: true,
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+			opts := []tdconv.PrismaFormatOption{tdconv.PrismaTypeMap(types)}
+			if p := o.String("provider"); p != "" {
+				opts = append(opts, tdconv.PrismaProvider(p))
+			}
+			return tdconv.NewPrismaFormatter(opts...)
+		},
+	})
+}
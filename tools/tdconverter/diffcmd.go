@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/takuoki/tdconv"
+	"github.com/urfave/cli"
+)
+
+// cachePrefix is the prefix of the source which means the cached revision of the spreadsheet.
+const cachePrefix = "cache:"
+
+func init() {
+	cmdList = append(cmdList, cli.Command{
+		Name: "diff",
+		Usage: "Shows the added, removed and changed tables, columns and keys between two sources. " +
+			"the source is a snapshot file (.json, .yaml or .yml), a DDL file (.sql), " +
+			"'" + cachePrefix + "<spreadsheet ID>' for the cached revision, or a spreadsheet ID. " +
+			"exits with non-zero if there are differences.",
+		ArgsUsage: "<source a> <source b>",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "json",
+				Usage: "flag indicating whether to output the differences as JSON.",
+			},
+			cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "flag indicating whether to disable the colors. the colors are used only for the terminal.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+
+			if c.NArg() != 2 {
+				return errors.New("Two sources are required")
+			}
+
+			conf, err := readConfigIfExists()
+			if err != nil {
+				return err
+			}
+
+			tss, err := loadDiffSources(c, conf, c.Args())
+			if err != nil {
+				return err
+			}
+
+			d := tdconv.CompareTableSets(tss[0], tss[1])
+
+			if c.Bool("json") {
+				b, err := json.MarshalIndent(d, "", "  ")
+				if err != nil {
+					return classify(failureOutput, fmt.Errorf("Unable to marshal differences: %v", err))
+				}
+				fmt.Println(string(b))
+			} else {
+				color := !c.Bool("no-color") && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+				printDiff(os.Stdout, d, color)
+			}
+
+			if !d.Empty() {
+				added, removed, changed := d.Count()
+				return classify(failureChanged, fmt.Errorf("%d table(s) added, %d removed, %d changed", added, removed, changed))
+			}
+
+			log.info("no differences!")
+
+			return nil
+		},
+	})
+}
+
+// loadDiffSources loads the table sets from the sources.
+// The cached revisions are loaded first, because fetching the spreadsheet updates the cache.
+func loadDiffSources(c *cli.Context, conf *config, srcs []string) ([]*tdconv.TableSet, error) {
+
+	ctx := context.Background()
+	tss := make([]*tdconv.TableSet, len(srcs))
+
+	var live fetcher
+	for _, cached := range []bool{true, false} {
+		for i, src := range srcs {
+			if strings.HasPrefix(src, cachePrefix) != cached {
+				continue
+			}
+			ts, err := loadDiffSource(ctx, c, conf, src, &live)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to load source (%s): %w", src, err)
+			}
+			tss[i] = ts
+		}
+	}
+
+	return tss, nil
+}
+
+// loadDiffSource loads the table set from the source.
+// The spreadsheet is parsed with 'sheetname' and 'common' of the global options or the configuration file,
+// and live is the fetcher created on the first spreadsheet.
+func loadDiffSource(ctx context.Context, c *cli.Context, conf *config, src string, live *fetcher) (*tdconv.TableSet, error) {
+
+	switch strings.ToLower(filepath.Ext(src)) {
+	case ".json", ".yaml", ".yml":
+		return loadSnapshot(src)
+	case ".sql":
+		ts, err := tdconv.LoadDDL(src)
+		if err != nil {
+			return nil, classify(failureParse, err)
+		}
+		return ts, nil
+	}
+
+	in := inputOf(c, conf)
+	in.Snapshot = ""
+
+	if strings.HasPrefix(src, cachePrefix) {
+		in.SheetID = strings.TrimPrefix(src, cachePrefix)
+		return loadSpreadsheet(ctx, offlineFetcher(), conf, in)
+	}
+
+	if *live == nil {
+		f, err := newFetcher(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		*live = f
+	}
+
+	in.SheetID = src
+	return loadSpreadsheet(ctx, *live, conf, in)
+}
+
+// printDiff prints the differences as a tree like below.
+//
+//	~ users
+//	    ~ primary_key: id -> id, tenant_id
+//	    + email: type=VARCHAR(255), not_null=true
+//	    ~ name
+//	        type: VARCHAR(32) -> VARCHAR(64)
+//	    - nickname
+//	    + index name_key (name)
+//	+ posts
+//	- logs
+func printDiff(w io.Writer, d *tdconv.SchemaDiff, color bool) {
+
+	line := func(depth int, k tdconv.DiffKind, format string, a ...interface{}) {
+		s := strings.Repeat("    ", depth) + diffMark(k) + " " + fmt.Sprintf(format, a...)
+		if color {
+			s = diffColor(k) + s + "\x1b[0m"
+		}
+		fmt.Fprintln(w, s)
+	}
+
+	for _, t := range d.Tables {
+		line(0, t.Kind, "%s", t.Name)
+		for _, ch := range t.Changes {
+			if t.Kind == tdconv.DiffAdded {
+				line(1, t.Kind, "%s=%s", ch.Attribute, ch.To)
+				continue
+			}
+			line(1, t.Kind, "%s: %s -> %s", ch.Attribute, diffValue(ch.From), diffValue(ch.To))
+		}
+		for _, c := range t.Columns {
+			switch c.Kind {
+			case tdconv.DiffAdded:
+				attrs := make([]string, 0, len(c.Changes))
+				for _, ch := range c.Changes {
+					attrs = append(attrs, ch.Attribute+"="+ch.To)
+				}
+				line(1, c.Kind, "%s: %s", c.Name, strings.Join(attrs, ", "))
+			case tdconv.DiffRemoved:
+				line(1, c.Kind, "%s", c.Name)
+			default:
+				line(1, c.Kind, "%s", c.Name)
+				for _, ch := range c.Changes {
+					fmt.Fprintf(w, "%s%s: %s -> %s\n", strings.Repeat("    ", 2), ch.Attribute, diffValue(ch.From), diffValue(ch.To))
+				}
+			}
+		}
+		for _, k := range t.Keys {
+			switch k.Kind {
+			case tdconv.DiffAdded:
+				line(1, k.Kind, "%s %s (%s)", k.Type, k.Name, strings.Join(k.To, ", "))
+			case tdconv.DiffRemoved:
+				line(1, k.Kind, "%s %s (%s)", k.Type, k.Name, strings.Join(k.From, ", "))
+			default:
+				line(1, k.Kind, "%s %s (%s) -> (%s)", k.Type, k.Name, strings.Join(k.From, ", "), strings.Join(k.To, ", "))
+			}
+		}
+	}
+}
+
+func diffMark(k tdconv.DiffKind) string {
+	switch k {
+	case tdconv.DiffAdded:
+		return "+"
+	case tdconv.DiffRemoved:
+		return "-"
+	}
+	return "~"
+}
+
+func diffColor(k tdconv.DiffKind) string {
+	switch k {
+	case tdconv.DiffAdded:
+		return "\x1b[32m"
+	case tdconv.DiffRemoved:
+		return "\x1b[31m"
+	}
+	return "\x1b[33m"
+}
+
+// diffValue returns the value to print, which shows the empty value explicitly.
+func diffValue(s string) string {
+	if s == "" {
+		return `""`
+	}
+	return s
+}
+
+// isTerminal reports whether the file is a terminal.
+// The null device is a character device too, but it's not a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(fi, null) {
+		return false
+	}
+	return true
+}
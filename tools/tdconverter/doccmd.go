@@ -0,0 +1,21 @@
+package main
+
+import (
+	"github.com/takuoki/tdconv"
+)
+
+func init() {
+	formatList = append(formatList, format{
+		name:  "md",
+		usage: "Converts the table definitions to Markdown document.",
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+			return tdconv.NewMarkdownFormatter()
+		},
+	}, format{
+		name:  "html",
+		usage: "Converts the table definitions to HTML document.",
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+			return tdconv.NewHTMLFormatter()
+		},
+	})
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/takuoki/tdconv"
+	"github.com/urfave/cli"
+)
+
+var erdNotations = map[string]tdconv.ERDNotation{
+	"":         tdconv.ERDMermaid,
+	"mermaid":  tdconv.ERDMermaid,
+	"plantuml": tdconv.ERDPlantUML,
+	"dot":      tdconv.ERDDOT,
+}
+
+func init() {
+	formatList = append(formatList, format{
+		name:  "erd",
+		usage: "Converts the table definitions to ER diagram.",
+		flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "format",
+				Value: "mermaid",
+				Usage: "notation of the ER diagram (mermaid, plantuml or dot).",
+			},
+		},
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+			n, ok := erdNotations[o.String("format")]
+			if !ok {
+				return nil, fmt.Errorf("Unknown ER diagram format (%s)", o.String("format"))
+			}
+			return tdconv.NewERDFormatter(tdconv.ERDFormat(n))
+		},
+	})
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/takuoki/tdconv"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	formatList = append(formatList, format{
+		name:  "export",
+		usage: "Exports the table definitions as the snapshot, which is available as the input with 'snapshot' option.",
+		flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "encoding",
+				Value: "json",
+				Usage: "encoding of the snapshot (json or yaml).",
+			},
+		},
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+			var enc tdconv.SnapshotEncoding
+			switch o.String("encoding") {
+			case "", "json":
+				enc = tdconv.SnapshotJSON
+			case "yaml":
+				enc = tdconv.SnapshotYAML
+			default:
+				return nil, fmt.Errorf("Unknown snapshot encoding (%s)", o.String("encoding"))
+			}
+			return tdconv.NewSnapshotFormatter(tdconv.SnapshotFormat(enc))
+		},
+	})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/takuoki/tdconv"
+	"github.com/urfave/cli"
+)
+
+// formatOptions is the options of the format.
+// *cli.Context implements it with the command flags,
+// and targetOptions implements it with the options in the configuration file.
+type formatOptions interface {
+	String(name string) string
+	Bool(name string) bool
+}
+
+// format is an output format, which is available as a sub command and a target of `gen` command.
+type format struct {
+	name  string
+	usage string
+	flags []cli.Flag
+	// typeMap indicates whether the format supports the type mapping passed to new.
+	typeMap bool
+	new     func(o formatOptions, types map[string]string) (tdconv.Formatter, error)
+	// after is called with the output table set after the output (optional).
+	after func(o formatOptions, f tdconv.Formatter, ts *tdconv.TableSet) error
+}
+
+var formatList = []format{}
+
+func findFormat(name string) (format, bool) {
+	for _, ft := range formatList {
+		if ft.name == name {
+			return ft, true
+		}
+	}
+	return format{}, false
+}
+
+func formatCommand(ft format) cli.Command {
+	return cli.Command{
+		Name:  ft.name,
+		Usage: ft.usage,
+		Flags: ft.flags,
+		Action: func(c *cli.Context) error {
+			return run(c, ft)
+		},
+	}
+}
+
+// targetOptions is the options of the target in the configuration file.
+type targetOptions map[string]interface{}
+
+func (o targetOptions) String(name string) string {
+	v, ok := o[name]
+	if !ok || v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func (o targetOptions) Bool(name string) bool {
+	switch v := o[name].(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return false
+}
+
+func (o targetOptions) describe() string {
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ss := make([]string, 0, len(keys))
+	for _, k := range keys {
+		ss = append(ss, k+"="+o.String(k))
+	}
+	return strings.Join(ss, ", ")
+}
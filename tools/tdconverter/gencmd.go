@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/takuoki/tdconv"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	cmdList = append(cmdList, cli.Command{
+		Name: "gen",
+		Usage: "Converts the table definitions to multiple formats at once. " +
+			fmt.Sprintf("if no format is specified, the targets in the configuration file (%s) are used.", configFile),
+		ArgsUsage: "[format...]",
+		Action: func(c *cli.Context) error {
+
+			if c.GlobalBool("stdout") {
+				return errors.New("Global option 'stdout' can't be used with 'gen' command")
+			}
+
+			conf, err := readConfigIfExists()
+			if err != nil {
+				return err
+			}
+
+			targets, err := genTargets(c, conf)
+			if err != nil {
+				return err
+			}
+
+			// create all formatters before fetching the spreadsheet
+			fs, err := newTargetFormatters(conf, targets)
+			if err != nil {
+				return err
+			}
+
+			ts, err := load(c, conf)
+			if err != nil {
+				return err
+			}
+
+			var stale int
+			sum := newSummary(ts)
+			for i, t := range targets {
+				if c.GlobalBool("check") {
+					n, err := check(fs[i], t.outdir(), ts, targetMulti(c, t), outputOptions(c, t.naming)...)
+					if err != nil {
+						return err
+					}
+					stale += n
+					continue
+				}
+
+				if err := outputTarget(c, t, fs[i], ts, sum.report()); err != nil {
+					return err
+				}
+				log.info("generated", field{"format", t.Format}, field{"outdir", t.outdir()})
+			}
+
+			if c.GlobalBool("check") {
+				return staleError(stale)
+			}
+
+			sum.log("complete!")
+
+			return nil
+		},
+	})
+}
+
+// genTargets returns the targets specified with the arguments,
+// or the targets in the configuration file if no argument.
+func genTargets(c *cli.Context, conf *config) ([]target, error) {
+
+	var targets []target
+	if c.NArg() > 0 {
+		for _, name := range c.Args() {
+			targets = append(targets, target{Format: name})
+		}
+	} else if conf != nil {
+		targets = conf.Targets
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("Specify the formats, or the targets in the configuration file (%s)", configFile)
+	}
+
+	for _, t := range targets {
+		if _, ok := findFormat(t.Format); !ok {
+			return nil, fmt.Errorf("Unknown format (%s)", t.Format)
+		}
+	}
+
+	return targets, nil
+}
+
+// newTargetFormatters creates the formatters of the targets.
+func newTargetFormatters(conf *config, targets []target) ([]tdconv.Formatter, error) {
+
+	fs := make([]tdconv.Formatter, len(targets))
+	for i, t := range targets {
+		ft, _ := findFormat(t.Format)
+		f, err := ft.new(t.Options, conf.TypeMap(t.Format, &targets[i]))
+		if err != nil {
+			return nil, classify(failureConfig, fmt.Errorf("Unable to create formatter (%s): %v", t.Format, err))
+		}
+		fs[i] = f
+	}
+
+	return fs, nil
+}
+
+// targetMulti returns whether to output multiple files for the target. The target takes precedence over the global option.
+func targetMulti(c *cli.Context, t target) bool {
+	if t.Multi != nil {
+		return *t.Multi
+	}
+	return c.GlobalBool("multi")
+}
+
+// outputTarget outputs the table set to the target, and calls the after function of the format.
+func outputTarget(c *cli.Context, t target, f tdconv.Formatter, ts *tdconv.TableSet, opts ...tdconv.OutputOption) error {
+
+	if err := output(f, t.outdir(), ts, targetMulti(c, t), append(outputOptions(c, t.naming), opts...)...); err != nil {
+		return err
+	}
+
+	ft, _ := findFormat(t.Format)
+	if ft.after != nil {
+		return classify(failureOutput, ft.after(t.Options, f, ts))
+	}
+
+	return nil
+}
@@ -2,19 +2,15 @@ package main
 
 import (
 	"github.com/takuoki/tdconv"
-	"github.com/urfave/cli"
 )
 
 func init() {
-	cmdList = append(cmdList, cli.Command{
-		Name:  "go",
-		Usage: "Converts the table definitions to Go struct.",
-		Action: func(c *cli.Context) error {
-			f, err := tdconv.NewGoFormatter()
-			if err != nil {
-				return err
-			}
-			return run(c, f)
+	formatList = append(formatList, format{
+		name:    "go",
+		usage:   "Converts the table definitions to Go struct.",
+		typeMap: true,
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+			return tdconv.NewGoFormatter(tdconv.GoTypeMap(types))
 		},
 	})
 }
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"github.com/takuoki/tdconv"
+)
+
+func init() {
+	formatList = append(formatList, format{
+		name:    "gorepo",
+		usage:   "Converts the table definitions to Go repository code using database/sql.",
+		typeMap: true,
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+			return tdconv.NewGoRepositoryFormatter(tdconv.GoRepositoryTypeMap(types))
+		},
+	})
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"github.com/takuoki/tdconv"
+)
+
+func init() {
+	formatList = append(formatList, format{
+		name:    "graphql",
+		usage:   "Converts the table definitions to GraphQL SDL type.",
+		typeMap: true,
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+			return tdconv.NewGraphQLFormatter(tdconv.GraphQLTypeMap(types))
+		},
+	})
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/takuoki/tdconv"
+)
+
+func init() {
+	formatList = append(formatList, format{
+		name:    "jsonschema",
+		usage:   "Converts the table definitions to JSON Schema.",
+		typeMap: true,
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+			return tdconv.NewJSONSchemaFormatter(tdconv.JSONSchemaTypeMap(types))
+		},
+	}, format{
+		name:    "openapi",
+		usage:   "Converts the table definitions to OpenAPI components.schemas.",
+		typeMap: true,
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+			return tdconv.NewOpenAPIFormatter(tdconv.OpenAPITypeMap(types))
+		},
+	})
+}
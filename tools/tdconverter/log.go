@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/takuoki/tdconv"
+	"github.com/urfave/cli"
+)
+
+// logFlags are the global options of the logging.
+var logFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "verbose",
+		Usage: "flag indicating whether to log the details like each fetched sheet and written file.",
+	},
+	cli.BoolFlag{
+		Name:  "quiet, q",
+		Usage: "flag indicating whether to log only the errors.",
+	},
+	cli.StringFlag{
+		Name:   "log-format",
+		Value:  "text",
+		EnvVar: "TDCONV_LOG_FORMAT",
+		Usage:  "format of the logs written to the standard error (text or json).",
+	},
+}
+
+// failure is the class of the failure, which decides the exit code.
+type failure int
+
+// Failure classes. The values are the exit codes.
+const (
+	// failureChanged means that the outputs are stale with 'check' option, or there are differences with `diff` command.
+	failureChanged failure = iota + 1
+	// failureValidation means the invalid arguments or options. It's also used for the unclassified errors.
+	failureValidation
+	failureConfig
+	failureAuth
+	failureFetch
+	failureParse
+	failureOutput
+)
+
+func (f failure) String() string {
+	switch f {
+	case failureChanged:
+		return "changed"
+	case failureValidation:
+		return "validation"
+	case failureConfig:
+		return "config"
+	case failureAuth:
+		return "auth"
+	case failureFetch:
+		return "fetch"
+	case failureParse:
+		return "parse"
+	case failureOutput:
+		return "output"
+	}
+	return fmt.Sprintf("failure(%d)", int(f))
+}
+
+// classError is the error with the failure class.
+type classError struct {
+	class failure
+	err   error
+}
+
+func (e *classError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classError) Unwrap() error {
+	return e.err
+}
+
+// classify returns the error with the failure class. If the error already has a class, it's kept.
+func classify(class failure, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *classError
+	if errors.As(err, &e) {
+		return err
+	}
+	return &classError{class: class, err: err}
+}
+
+// failureOf returns the failure class of the error. The unclassified error is failureValidation,
+// because the errors from the command line parser are not classified.
+func failureOf(err error) failure {
+	var e *classError
+	if errors.As(err, &e) {
+		return e.class
+	}
+	return failureValidation
+}
+
+type logLevel int
+
+const (
+	levelError logLevel = iota
+	levelWarn
+	levelInfo
+	levelDebug
+)
+
+func (l logLevel) String() string {
+	switch l {
+	case levelError:
+		return "error"
+	case levelWarn:
+		return "warn"
+	case levelInfo:
+		return "info"
+	}
+	return "debug"
+}
+
+// field is a key-value pair of the log.
+type field struct {
+	key   string
+	value interface{}
+}
+
+// logger writes the logs to the standard error. Configure it with the global options in setupLogger.
+// In the text format, the fields follow the message like `key=value`, except for the errors.
+type logger struct {
+	w     io.Writer
+	level logLevel
+	json  bool
+}
+
+var log = &logger{w: os.Stderr, level: levelInfo}
+
+// setupLogger configures the logger with the global options.
+func setupLogger(c *cli.Context) error {
+
+	if c.GlobalBool("verbose") && c.GlobalBool("quiet") {
+		return errors.New("Global option 'verbose' can't be used with 'quiet' option")
+	}
+
+	switch {
+	case c.GlobalBool("verbose"):
+		log.level = levelDebug
+	case c.GlobalBool("quiet"):
+		log.level = levelError
+	default:
+		log.level = levelInfo
+	}
+
+	switch c.GlobalString("log-format") {
+	case "", "text":
+		log.json = false
+	case "json":
+		log.json = true
+	default:
+		return fmt.Errorf("Unknown log format (%s)", c.GlobalString("log-format"))
+	}
+
+	return nil
+}
+
+func (l *logger) log(level logLevel, msg string, fields ...field) {
+
+	if level > l.level {
+		return
+	}
+
+	if l.json {
+		m := make(map[string]interface{}, len(fields)+3)
+		for _, f := range fields {
+			m[f.key] = f.value
+		}
+		m["time"] = time.Now().Format(time.RFC3339)
+		m["level"] = level.String()
+		m["msg"] = msg
+		b, err := json.Marshal(m)
+		if err != nil {
+			b, _ = json.Marshal(map[string]string{"level": "error", "msg": err.Error()})
+		}
+		fmt.Fprintln(l.w, string(b))
+		return
+	}
+
+	ss := make([]string, 0, len(fields)+1)
+	ss = append(ss, msg)
+	if level != levelError {
+		for _, f := range fields {
+			ss = append(ss, fmt.Sprintf("%s=%v", f.key, f.value))
+		}
+	}
+	fmt.Fprintln(l.w, strings.Join(ss, " "))
+}
+
+func (l *logger) error(err error) {
+	f := failureOf(err)
+	l.log(levelError, err.Error(), field{"class", f.String()}, field{"exit_code", int(f)})
+}
+
+func (l *logger) warn(msg string, fields ...field) {
+	l.log(levelWarn, msg, fields...)
+}
+
+func (l *logger) info(msg string, fields ...field) {
+	l.log(levelInfo, msg, fields...)
+}
+
+func (l *logger) debug(msg string, fields ...field) {
+	l.log(levelDebug, msg, fields...)
+}
+
+// summary is the summary of the tables processed and the files written.
+type summary struct {
+	tables int
+	files  map[tdconv.FileStatus][]string
+}
+
+func newSummary(ts *tdconv.TableSet) *summary {
+	s := &summary{files: map[tdconv.FileStatus][]string{}}
+	if ts != nil {
+		s.tables = len(ts.Tables)
+	}
+	return s
+}
+
+// report returns the output option to collect the files.
+func (s *summary) report() tdconv.OutputOption {
+	return tdconv.OutputReport(func(name string, st tdconv.FileStatus) {
+		s.files[st] = append(s.files[st], name)
+	})
+}
+
+// logFiles logs each file of the statuses in the order of the name.
+func (s *summary) logFiles(level logLevel, statuses ...tdconv.FileStatus) {
+	for _, st := range statuses {
+		sort.Strings(s.files[st])
+		for _, name := range s.files[st] {
+			log.log(level, "file "+st.String(), field{"file", name})
+		}
+	}
+}
+
+// fields returns the fields of the summary.
+func (s *summary) fields() []field {
+	return []field{
+		{"tables", s.tables},
+		{"created", len(s.files[tdconv.FileCreated])},
+		{"updated", len(s.files[tdconv.FileUpdated])},
+		{"unchanged", len(s.files[tdconv.FileUnchanged])},
+		{"removed", len(s.files[tdconv.FileRemoved])},
+	}
+}
+
+// log logs the summary with the message. Each file is logged only with 'verbose' option.
+func (s *summary) log(msg string) {
+	s.logFiles(levelDebug, tdconv.FileCreated, tdconv.FileUpdated, tdconv.FileUnchanged, tdconv.FileRemoved)
+	log.info(msg, s.fields()...)
+}
@@ -5,13 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
-	"github.com/takuoki/gsheets"
 	"github.com/takuoki/tdconv"
+	"github.com/takuoki/tdconv/internal/fetch"
 	"github.com/urfave/cli"
 )
 
-const version = "1.0.0"
+const (
+	version      = "1.0.0"
+	manifestName = ".tdconv-manifest"
+	cacheDir     = ".tdconv-cache"
+)
 
 var (
 	cmdList = []cli.Command{}
@@ -30,162 +36,417 @@ func main() {
 			Value: "",
 			Usage: "spreadsheet ID of the table definitions sheet.",
 		},
-		cli.StringFlag{
+		cli.StringSliceFlag{
 			Name:  "sheetname, n",
-			Value: "",
-			Usage: "sheet name of the table definitions sheet. if not specified, all sheets in the spreadsheet.",
+			Usage: "sheet name of the table definitions sheet. it can be specified multiple times. if not specified, all sheets in the spreadsheet.",
 		},
 		cli.StringFlag{
 			Name:  "common, c",
 			Value: "",
 			Usage: "spreadsheet ID of the common columns sheet.",
 		},
+		cli.StringSliceFlag{
+			Name: "common-sheet",
+			Usage: "sheet name of the common column group in the common spreadsheet. it can be specified multiple times. " +
+				"if not specified, the groups in the configuration file or '" + tdconv.DefaultCommonGroup + "' sheet.",
+		},
+		cli.StringFlag{
+			Name:  "snapshot, s",
+			Value: "",
+			Usage: "snapshot file (.json, .yaml or .yml) exported by 'export' command, which is used instead of the spreadsheet.",
+		},
 		cli.BoolFlag{
 			Name:  "multi, m",
 			Usage: "flag indicating whether to output multiple files.",
 		},
+		cli.StringFlag{
+			Name: "filename",
+			Usage: "template of the output file name without the extension (e.g. '{{ snake .TableSet.Name }}/{{ snake .Name }}'). " +
+				"'.Name' is the table name with 'multi' option, or the spreadsheet title.",
+		},
+		cli.StringFlag{
+			Name:  "prefix",
+			Usage: "prefix of the output file name.",
+		},
+		cli.StringFlag{
+			Name:  "suffix",
+			Usage: "suffix of the output file name (before the extension, e.g. '_gen').",
+		},
+		cli.BoolFlag{
+			Name: "manifest",
+			Usage: "flag indicating whether to write the manifest file ('" + manifestName + "') into the output directory. " +
+				"the files listed in the previous manifest but not generated anymore are removed.",
+		},
+		cli.BoolFlag{
+			Name:  "check",
+			Usage: "flag indicating whether to check that the files in './out/<format>' are up to date without writing. if not, the diff is shown and exits with non-zero.",
+		},
+		cli.BoolFlag{
+			Name:  "offline",
+			Usage: "flag indicating whether to parse only from the cache ('" + cacheDir + "') without network access.",
+		},
+		cli.BoolFlag{
+			Name:  "no-cache",
+			Usage: "flag indicating whether to fetch all sheets without reading and writing the cache.",
+		},
+		cli.BoolFlag{
+			Name:  "stdout",
+			Usage: "flag indicating whether to output to the standard output instead of files. 'multi' option is ignored.",
+		},
 	}
 
+	app.Flags = append(app.Flags, sheetFlags...)
+	app.Flags = append(app.Flags, authFlags...)
+	app.Flags = append(app.Flags, logFlags...)
+	app.Before = setupLogger
+
 	app.Commands = cmdList
+	for _, ft := range formatList {
+		app.Commands = append(app.Commands, formatCommand(ft))
+	}
 
 	if err := app.Run(os.Args); err != nil {
-		fmt.Fprint(os.Stderr, err)
+		log.error(err)
+		os.Exit(int(failureOf(err)))
 	}
 }
 
-func run(c *cli.Context, f tdconv.Formatter) error {
+func run(c *cli.Context, ft format) error {
 
-	err := validate(c)
+	conf, err := readConfigIfExists()
 	if err != nil {
 		return err
 	}
 
-	am, err := getAliasMap()
+	f, err := ft.new(c, conf.TypeMap(ft.name, nil))
+	if err != nil {
+		return classify(failureValidation, err)
+	}
+
+	ts, err := load(c, conf)
 	if err != nil {
 		return err
 	}
 
+	if c.GlobalBool("stdout") {
+		if err := tdconv.FprintTableSet(os.Stdout, f, ts); err != nil {
+			return classify(failureOutput, fmt.Errorf("Fail to output table definitions: %v", err))
+		}
+		newSummary(ts).log("complete!")
+		return nil
+	}
+
+	outdir := filepath.Join("out", ft.name)
+
+	if c.GlobalBool("check") {
+		stale, err := check(f, outdir, ts, c.GlobalBool("multi"), outputOptions(c, naming{})...)
+		if err != nil {
+			return err
+		}
+		return staleError(stale)
+	}
+
+	sum := newSummary(ts)
+	err = output(f, outdir, ts, c.GlobalBool("multi"), append(outputOptions(c, naming{}), sum.report())...)
+	if err != nil {
+		return err
+	}
+
+	if ft.after != nil {
+		if err := ft.after(c, f, ts); err != nil {
+			return classify(failureOutput, err)
+		}
+	}
+
+	sum.log("complete!")
+
+	return nil
+}
+
+// load parses the table definitions in the spreadsheet specified with the global options,
+// or the input in the configuration file (optional).
+// If the snapshot is specified, it's loaded instead of the spreadsheet.
+func load(c *cli.Context, conf *config) (*tdconv.TableSet, error) {
+
+	in := inputOf(c, conf)
+	if in.Snapshot != "" {
+		return loadSnapshot(in.Snapshot)
+	}
+	if in.SheetID == "" {
+		return nil, classify(failureValidation, errors.New("Global option 'sheetid' or 'snapshot' is required"))
+	}
+
 	ctx := context.Background()
 
-	gc, err := gsheets.NewForCLI(ctx, "credentials.json")
+	f, err := newFetcher(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadSpreadsheet(ctx, f, conf, in)
+}
+
+// loadSnapshot loads the snapshot file.
+func loadSnapshot(name string) (*tdconv.TableSet, error) {
+
+	ts, err := tdconv.LoadSnapshot(name)
 	if err != nil {
-		return fmt.Errorf("Unable to create a google sheet client. "+
-			"Is 'credentials.json' present correctly?: %v", err)
+		return nil, classify(failureParse, err)
 	}
+	log.debug("loaded snapshot", field{"file", name}, field{"tables", len(ts.Tables)})
+
+	return ts, nil
+}
 
-	sheetid := c.GlobalString("sheetid")
+// loadSpreadsheet parses the table definitions in the spreadsheet of the input with the fetcher.
+// The spreadsheet IDs can be the aliases in the configuration file.
+func loadSpreadsheet(ctx context.Context, f fetcher, conf *config, in input) (*tdconv.TableSet, error) {
+
+	opts, err := conf.ParseOptions()
+	if err != nil {
+		return nil, classify(failureConfig, err)
+	}
+
+	am := conf.AliasMap()
+
+	sheetid := in.SheetID
 	if s, ok := am[sheetid]; ok {
 		sheetid = s
 	}
 
-	common := c.GlobalString("common")
+	common := in.Common
 	if s, ok := am[common]; ok {
 		common = s
 	}
 
-	ts, err := parse(ctx, gc, sheetid, c.GlobalString("sheetname"), common)
+	filter, err := newSheetFilter(in)
 	if err != nil {
-		return err
+		return nil, classify(failureValidation, err)
 	}
 
-	err = output(f, c.Command.Name, ts, c.GlobalBool("multi"))
-	if err != nil {
-		return err
+	var groups []commonGroup
+	if common != "" {
+		groups = conf.CommonGroups(in.CommonSheet)
 	}
 
-	fmt.Println("complete!")
-
-	return nil
+	return parse(ctx, f, sheetid, in.SheetName, filter, common, groups, opts...)
 }
 
-func validate(c *cli.Context) error {
+// fetcher returns the spreadsheet with the values of the sheets selected by fetch.SelectSheets.
+type fetcher func(ctx context.Context, id string, names []string, filter func(name string) bool) (*fetch.Spreadsheet, error)
+
+// newFetcher returns the fetcher which uses the cache, or only the cache with 'offline' option.
+func newFetcher(ctx context.Context, c *cli.Context) (fetcher, error) {
 
-	if c.GlobalString("sheetid") == "" {
-		return errors.New("Global option 'sheetid' is required")
+	if c.GlobalBool("offline") {
+		if c.GlobalBool("no-cache") {
+			return nil, classify(failureValidation, errors.New("Global option 'offline' can't be used with 'no-cache' option"))
+		}
+		return offlineFetcher(), nil
 	}
 
-	return nil
-}
+	cache := fetch.NewCache(cacheDir)
+	if c.GlobalBool("no-cache") {
+		cache = nil
+	}
 
-func getAliasMap() (map[string]string, error) {
+	a, err := newAuth(ctx, c)
+	if err != nil {
+		return nil, classify(failureAuth, fmt.Errorf("Unable to create a google sheet client: %v", err))
+	}
 
-	conf, err := readConfig()
+	gc, err := fetch.New(ctx, a.options, fetch.CacheBypassHandler(func(id string, err error) {
+		log.warn("cache is not used", field{"id", id}, field{"reason", err})
+	}))
 	if err != nil {
+		return nil, classify(failureAuth, fmt.Errorf("Unable to create a google sheet client: %v", err))
+	}
+
+	return func(ctx context.Context, id string, names []string, filter func(name string) bool) (*fetch.Spreadsheet, error) {
+		log.debug("fetching spreadsheet", field{"id", id}, field{"sheets", strings.Join(names, ",")})
+		s, err := gc.Fetch(ctx, id, names, filter, cache)
 		switch err.(type) {
-		case *unableToReadConfigError:
-			return nil, nil
-		default:
-			return nil, err
+		case *fetch.AccessError, *fetch.ScopeError:
+			return nil, classify(failureAuth, a.guide(err))
+		}
+		if err != nil {
+			return nil, classify(failureFetch, err)
 		}
+		log.debug("fetched spreadsheet", field{"id", id}, field{"title", s.Title}, field{"revision", s.Revision})
+		return s, nil
+	}, nil
+}
+
+// offlineFetcher returns the fetcher which uses only the cache without network access.
+func offlineFetcher() fetcher {
+	cache := fetch.NewCache(cacheDir)
+	return func(_ context.Context, id string, names []string, filter func(name string) bool) (*fetch.Spreadsheet, error) {
+		s, err := cache.Fetch(id, names, filter)
+		if err != nil {
+			return nil, classify(failureFetch, err)
+		}
+		log.debug("loaded cache", field{"id", id}, field{"title", s.Title}, field{"revision", s.Revision})
+		return s, nil
+	}
+}
+
+// inputOf returns the input source. The global options take precedence over the configuration file,
+// so 'sheetid' option overrides the snapshot in the configuration file.
+func inputOf(c *cli.Context, conf *config) input {
+
+	var in input
+	if conf != nil {
+		in = conf.Input
+	}
+	if s := c.GlobalString("sheetid"); s != "" {
+		in.SheetID = s
+		in.Snapshot = ""
+	}
+	if s := c.GlobalString("snapshot"); s != "" {
+		in.Snapshot = s
+	}
+	if ss := c.GlobalStringSlice("sheetname"); len(ss) > 0 {
+		in.SheetName = ss
+	}
+	if ss := c.GlobalStringSlice("include"); len(ss) > 0 {
+		in.Include = ss
+	}
+	if ss := c.GlobalStringSlice("exclude"); len(ss) > 0 {
+		in.Exclude = ss
+	}
+	if ss := c.GlobalStringSlice("skip-prefix"); len(ss) > 0 {
+		in.SkipPrefix = ss
+	}
+	if s := c.GlobalString("common"); s != "" {
+		in.Common = s
+	}
+	if ss := c.GlobalStringSlice("common-sheet"); len(ss) > 0 {
+		in.CommonSheet = ss
 	}
 
-	return conf.AliasMap(), nil
+	return in
 }
 
-func parse(ctx context.Context, gc *gsheets.Client, id, sheet, common string) (*tdconv.TableSet, error) {
+// parse fetches the sheets and parses them. If names is empty, all sheets are fetched.
+// The sheets skipped by the filter are not fetched, and the sheets not like the table definitions are not parsed,
+// but the sheet named explicitly which is not like the table definitions is an error.
+// The order of the tables is the same as the sheets in the spreadsheet.
+func parse(ctx context.Context, f fetcher, id string, names []string, filter *sheetFilter, common string, groups []commonGroup, opts ...tdconv.ParseOption) (*tdconv.TableSet, error) {
 
-	title, err := gc.GetTitle(ctx, id)
+	s, err := f(ctx, id, names, func(name string) bool { return filter.skip(name) == "" })
 	if err != nil {
-		return nil, fmt.Errorf("Unable to get spreadsheet title: %v", err)
+		return nil, classify(failureFetch, fmt.Errorf("Unable to get sheet values: %w", err))
 	}
-
-	var sheets []string
-	if sheet != "" {
-		sheets = []string{sheet}
-	} else {
-		sheets, err = gc.GetSheetNames(ctx, id)
-		if err != nil {
-			return nil, fmt.Errorf("Unable to get all sheet names using spreadsheet id: %v", err)
+	var selected []string
+	for _, sheetname := range fetch.SelectSheets(s.SheetNames, names, nil) {
+		if reason := filter.skip(sheetname); reason != "" {
+			log.debug("skipped sheet", field{"sheet", sheetname}, field{"reason", reason})
+			continue
 		}
+		selected = append(selected, sheetname)
+	}
+	if len(selected) == 0 {
+		return nil, classify(failureParse, fmt.Errorf("No table definitions sheet in spreadsheet (id=%s)", id))
+	}
+	sheets, values, err := s.Values(selected)
+	if err != nil {
+		return nil, classify(failureFetch, err)
 	}
 
-	p, err := tdconv.NewParser()
+	overlap := tdconv.OverlapReport(func(table, column, group string) {
+		log.warn("skipped common column defined already", field{"table", table}, field{"column", column}, field{"group", group})
+	})
+	p, err := tdconv.NewParser(append(opts, overlap)...)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to create new parser: %v", err)
+		return nil, classify(failureConfig, fmt.Errorf("Unable to create new parser: %v", err))
 	}
 
 	if common != "" {
-		s, err := gc.GetSheet(ctx, common, "common")
+		gnames := make([]string, len(groups))
+		for i, g := range groups {
+			gnames[i] = g.Sheet
+		}
+		cs, err := f(ctx, common, gnames, nil)
 		if err != nil {
-			return nil, fmt.Errorf("Unable to get common sheet values: %v", err)
+			return nil, classify(failureFetch, fmt.Errorf("Unable to get common sheet values: %w", err))
 		}
-		err = p.SetCommonColumns(s)
+		_, cv, err := cs.Values(gnames)
 		if err != nil {
-			return nil, fmt.Errorf("Unable to parse common sheet information: %v", err)
+			return nil, classify(failureFetch, err)
+		}
+		for i, g := range groups {
+			gopts, err := g.options()
+			if err != nil {
+				return nil, classify(failureConfig, err)
+			}
+			if err := p.AddCommonColumns(g.Sheet, cv[i], gopts...); err != nil {
+				return nil, classify(failureParse, fmt.Errorf("Unable to parse common sheet information (sheetname=%s): %v", g.Sheet, err))
+			}
 		}
 	}
 
 	var tables []*tdconv.Table
-	for _, sheetname := range sheets {
-		s, err := gc.GetSheet(ctx, id, sheetname)
-		if err != nil {
-			return nil, fmt.Errorf("Unable to get sheet values (sheetname=%s): %v", sheetname, err)
+	for i, sheetname := range sheets {
+		if !p.IsTable(values[i]) {
+			if len(names) > 0 {
+				return nil, classify(failureParse, fmt.Errorf("Sheet is not like table definitions (sheetname=%s)", sheetname))
+			}
+			log.warn("skipped sheet not like table definitions", field{"sheet", sheetname})
+			continue
 		}
-		t, err := p.Parse(s)
+		t, err := p.Parse(values[i])
 		if err != nil {
-			return nil, fmt.Errorf("Unable to parse sheet information (sheetname=%s): %v", sheetname, err)
+			return nil, classify(failureParse, fmt.Errorf("Unable to parse sheet information (sheetname=%s): %v", sheetname, err))
 		}
+		log.debug("parsed sheet", field{"sheet", sheetname}, field{"table", t.Name}, field{"columns", len(t.Columns)})
 		tables = append(tables, t)
 	}
 
+	if len(tables) == 0 {
+		return nil, classify(failureParse, fmt.Errorf("No table definitions sheet in spreadsheet (id=%s)", id))
+	}
+
 	return &tdconv.TableSet{
-		Name:   title,
+		Name:   s.Title,
 		Tables: tables,
 	}, nil
 }
 
-func output(f tdconv.Formatter, commandName string, ts *tdconv.TableSet, multi bool) error {
+// outputOptions returns the output options with the naming (optional) and the global options.
+// The naming takes precedence over the global options.
+func outputOptions(c *cli.Context, n naming) []tdconv.OutputOption {
+
+	if n.Filename == "" {
+		n.Filename = c.GlobalString("filename")
+	}
+	if n.Prefix == "" {
+		n.Prefix = c.GlobalString("prefix")
+	}
+	if n.Suffix == "" {
+		n.Suffix = c.GlobalString("suffix")
+	}
 
-	outdir := "./out/" + commandName
-	if _, err := os.Stat("./out"); os.IsNotExist(err) {
-		os.Mkdir("./out", 0777)
+	var opts []tdconv.OutputOption
+	if n.Filename != "" {
+		opts = append(opts, tdconv.OutputFileNameTemplate(n.Filename))
+	}
+	if n.Prefix != "" {
+		opts = append(opts, tdconv.OutputFileNamePrefix(n.Prefix))
+	}
+	if n.Suffix != "" {
+		opts = append(opts, tdconv.OutputFileNameSuffix(n.Suffix))
 	}
-	if _, err := os.Stat(outdir); os.IsNotExist(err) {
-		os.Mkdir(outdir, 0777)
+	if c.GlobalBool("manifest") {
+		opts = append(opts, tdconv.OutputManifest(manifestName))
 	}
 
-	if err := tdconv.Output(f, ts, multi, outdir); err != nil {
-		return fmt.Errorf("Fail to output table definitions: %v", err)
+	return opts
+}
+
+func output(f tdconv.Formatter, outdir string, ts *tdconv.TableSet, multi bool, opts ...tdconv.OutputOption) error {
+
+	if err := tdconv.Output(f, ts, multi, outdir, opts...); err != nil {
+		return classify(failureOutput, fmt.Errorf("Fail to output table definitions: %v", err))
 	}
 
 	return nil
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+	"github.com/takuoki/tdconv/internal/fetch"
+)
+
+// testFetcher returns the fetcher of the spreadsheet which has a table definitions sheet for each table name,
+// and README sheet which is not like the table definitions.
+// Like the real fetcher, the spreadsheet has only the values of the selected sheets.
+func testFetcher(tables ...string) fetcher {
+	values := map[string]tdconv.SheetValues{"README": {{"this is README"}}}
+	for _, t := range tables {
+		values[t] = tdconv.SheetValues{
+			{}, {"", "table name", t}, {}, {},
+			{"", "1", "id", "INT", "yes", "yes", "", "", "", ""},
+		}
+	}
+	return func(_ context.Context, _ string, names []string, filter func(name string) bool) (*fetch.Spreadsheet, error) {
+		s := &fetch.Spreadsheet{
+			Version:    fetch.CacheVersion,
+			ID:         "id",
+			Title:      "sample",
+			SheetNames: append([]string{"README"}, tables...),
+			Sheets:     map[string]tdconv.SheetValues{},
+		}
+		for _, n := range fetch.SelectSheets(s.SheetNames, names, filter) {
+			s.Sheets[n] = values[n]
+		}
+		return s, nil
+	}
+}
+
+func TestParse(t *testing.T) {
+
+	setTestLogger(t)
+
+	cases := []struct {
+		caseName string
+		names    []string
+		in       input
+		expected []string
+		errMsg   string
+	}{
+		{
+			caseName: "success:all sheets",
+			expected: []string{"users", "posts", "_draft_logs"},
+		},
+		{
+			caseName: "success:filter",
+			in:       input{SkipPrefix: stringList{"_draft"}},
+			expected: []string{"users", "posts"},
+		},
+		{
+			caseName: "success:filter with sheet names",
+			names:    []string{"users", "_draft_logs"},
+			in:       input{SkipPrefix: stringList{"_draft"}},
+			expected: []string{"users"},
+		},
+		{
+			caseName: "success:sheet names",
+			names:    []string{"posts"},
+			expected: []string{"posts"},
+		},
+		{
+			caseName: "failure:sheet name not like table definitions",
+			names:    []string{"users", "README"},
+			errMsg:   "Sheet is not like table definitions (sheetname=README)",
+		},
+		{
+			caseName: "failure:no table definitions",
+			in:       input{Include: stringList{"README"}},
+			errMsg:   "No table definitions sheet in spreadsheet (id=id)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			filter, err := newSheetFilter(c.in)
+			if err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+
+			ts, err := parse(context.Background(), testFetcher("users", "posts", "_draft_logs"), "id", c.names, filter, "", nil)
+
+			if c.errMsg != "" {
+				if err == nil {
+					t.Fatal("error must occur")
+				}
+				if err.Error() != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+				}
+				if f := failureOf(err); f != failureParse {
+					t.Errorf("failure doesn't match (expected=%s, actual=%s)", failureParse, f)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			var tables []string
+			for _, tb := range ts.Tables {
+				tables = append(tables, tb.Name)
+			}
+			if strings.Join(tables, ",") != strings.Join(c.expected, ",") {
+				t.Errorf("tables don't match (expected=%v, actual=%v)", c.expected, tables)
+			}
+		})
+	}
+}
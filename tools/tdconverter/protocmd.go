@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/takuoki/tdconv"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	formatList = append(formatList, format{
+		name:  "proto",
+		usage: "Converts the table definitions to Protocol Buffers message.",
+		flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "package",
+				Usage: "package name of the proto file. if not specified, the spreadsheet title is used.",
+			},
+			cli.StringFlag{
+				Name:  "go_package",
+				Usage: "go_package option of the proto file.",
+			},
+			cli.StringFlag{
+				Name: "lock",
+				Usage: "lock file (.json) or previous proto file (.proto) to keep the field numbers stable. " +
+					"the lock file is updated after the output.",
+			},
+		},
+		typeMap: true,
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+
+			opts := []tdconv.ProtoFormatOption{tdconv.ProtoTypeMap(types)}
+			if p := o.String("package"); p != "" {
+				opts = append(opts, tdconv.ProtoPackage(p))
+			}
+			if p := o.String("go_package"); p != "" {
+				opts = append(opts, tdconv.ProtoGoPackage(p))
+			}
+
+			lock := o.String("lock")
+			if lock != "" {
+				n, err := readProtoLock(lock)
+				if err != nil {
+					return nil, err
+				}
+				opts = append(opts, tdconv.ProtoFieldNumberLock(n))
+			}
+
+			return tdconv.NewProtoFormatter(opts...)
+		},
+		after: func(o formatOptions, f tdconv.Formatter, ts *tdconv.TableSet) error {
+			lock := o.String("lock")
+			if lock != "" && filepath.Ext(lock) != ".proto" {
+				return writeProtoLock(lock, f.(*tdconv.ProtoFormatter).FieldNumbers(ts))
+			}
+			return nil
+		},
+	})
+}
+
+func readProtoLock(name string) (tdconv.ProtoFieldNumbers, error) {
+
+	file, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open lock file (%s): %v", name, err)
+	}
+	defer file.Close()
+
+	if filepath.Ext(name) == ".proto" {
+		return tdconv.ParseProtoFieldNumbers(file)
+	}
+	return tdconv.ReadProtoFieldNumbers(file)
+}
+
+// writeProtoLock writes the lock file atomically, so that the previous lock file is kept on failure.
+func writeProtoLock(name string, n tdconv.ProtoFieldNumbers) error {
+
+	file, err := tdconv.OSFileSystem{}.Create(name)
+	if err != nil {
+		return fmt.Errorf("Unable to create lock file (%s): %v", name, err)
+	}
+	if err := tdconv.WriteProtoFieldNumbers(file, n); err != nil {
+		if a, ok := file.(tdconv.Aborter); ok {
+			a.Abort()
+		} else {
+			file.Close()
+		}
+		return fmt.Errorf("Unable to write lock file (%s): %v", name, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("Unable to close lock file (%s): %v", name, err)
+	}
+
+	return nil
+}
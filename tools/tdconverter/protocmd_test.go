@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+func TestWriteProtoLock(t *testing.T) {
+
+	n := tdconv.ProtoFieldNumbers{
+		"Users": {Fields: map[string]int{"id": 1, "name": 3}, Reserved: []int{2}},
+	}
+
+	name := filepath.Join(t.TempDir(), "proto.lock.json")
+	if err := writeProtoLock(name, n); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	actual, err := readProtoLock(name)
+	if err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	if !reflect.DeepEqual(actual, n) {
+		t.Errorf("field numbers don't match (expected=%v, actual=%v)", n, actual)
+	}
+
+	// the error on closing (renaming to the directory) is returned, and the directory is kept
+	dir := filepath.Join(t.TempDir(), "proto.lock.json")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	if err := writeProtoLock(dir, n); err == nil {
+		t.Fatal("error must occur")
+	}
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		t.Errorf("directory must be kept: %v", err)
+	}
+	if ms, _ := filepath.Glob(filepath.Join(filepath.Dir(dir), ".*.tmp*")); len(ms) != 0 {
+		t.Errorf("temporary files must be removed: %v", ms)
+	}
+}
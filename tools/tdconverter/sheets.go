@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// regexpPrefix is the prefix of the sheet pattern which means the regular expression.
+const regexpPrefix = "re:"
+
+// sheetFlags are the global options to select the table definitions sheets.
+var sheetFlags = []cli.Flag{
+	cli.StringSliceFlag{
+		Name: "include",
+		Usage: "pattern of the sheet names to parse, which is a glob (e.g. 'user_*') or a regular expression with '" + regexpPrefix + "' prefix. " +
+			"it can be specified multiple times.",
+	},
+	cli.StringSliceFlag{
+		Name:  "exclude",
+		Usage: "pattern of the sheet names to skip, same as 'include' option. it takes precedence over 'include' option.",
+	},
+	cli.StringSliceFlag{
+		Name:  "skip-prefix",
+		Usage: "prefix of the sheet names to skip (e.g. '_draft'). it can be specified multiple times.",
+	},
+}
+
+// stringList is a list of strings in the configuration file, which also accepts a single string.
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+	var ss []string
+	if err := json.Unmarshal(b, &ss); err != nil {
+		return err
+	}
+	*l = ss
+	return nil
+}
+
+func (l *stringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+	var ss []string
+	if err := unmarshal(&ss); err != nil {
+		return err
+	}
+	*l = ss
+	return nil
+}
+
+func (l stringList) String() string {
+	return strings.Join(l, ", ")
+}
+
+// sheetPattern is a glob or a regular expression of the sheet names.
+type sheetPattern struct {
+	glob string
+	re   *regexp.Regexp
+}
+
+func newSheetPattern(s string) (sheetPattern, error) {
+	if strings.HasPrefix(s, regexpPrefix) {
+		re, err := regexp.Compile(strings.TrimPrefix(s, regexpPrefix))
+		if err != nil {
+			return sheetPattern{}, fmt.Errorf("Invalid sheet pattern (%s): %v", s, err)
+		}
+		return sheetPattern{re: re}, nil
+	}
+	if _, err := path.Match(s, ""); err != nil {
+		return sheetPattern{}, fmt.Errorf("Invalid sheet pattern (%s): %v", s, err)
+	}
+	return sheetPattern{glob: s}, nil
+}
+
+func (p sheetPattern) match(name string) bool {
+	if p.re != nil {
+		return p.re.MatchString(name)
+	}
+	ok, _ := path.Match(p.glob, name)
+	return ok
+}
+
+// sheetFilter selects the sheets to parse by the include and exclude patterns and the prefixes to skip.
+type sheetFilter struct {
+	include, exclude []sheetPattern
+	skipPrefix       []string
+}
+
+// newSheetFilter returns the filter of the input.
+func newSheetFilter(in input) (*sheetFilter, error) {
+
+	f := &sheetFilter{skipPrefix: in.SkipPrefix}
+	for _, s := range in.Include {
+		p, err := newSheetPattern(s)
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, p)
+	}
+	for _, s := range in.Exclude {
+		p, err := newSheetPattern(s)
+		if err != nil {
+			return nil, err
+		}
+		f.exclude = append(f.exclude, p)
+	}
+
+	return f, nil
+}
+
+// skip returns the reason to skip the sheet, or "" if the sheet is selected.
+func (f *sheetFilter) skip(name string) string {
+
+	for _, s := range f.skipPrefix {
+		if strings.HasPrefix(name, s) {
+			return "prefix " + s
+		}
+	}
+	for _, p := range f.exclude {
+		if p.match(name) {
+			return "excluded"
+		}
+	}
+	if len(f.include) == 0 {
+		return ""
+	}
+	for _, p := range f.include {
+		if p.match(name) {
+			return ""
+		}
+	}
+
+	return "not included"
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewSheetPattern(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		pattern  string
+		names    map[string]bool
+		errMsg   string
+	}{
+		{
+			caseName: "success:glob",
+			pattern:  "user_*",
+			names:    map[string]bool{"user_profiles": true, "user_": true, "users": false, "my_user_profiles": false},
+		},
+		{
+			caseName: "success:glob without meta characters",
+			pattern:  "users",
+			names:    map[string]bool{"users": true, "users_old": false},
+		},
+		{
+			caseName: "success:regular expression",
+			pattern:  "re:^(users|posts)$",
+			names:    map[string]bool{"users": true, "posts": true, "user_posts": false},
+		},
+		{
+			caseName: "success:regular expression matches a part",
+			pattern:  "re:_old",
+			names:    map[string]bool{"users_old": true, "_old_posts": true, "users": false},
+		},
+		{
+			caseName: "success:glob like regular expression",
+			pattern:  "^users$",
+			names:    map[string]bool{"^users$": true, "users": false},
+		},
+		{
+			caseName: "failure:invalid glob",
+			pattern:  "user_[",
+			errMsg:   "Invalid sheet pattern (user_[)",
+		},
+		{
+			caseName: "failure:invalid regular expression",
+			pattern:  "re:(users",
+			errMsg:   "Invalid sheet pattern (re:(users)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			p, err := newSheetPattern(c.pattern)
+
+			if c.errMsg != "" {
+				if err == nil {
+					t.Fatal("error must occur")
+				}
+				if !strings.HasPrefix(err.Error(), c.errMsg) {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			for name, expected := range c.names {
+				if actual := p.match(name); actual != expected {
+					t.Errorf("match doesn't match (name=%s, expected=%t, actual=%t)", name, expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestSheetFilter_skip(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		in       input
+		names    map[string]string
+	}{
+		{
+			caseName: "no filter",
+			in:       input{},
+			names:    map[string]string{"users": "", "_draft_users": "", "README": ""},
+		},
+		{
+			caseName: "include",
+			in:       input{Include: stringList{"user*", "re:^posts$"}},
+			names:    map[string]string{"users": "", "user_profiles": "", "posts": "", "comments": "not included"},
+		},
+		{
+			caseName: "exclude",
+			in:       input{Exclude: stringList{"tmp_*", "re:(?i)readme"}},
+			names:    map[string]string{"users": "", "tmp_users": "excluded", "README": "excluded"},
+		},
+		{
+			caseName: "exclude takes precedence over include",
+			in:       input{Include: stringList{"user*"}, Exclude: stringList{"users_old"}},
+			names:    map[string]string{"users": "", "users_old": "excluded", "posts": "not included"},
+		},
+		{
+			caseName: "prefixes are skipped first",
+			in: input{
+				Include:    stringList{"*users"},
+				Exclude:    stringList{"_tmp*"},
+				SkipPrefix: stringList{"_draft", "_tmp"},
+			},
+			names: map[string]string{"users": "", "_draft_users": "prefix _draft", "_tmp_users": "prefix _tmp", "posts": "not included"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			f, err := newSheetFilter(c.in)
+			if err != nil {
+				t.Fatalf("error must not occur: %v", err)
+			}
+			for name, expected := range c.names {
+				if actual := f.skip(name); actual != expected {
+					t.Errorf("reason doesn't match (name=%s, expected=%q, actual=%q)", name, expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSheetFilter_invalid(t *testing.T) {
+
+	cases := []struct {
+		caseName string
+		in       input
+		errMsg   string
+	}{
+		{
+			caseName: "include",
+			in:       input{Include: stringList{"users", "re:["}},
+			errMsg:   "Invalid sheet pattern (re:[)",
+		},
+		{
+			caseName: "exclude",
+			in:       input{Exclude: stringList{"["}},
+			errMsg:   "Invalid sheet pattern ([)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := newSheetFilter(c.in)
+			if err == nil {
+				t.Fatal("error must occur")
+			}
+			if !strings.HasPrefix(err.Error(), c.errMsg) {
+				t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+			}
+		})
+	}
+}
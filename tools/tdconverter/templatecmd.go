@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/takuoki/tdconv"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	formatList = append(formatList, format{
+		name:  "template",
+		usage: "Converts the table definitions using the template files.",
+		flags: []cli.Flag{
+			cli.StringFlag{
+				Name: "dir",
+				Usage: "directory of the template files " +
+					"(header.tmpl, table_header.tmpl, body.tmpl, table_footer.tmpl and footer.tmpl. only body.tmpl is required).",
+			},
+			cli.StringFlag{
+				Name:  "ext",
+				Value: "txt",
+				Usage: "extension of the output files.",
+			},
+		},
+		typeMap: true,
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+			if o.String("dir") == "" {
+				return nil, errors.New("Option 'dir' is required")
+			}
+			opts := []tdconv.TemplateFormatOption{tdconv.TemplateDir(o.String("dir")), tdconv.TemplateTypeMap(types)}
+			if ext := o.String("ext"); ext != "" {
+				opts = append(opts, tdconv.TemplateExtension(ext))
+			}
+			return tdconv.NewTemplateFormatter(opts...)
+		},
+	})
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/takuoki/tdconv"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	formatList = append(formatList, format{
+		name:  "ts",
+		usage: "Converts the table definitions to TypeScript interface.",
+		flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "camel",
+				Usage: "flag indicating whether to use camelCase for the property keys.",
+			},
+		},
+		typeMap: true,
+		new: func(o formatOptions, types map[string]string) (tdconv.Formatter, error) {
+			opts := []tdconv.TypeScriptFormatOption{tdconv.TypeScriptTypeMap(types)}
+			if o.Bool("camel") {
+				opts = append(opts, tdconv.TypeScriptCamelCase())
+			}
+			return tdconv.NewTypeScriptFormatter(opts...)
+		},
+	})
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/takuoki/tdconv"
+	"github.com/urfave/cli"
+)
+
+// fileWatchInterval is the interval to check the modification of the local input file.
+const fileWatchInterval = time.Second
+
+func init() {
+	cmdList = append(cmdList, cli.Command{
+		Name: "watch",
+		Usage: "Watches the spreadsheet or the snapshot file, and regenerates the outputs when the table definitions are changed. " +
+			fmt.Sprintf("the targets are same as 'gen' command (%s). stop with Ctrl-C.", configFile),
+		ArgsUsage: "[format...]",
+		Flags: []cli.Flag{
+			cli.DurationFlag{
+				Name:  "interval",
+				Value: 30 * time.Second,
+				Usage: "interval to poll the revision of the spreadsheet. the snapshot file is checked every second.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+
+			if c.GlobalBool("stdout") || c.GlobalBool("check") {
+				return errors.New("Global option 'stdout' and 'check' can't be used with 'watch' command")
+			}
+			if c.Duration("interval") <= 0 {
+				return errors.New("Option 'interval' must be greater than zero")
+			}
+
+			conf, err := readConfigIfExists()
+			if err != nil {
+				return err
+			}
+
+			targets, err := genTargets(c, conf)
+			if err != nil {
+				return err
+			}
+
+			fs, err := newTargetFormatters(conf, targets)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sig := make(chan os.Signal, 1)
+			signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(sig)
+			go func() {
+				select {
+				case <-sig:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			src, err := newWatchSource(ctx, c, conf)
+			if err != nil {
+				return err
+			}
+
+			log.info("watching (Ctrl-C to stop)", field{"source", src.name}, field{"interval", src.interval})
+
+			var prev *tdconv.TableSet
+			tick := time.NewTicker(src.interval)
+			defer tick.Stop()
+			for {
+				if src.changed() {
+					ts, err := src.load(ctx)
+					if ctx.Err() != nil {
+						log.info("stopped!")
+						return nil
+					}
+					if err != nil {
+						log.error(err)
+					} else if changedTableSet(prev, ts) {
+						if err := regenerate(c, targets, fs, ts, tdconv.CompareTableSets(prev, ts)); err != nil {
+							log.error(err)
+						} else {
+							prev = ts
+							src.done()
+						}
+					} else {
+						src.done()
+					}
+				}
+
+				select {
+				case <-ctx.Done():
+					log.info("stopped!")
+					return nil
+				case <-tick.C:
+				}
+			}
+		},
+	})
+}
+
+// watchSource is the input source watched by `watch` command.
+type watchSource struct {
+	name     string
+	interval time.Duration
+	// changed reports whether the input may be changed since the last call of done.
+	changed func() bool
+	load    func(ctx context.Context) (*tdconv.TableSet, error)
+	// done records the input checked by changed as processed, which is called only after the outputs are up to date,
+	// so that the input failed to load or regenerate is retried on the next interval.
+	done func()
+}
+
+// newWatchSource returns the snapshot file or the spreadsheet as the input source.
+// The spreadsheet is loaded on every interval, but the sheets are fetched only when the revision is changed,
+// because the fetched sheets are cached by the revision.
+func newWatchSource(ctx context.Context, c *cli.Context, conf *config) (*watchSource, error) {
+
+	in := inputOf(c, conf)
+
+	if in.Snapshot != "" {
+		var modTime, checked time.Time
+		return &watchSource{
+			name:     in.Snapshot,
+			interval: fileWatchInterval,
+			changed: func() bool {
+				fi, err := os.Stat(in.Snapshot)
+				if err != nil {
+					// the error is reported by load
+					return true
+				}
+				checked = fi.ModTime()
+				return !checked.Equal(modTime)
+			},
+			load: func(context.Context) (*tdconv.TableSet, error) {
+				return loadSnapshot(in.Snapshot)
+			},
+			done: func() {
+				modTime = checked
+			},
+		}, nil
+	}
+
+	if in.SheetID == "" {
+		return nil, classify(failureValidation, errors.New("Global option 'sheetid' or 'snapshot' is required"))
+	}
+
+	f, err := newFetcher(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &watchSource{
+		name:     "spreadsheet " + in.SheetID,
+		interval: c.Duration("interval"),
+		changed:  func() bool { return true },
+		load: func(ctx context.Context) (*tdconv.TableSet, error) {
+			return loadSpreadsheet(ctx, f, conf, in)
+		},
+		done: func() {},
+	}, nil
+}
+
+// changedTableSet reports whether the outputs must be regenerated for the table set.
+// The whole table set is compared, because the outputs depend on more than the schema differences,
+// like the order of the tables, the common columns and the name of the table set.
+func changedTableSet(prev, ts *tdconv.TableSet) bool {
+	return prev == nil || !reflect.DeepEqual(prev, ts)
+}
+
+// regenerate outputs the table set to all targets, and logs the summary of the changes.
+// The unchanged files are not rewritten, and the schema differences are used only for the summary.
+func regenerate(c *cli.Context, targets []target, fs []tdconv.Formatter, ts *tdconv.TableSet, d *tdconv.SchemaDiff) error {
+
+	sum := newSummary(ts)
+	for i, t := range targets {
+		if err := outputTarget(c, t, fs[i], ts, sum.report()); err != nil {
+			return err
+		}
+	}
+
+	tables := make([]string, 0, len(d.Tables))
+	for _, t := range d.Tables {
+		tables = append(tables, diffMark(t.Kind)+t.Name)
+	}
+
+	sum.logFiles(levelInfo, tdconv.FileCreated, tdconv.FileUpdated, tdconv.FileRemoved)
+	if len(tables) == 0 {
+		log.info("table definitions changed without schema differences", sum.fields()...)
+		return nil
+	}
+	log.info(fmt.Sprintf("%d table(s) changed: %s", len(tables), strings.Join(tables, " ")), sum.fields()...)
+
+	return nil
+}
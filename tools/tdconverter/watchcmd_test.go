@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/takuoki/tdconv"
+	"github.com/urfave/cli"
+)
+
+// newTestContext returns the context of the command with the global options.
+func newTestContext(t *testing.T, args ...string) *cli.Context {
+
+	set := flag.NewFlagSet("tdconverter", flag.ContinueOnError)
+	set.String("sheetid", "", "")
+	set.String("snapshot", "", "")
+	set.Bool("multi", false, "")
+	if err := set.Parse(args); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+
+	app := cli.NewApp()
+	return cli.NewContext(app, flag.NewFlagSet("watch", flag.ContinueOnError), cli.NewContext(app, set, nil))
+}
+
+// setTestLogger replaces the logger with the buffer until the end of the test.
+func setTestLogger(t *testing.T) *bytes.Buffer {
+	b := &bytes.Buffer{}
+	orig := log
+	log = &logger{w: b, level: levelInfo}
+	t.Cleanup(func() { log = orig })
+	return b
+}
+
+var watchTestTableSet = &tdconv.TableSet{
+	Name: "sample_table_set",
+	Tables: []*tdconv.Table{
+		{
+			Name:    "users",
+			Columns: []tdconv.Column{{Name: "id", Type: "INT", PKey: true}},
+		},
+	},
+}
+
+func TestNewWatchSource_snapshot(t *testing.T) {
+
+	setTestLogger(t)
+
+	name := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := tdconv.SaveSnapshot(name, watchTestTableSet); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+
+	src, err := newWatchSource(context.Background(), newTestContext(t, "-snapshot", name), nil)
+	if err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	if src.name != name {
+		t.Errorf("name doesn't match (expected=%s, actual=%s)", name, src.name)
+	}
+	if src.interval != fileWatchInterval {
+		t.Errorf("interval doesn't match (expected=%s, actual=%s)", fileWatchInterval, src.interval)
+	}
+
+	// the first check
+	if !src.changed() {
+		t.Fatal("snapshot must be changed at first")
+	}
+	if _, err := src.load(context.Background()); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	src.done()
+	if src.changed() {
+		t.Fatal("snapshot must not be changed after done")
+	}
+
+	// the broken file is retried until it's loaded
+	if err := ioutil.WriteFile(name, []byte("{"), 0644); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	touch(t, name, time.Now().Add(time.Minute))
+	if !src.changed() {
+		t.Fatal("snapshot must be changed after written")
+	}
+	if _, err := src.load(context.Background()); err == nil {
+		t.Fatal("error must occur")
+	}
+	if !src.changed() {
+		t.Fatal("snapshot must be changed until it's loaded")
+	}
+
+	if err := tdconv.SaveSnapshot(name, watchTestTableSet); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	touch(t, name, time.Now().Add(2*time.Minute))
+	if !src.changed() {
+		t.Fatal("snapshot must be changed after fixed")
+	}
+	ts, err := src.load(context.Background())
+	if err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	if len(ts.Tables) != 1 || ts.Tables[0].Name != "users" {
+		t.Errorf("loaded tables don't match: %v", ts.Tables)
+	}
+	src.done()
+	if src.changed() {
+		t.Fatal("snapshot must not be changed after done")
+	}
+
+	// the removed file is reported by load
+	if err := os.Remove(name); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	if !src.changed() {
+		t.Fatal("snapshot must be changed after removed")
+	}
+	if _, err := src.load(context.Background()); err == nil {
+		t.Fatal("error must occur")
+	}
+}
+
+func TestNewWatchSource_noInput(t *testing.T) {
+
+	_, err := newWatchSource(context.Background(), newTestContext(t), nil)
+	if err == nil {
+		t.Fatal("error must occur")
+	}
+	if f := failureOf(err); f != failureValidation {
+		t.Errorf("failure doesn't match (expected=%s, actual=%s)", failureValidation, f)
+	}
+}
+
+func TestRegenerate(t *testing.T) {
+
+	b := setTestLogger(t)
+
+	targets := []target{{Format: "md", Outdir: t.TempDir()}}
+	fs, err := newTargetFormatters(nil, targets)
+	if err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	c := newTestContext(t)
+
+	// the first generation
+	if err := regenerate(c, targets, fs, watchTestTableSet, tdconv.CompareTableSets(nil, watchTestTableSet)); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	file := filepath.Join(targets[0].Outdir, "sample_table_set.md")
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("output file must be created: %v", err)
+	}
+	expected := []string{"file created", "1 table(s) changed: +users"}
+	for _, s := range expected {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("log must contain %q: %s", s, b.String())
+		}
+	}
+
+	// the unchanged files are not rewritten
+	b.Reset()
+	ts := &tdconv.TableSet{
+		Name: watchTestTableSet.Name,
+		Tables: []*tdconv.Table{
+			watchTestTableSet.Tables[0],
+			{Name: "posts", Columns: []tdconv.Column{{Name: "id", Type: "INT", PKey: true}}},
+		},
+	}
+	if err := regenerate(c, targets, fs, ts, tdconv.CompareTableSets(watchTestTableSet, ts)); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	expected = []string{"file updated", "1 table(s) changed: +posts"}
+	for _, s := range expected {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("log must contain %q: %s", s, b.String())
+		}
+	}
+
+	b.Reset()
+	if err := regenerate(c, targets, fs, ts, tdconv.CompareTableSets(ts, ts)); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+	if strings.Contains(b.String(), "file ") {
+		t.Errorf("unchanged file must not be logged: %s", b.String())
+	}
+	if !strings.Contains(b.String(), "table definitions changed without schema differences") {
+		t.Errorf("log must contain the change without schema differences: %s", b.String())
+	}
+}
+
+func TestChangedTableSet(t *testing.T) {
+
+	users := &tdconv.Table{Name: "users", Columns: []tdconv.Column{{Name: "id", Type: "INT", PKey: true}}}
+	posts := &tdconv.Table{Name: "posts", Columns: []tdconv.Column{{Name: "id", Type: "INT", PKey: true}}}
+	prev := &tdconv.TableSet{Name: "sample", Tables: []*tdconv.Table{users, posts}}
+
+	cases := []struct {
+		caseName string
+		prev     *tdconv.TableSet
+		ts       *tdconv.TableSet
+		expected bool
+	}{
+		{
+			caseName: "first",
+			ts:       prev,
+			expected: true,
+		},
+		{
+			caseName: "same",
+			prev:     prev,
+			ts: &tdconv.TableSet{Name: "sample", Tables: []*tdconv.Table{
+				{Name: "users", Columns: []tdconv.Column{{Name: "id", Type: "INT", PKey: true}}},
+				{Name: "posts", Columns: []tdconv.Column{{Name: "id", Type: "INT", PKey: true}}},
+			}},
+			expected: false,
+		},
+		{
+			caseName: "order",
+			prev:     prev,
+			ts:       &tdconv.TableSet{Name: "sample", Tables: []*tdconv.Table{posts, users}},
+			expected: true,
+		},
+		{
+			caseName: "name",
+			prev:     prev,
+			ts:       &tdconv.TableSet{Name: "sample2", Tables: []*tdconv.Table{users, posts}},
+			expected: true,
+		},
+		{
+			caseName: "common column",
+			prev:     prev,
+			ts: &tdconv.TableSet{Name: "sample", Tables: []*tdconv.Table{
+				{Name: "users", Columns: []tdconv.Column{{Name: "id", Type: "INT", PKey: true, IsCommon: true}}},
+				posts,
+			}},
+			expected: true,
+		},
+		{
+			caseName: "type whitespace",
+			prev:     prev,
+			ts: &tdconv.TableSet{Name: "sample", Tables: []*tdconv.Table{
+				{Name: "users", Columns: []tdconv.Column{{Name: "id", Type: "INT ", PKey: true}}},
+				posts,
+			}},
+			expected: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			if actual := changedTableSet(c.prev, c.ts); actual != c.expected {
+				t.Errorf("result doesn't match (expected=%t, actual=%t)", c.expected, actual)
+			}
+		})
+	}
+}
+
+// touch changes the modification time of the file.
+func touch(t *testing.T, name string, mt time.Time) {
+	if err := os.Chtimes(name, mt, mt); err != nil {
+		t.Fatalf("error must not occur: %v", err)
+	}
+}
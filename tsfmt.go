@@ -0,0 +1,167 @@
+package tdconv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// TypeScriptFormatter is a formatter to output the table definision as TypeScript interface.
+type TypeScriptFormatter struct {
+	formatter
+	keyNameFunc func(string) string
+}
+
+// NewTypeScriptFormatter creates a new TypeScriptFormatter.
+// You can change some parameters of the TypeScriptFormatter with TypeScriptFormatOption.
+func NewTypeScriptFormatter(options ...TypeScriptFormatOption) (*TypeScriptFormatter, error) {
+
+	f := TypeScriptFormatter{
+		keyNameFunc: func(s string) string {
+			return s
+		},
+	}
+	f.setHeader(func(w io.Writer, _ *TableSet) {
+		fmt.Fprint(w,
+			"// This file generated by tdconv. DO NOT EDIT.\n"+
+				"// See more details at https://github.com/takuoki/tdconv.\n\n")
+	})
+	for _, opt := range options {
+		err := opt(&f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+// TypeScriptFormatOption changes some parameters of the TypeScriptFormatter.
+type TypeScriptFormatOption func(*TypeScriptFormatter) error
+
+// TypeScriptHeader changes the header.
+func TypeScriptHeader(fc func(w io.Writer, ts *TableSet)) TypeScriptFormatOption {
+	return func(f *TypeScriptFormatter) error {
+		f.setHeader(fc)
+		return nil
+	}
+}
+
+// TypeScriptTableHeader changes the header of each table.
+func TypeScriptTableHeader(fc func(w io.Writer, t *Table)) TypeScriptFormatOption {
+	return func(f *TypeScriptFormatter) error {
+		f.setTableHeader(fc)
+		return nil
+	}
+}
+
+// TypeScriptTableFooter changes the footer of each table.
+func TypeScriptTableFooter(fc func(w io.Writer, t *Table)) TypeScriptFormatOption {
+	return func(f *TypeScriptFormatter) error {
+		f.setTableFooter(fc)
+		return nil
+	}
+}
+
+// TypeScriptFooter changes the footer.
+func TypeScriptFooter(fc func(w io.Writer, ts *TableSet)) TypeScriptFormatOption {
+	return func(f *TypeScriptFormatter) error {
+		f.setFooter(fc)
+		return nil
+	}
+}
+
+// TypeScriptTypeMap overrides the type conversion to TypeScript type.
+// The key is the base SQL type name, like `VARCHAR`, and it's case-insensitive.
+func TypeScriptTypeMap(m map[string]string) TypeScriptFormatOption {
+	return func(f *TypeScriptFormatter) error {
+		f.setTypeMap(m)
+		return nil
+	}
+}
+
+// TypeScriptKeyNameFunc changes the function to convert the column name to the property key.
+// By default, the column name is used as it is (snake_case).
+func TypeScriptKeyNameFunc(fc func(string) string) TypeScriptFormatOption {
+	return func(f *TypeScriptFormatter) error {
+		if fc == nil {
+			return errors.New("Key name function must not be nil")
+		}
+		f.keyNameFunc = fc
+		return nil
+	}
+}
+
+// TypeScriptCamelCase is an option to use camelCase for the property keys.
+func TypeScriptCamelCase() TypeScriptFormatOption {
+	return TypeScriptKeyNameFunc(strcase.ToLowerCamel)
+}
+
+// Extension returns the extension of TypeScript file.
+func (f *TypeScriptFormatter) Extension() string {
+	return "ts"
+}
+
+// Fprint outputs the table definision as TypeScript interface.
+func (f *TypeScriptFormatter) Fprint(w io.Writer, t *Table) {
+
+	if f == nil || t == nil {
+		return
+	}
+
+	fmt.Fprintf(w, "export interface %s {\n", strcase.ToCamel(t.Name))
+
+	for _, c := range t.Columns {
+		if c.Comment != "" {
+			fmt.Fprintf(w, "  /** %s */\n", strings.Replace(c.Comment, "*/", "*\\/", -1))
+		}
+		typ := f.convType(c.Type, convTSType)
+		if typ == "unknown" && !f.isMapped(c.Type) {
+			ReportError(w, typeError("TypeScript", t, c))
+		}
+		if !c.NotNull && !c.PKey {
+			typ += " | null"
+		}
+		fmt.Fprintf(w, "  %s: %s;\n", tsKey(f.keyNameFunc(c.Name)), typ)
+	}
+
+	fmt.Fprintln(w, "}")
+}
+
+func convTSType(t string) string {
+	st := parseSQLType(t)
+	var r string
+	switch st.name {
+	case "INT", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT", "DECIMAL", "FLOAT", "DOUBLE":
+		r = "number"
+	case "CHAR", "VARCHAR", "TEXT":
+		r = "string"
+	case "ENUM":
+		vs := make([]string, 0, len(st.args))
+		for _, a := range st.args {
+			vs = append(vs, "'"+strings.Replace(a, "'", "\\'", -1)+"'")
+		}
+		r = strings.Join(vs, " | ")
+		if r == "" {
+			r = "string"
+		}
+	case "BOOLEAN":
+		r = "boolean"
+	case "TIMESTAMP", "DATETIME", "DATE", "TIME":
+		r = "string"
+	default:
+		r = "unknown"
+	}
+	return r
+}
+
+func tsKey(k string) string {
+	for i, r := range k {
+		if !(r == '_' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || i > 0 && '0' <= r && r <= '9') {
+			return "'" + strings.Replace(k, "'", "\\'", -1) + "'"
+		}
+	}
+	return k
+}
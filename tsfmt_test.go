@@ -0,0 +1,219 @@
+package tdconv_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/takuoki/tdconv"
+)
+
+var mustTypeScriptFormatter = func(options ...tdconv.TypeScriptFormatOption) *tdconv.TypeScriptFormatter {
+	f, err := tdconv.NewTypeScriptFormatter(options...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func TestNewTypeScriptFormatter(t *testing.T) {
+
+	errOptionFunc := func(*tdconv.TypeScriptFormatter) error {
+		return errors.New("error")
+	}
+
+	cases := []struct {
+		caseName string
+		opts     []tdconv.TypeScriptFormatOption
+		errMsg   string
+	}{
+		{
+			caseName: "success: default",
+		},
+		{
+			caseName: "success: set all options",
+			opts: []tdconv.TypeScriptFormatOption{
+				tdconv.TypeScriptHeader(nil),
+				tdconv.TypeScriptTableHeader(nil),
+				tdconv.TypeScriptTableFooter(nil),
+				tdconv.TypeScriptFooter(nil),
+				tdconv.TypeScriptTypeMap(map[string]string{"json": "string"}),
+				tdconv.TypeScriptCamelCase(),
+			},
+		},
+		{
+			caseName: "failure: key name function is nil",
+			opts:     []tdconv.TypeScriptFormatOption{tdconv.TypeScriptKeyNameFunc(nil)},
+			errMsg:   "Key name function must not be nil",
+		},
+		{
+			caseName: "failure: option error",
+			opts:     []tdconv.TypeScriptFormatOption{errOptionFunc},
+			errMsg:   "error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+			_, err := tdconv.NewTypeScriptFormatter(c.opts...)
+
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+					return
+				}
+			} else {
+				if err == nil {
+					t.Errorf("error must occur")
+					return
+				}
+				if endIndex := strings.Index(err.Error(), ":"); endIndex < 0 {
+					if err.Error() != c.errMsg {
+						t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error())
+						return
+					}
+				} else if err.Error()[:endIndex] != c.errMsg {
+					t.Errorf("error message doesn't match (expected=%s, actual=%s)", c.errMsg, err.Error()[:endIndex])
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestTypeScriptFormatter_Extension(t *testing.T) {
+	var f *tdconv.TypeScriptFormatter
+	if f.Extension() != "ts" {
+		t.Errorf("value doesn't match (expected=ts, actual=%s)", f.Extension())
+	}
+}
+
+func TestTypeScriptFormatter_Fprint(t *testing.T) {
+
+	table := &tdconv.Table{
+		Name: "sample_table",
+		Columns: []tdconv.Column{
+			{Name: "id", Type: "INT UNSIGNED", PKey: true, NotNull: true, Unique: false, Index: false, Option: "AUTO_INCREMENT", Comment: "this is id!", IsCommon: false},
+			{Name: "foo", Type: "VARCHAR(32)", PKey: false, NotNull: true, Unique: true, Index: false, Option: "", Comment: "", IsCommon: false},
+			{Name: "bar", Type: "ENUM('a','b')", PKey: false, NotNull: false, Unique: false, Index: true, Option: "", Comment: "", IsCommon: false},
+			{Name: "baz", Type: "DOUBLE", PKey: false, NotNull: true, Unique: false, Index: false, Option: "", Comment: "", IsCommon: false},
+			{Name: "qux", Type: "BOOLEAN", PKey: false, NotNull: true, Unique: false, Index: false, Option: "", Comment: "", IsCommon: false},
+			{Name: "quux", Type: "LONGBLOB", PKey: false, NotNull: true, Unique: false, Index: false, Option: "", Comment: "", IsCommon: false},
+			{Name: "created_at", Type: "TIMESTAMP NULL", PKey: false, NotNull: false, Unique: false, Index: false, Option: "DEFAULT CURRENT_TIMESTAMP", Comment: "", IsCommon: true},
+		},
+		PKeyColumns: []string{"id"},
+		IndexKeys:   []tdconv.Key{{Name: "bar_key", Columns: []string{"bar"}}},
+	}
+
+	cases := []struct {
+		caseName string
+		f        *tdconv.TypeScriptFormatter
+		t        *tdconv.Table
+		expected string
+		errMsg   string
+	}{
+		{
+			caseName: "nil formatter",
+			f:        nil,
+			t:        table,
+			expected: "",
+		},
+		{
+			caseName: "table is nil",
+			f:        mustTypeScriptFormatter(),
+			t:        nil,
+			expected: "",
+		},
+		{
+			caseName: "standard output",
+			f:        mustTypeScriptFormatter(),
+			t:        table,
+			expected: "export interface SampleTable {\n" +
+				"  /** this is id! */\n" +
+				"  id: number;\n" +
+				"  foo: string;\n" +
+				"  bar: 'a' | 'b' | null;\n" +
+				"  baz: number;\n" +
+				"  qux: boolean;\n" +
+				"  quux: unknown;\n" +
+				"  created_at: string | null;\n" +
+				"}\n",
+			errMsg: "Unable to convert the type to TypeScript (table=sample_table, column=quux, type=LONGBLOB)",
+		},
+		{
+			caseName: "camel case",
+			f:        mustTypeScriptFormatter(tdconv.TypeScriptCamelCase()),
+			t:        table,
+			expected: "export interface SampleTable {\n" +
+				"  /** this is id! */\n" +
+				"  id: number;\n" +
+				"  foo: string;\n" +
+				"  bar: 'a' | 'b' | null;\n" +
+				"  baz: number;\n" +
+				"  qux: boolean;\n" +
+				"  quux: unknown;\n" +
+				"  createdAt: string | null;\n" +
+				"}\n",
+			errMsg: "Unable to convert the type to TypeScript (table=sample_table, column=quux, type=LONGBLOB)",
+		},
+		{
+			caseName: "type map",
+			f:        mustTypeScriptFormatter(tdconv.TypeScriptTypeMap(map[string]string{"longblob": "Uint8Array", "TIMESTAMP": "Date"})),
+			t:        table,
+			expected: "export interface SampleTable {\n" +
+				"  /** this is id! */\n" +
+				"  id: number;\n" +
+				"  foo: string;\n" +
+				"  bar: 'a' | 'b' | null;\n" +
+				"  baz: number;\n" +
+				"  qux: boolean;\n" +
+				"  quux: Uint8Array;\n" +
+				"  created_at: Date | null;\n" +
+				"}\n",
+		},
+		{
+			caseName: "type map to unknown",
+			f:        mustTypeScriptFormatter(tdconv.TypeScriptTypeMap(map[string]string{"LONGBLOB": "unknown"})),
+			t: &tdconv.Table{
+				Name:    "sample_table",
+				Columns: []tdconv.Column{{Name: "quux", Type: "LONGBLOB", NotNull: true}},
+			},
+			expected: "export interface SampleTable {\n" +
+				"  quux: unknown;\n" +
+				"}\n",
+		},
+		{
+			caseName: "key needs quote",
+			f:        mustTypeScriptFormatter(),
+			t: &tdconv.Table{
+				Name: "sample_table",
+				Columns: []tdconv.Column{
+					{Name: "1st-key", Type: "TEXT", NotNull: true},
+				},
+			},
+			expected: "export interface SampleTable {\n" +
+				"  '1st-key': string;\n" +
+				"}\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.caseName, func(t *testing.T) {
+
+			b := &bytes.Buffer{}
+			err := tdconv.FprintTable(b, c.f, nil, c.t)
+
+			if b.String() != c.expected {
+				t.Errorf("value doesn't match (expected=%s, actual=%s)", c.expected, b.String())
+			}
+			if c.errMsg == "" {
+				if err != nil {
+					t.Errorf("error must not occur: %v", err)
+				}
+			} else if err == nil || err.Error() != c.errMsg {
+				t.Errorf("error message doesn't match (expected=%s, actual=%v)", c.errMsg, err)
+			}
+		})
+	}
+}